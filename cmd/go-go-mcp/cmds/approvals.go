@@ -0,0 +1,305 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/go-go-golems/go-go-mcp/pkg/embeddable"
+	"github.com/go-go-golems/go-go-mcp/pkg/ui/tui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// approvalClient talks to the approval queue served by `server start`
+type approvalClient struct {
+	url      string
+	token    string
+	approver string
+}
+
+// NewApprovalsCommand returns the group for deciding tool calls waiting for
+// approval on a running server.
+func NewApprovalsCommand() *cobra.Command {
+	client := &approvalClient{}
+	cmd := &cobra.Command{
+		Use:   "approvals",
+		Short: "List, approve and deny tool calls waiting for approval",
+	}
+	cmd.PersistentFlags().StringVar(&client.url, "url", "http://localhost:3001", "Base URL of the approval queue (the server, or --approval-addr)")
+	cmd.PersistentFlags().StringVar(&client.token, "token", os.Getenv("GO_GO_MCP_APPROVAL_TOKEN"), "Approval token (as passed to --approval-token)")
+	cmd.PersistentFlags().StringVar(&client.approver, "approver", os.Getenv("USER"), "Name recorded as approver in the audit log")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List pending tool calls",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			requests, err := client.pending(cmd.Context())
+			if err != nil {
+				return err
+			}
+			for _, request := range requests {
+				arguments, _ := json.Marshal(request.Arguments)
+				fmt.Printf("%s\t%s\t%s\tsub=%s\tsession=%s\texpires=%s\t%s\n",
+					request.ID,
+					request.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+					request.Tool,
+					request.Subject,
+					request.SessionID,
+					request.Deadline.Local().Format("15:04:05"),
+					arguments,
+				)
+			}
+			return nil
+		},
+	})
+
+	for _, approve := range []bool{true, false} {
+		use, short := "approve ID", "Approve a pending tool call"
+		if !approve {
+			use, short = "deny ID", "Deny a pending tool call"
+		}
+		var reason string
+		decide := &cobra.Command{
+			Use:   use,
+			Short: short,
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				decision, err := client.decide(cmd.Context(), args[0], approve, reason)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s: %s\n", args[0], decision)
+				return nil
+			},
+		}
+		decide.Flags().StringVar(&reason, "reason", "", "Reason recorded with the decision")
+		cmd.AddCommand(decide)
+	}
+
+	var interval time.Duration
+	watch := &cobra.Command{
+		Use:   "watch",
+		Short: "Interactively approve or deny tool calls as they come in",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := tea.NewProgram(newApprovalsModel(cmd.Context(), client, interval), tea.WithAltScreen())
+			_, err := p.Run()
+			return err
+		},
+	}
+	watch.Flags().DurationVar(&interval, "interval", time.Second, "Polling interval for new requests")
+	cmd.AddCommand(watch)
+
+	return cmd
+}
+
+func (c *approvalClient) pending(ctx context.Context) ([]embeddable.ApprovalRequest, error) {
+	var requests []embeddable.ApprovalRequest
+	if err := c.do(ctx, http.MethodGet, "/approvals", nil, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (c *approvalClient) decide(ctx context.Context, id string, approve bool, reason string) (embeddable.ApprovalDecision, error) {
+	action := "deny"
+	if approve {
+		action = "approve"
+	}
+	body := map[string]string{"approver": c.approver, "reason": reason}
+
+	var decision embeddable.ApprovalDecision
+	err := c.do(ctx, http.MethodPost, "/approvals/"+id+"/"+action, body, &decision)
+	return decision, err
+}
+
+func (c *approvalClient) do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.url, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to reach approval queue")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("approval queue returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// approvalsModel polls the approval queue and asks about one pending call at
+// a time with a confirmation dialog.
+type approvalsModel struct {
+	ctx      context.Context
+	client   *approvalClient
+	interval time.Duration
+
+	pending []embeddable.ApprovalRequest
+	current *embeddable.ApprovalRequest
+	confirm tui.ConfirmModel
+	status  string
+}
+
+type approvalsPendingMsg struct {
+	requests []embeddable.ApprovalRequest
+	err      error
+}
+
+type approvalsTickMsg struct{}
+
+type approvalsDecidedMsg struct {
+	id       string
+	decision embeddable.ApprovalDecision
+	err      error
+}
+
+func newApprovalsModel(ctx context.Context, client *approvalClient, interval time.Duration) approvalsModel {
+	return approvalsModel{ctx: ctx, client: client, interval: interval}
+}
+
+func (m approvalsModel) Init() tea.Cmd {
+	return m.fetch
+}
+
+func (m approvalsModel) fetch() tea.Msg {
+	requests, err := m.client.pending(m.ctx)
+	return approvalsPendingMsg{requests: requests, err: err}
+}
+
+func (m approvalsModel) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(time.Time) tea.Msg {
+		return approvalsTickMsg{}
+	})
+}
+
+func (m approvalsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" || (m.current == nil && msg.String() == "q") {
+			return m, tea.Quit
+		}
+		if m.current != nil {
+			var cmd tea.Cmd
+			m.confirm, cmd = m.confirm.Update(msg)
+			return m, cmd
+		}
+
+	case approvalsPendingMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+		} else {
+			m.pending = msg.requests
+			if m.current != nil && !hasApprovalRequest(m.pending, m.current.ID) {
+				m.status = fmt.Sprintf("%s: no longer pending", m.current.ID)
+				m.current = nil
+			}
+			m.next()
+		}
+		return m, m.tick()
+
+	case approvalsTickMsg:
+		return m, m.fetch
+
+	case tui.ConfirmMsg:
+		if m.current == nil {
+			return m, nil
+		}
+		id, approve := m.current.ID, msg.Confirmed
+		m.current = nil
+		return m, func() tea.Msg {
+			decision, err := m.client.decide(m.ctx, id, approve, "")
+			return approvalsDecidedMsg{id: id, decision: decision, err: err}
+		}
+
+	case approvalsDecidedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("%s: %v", msg.id, msg.err)
+		} else {
+			m.status = fmt.Sprintf("%s: %s", msg.id, msg.decision)
+		}
+		m.pending = removeApprovalRequest(m.pending, msg.id)
+		m.next()
+	}
+	return m, nil
+}
+
+// next shows the oldest pending request, unless one is already shown
+func (m *approvalsModel) next() {
+	if m.current != nil || len(m.pending) == 0 {
+		return
+	}
+	request := m.pending[0]
+	m.current = &request
+
+	arguments, _ := json.MarshalIndent(request.Arguments, "", "  ")
+	message := fmt.Sprintf("Arguments: %s\nCaller: %s (client %s, session %s)\nExpires at %s",
+		arguments,
+		request.Subject,
+		request.ClientID,
+		request.SessionID,
+		request.Deadline.Local().Format("15:04:05"),
+	)
+	m.confirm = tui.NewConfirmModel(fmt.Sprintf("Run %s? (#%s)", request.Tool, request.ID), message)
+}
+
+func (m approvalsModel) View() string {
+	var b strings.Builder
+	if m.current != nil {
+		b.WriteString(m.confirm.View())
+		b.WriteString(fmt.Sprintf("\n\n%d more waiting\n", max(len(m.pending)-1, 0)))
+	} else {
+		b.WriteString("No tool calls waiting for approval (q to quit)\n")
+	}
+	if m.status != "" {
+		b.WriteString("\n" + m.status + "\n")
+	}
+	return b.String()
+}
+
+func hasApprovalRequest(requests []embeddable.ApprovalRequest, id string) bool {
+	for _, request := range requests {
+		if request.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func removeApprovalRequest(requests []embeddable.ApprovalRequest, id string) []embeddable.ApprovalRequest {
+	ret := requests[:0:0]
+	for _, request := range requests {
+		if request.ID != id {
+			ret = append(ret, request)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,188 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/embeddable"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type auditFlags struct {
+	db      string
+	file    string
+	tool    string
+	subject string
+	status  string
+	since   string
+	json    bool
+}
+
+// NewAuditCommand returns the group for querying the tool call audit log.
+func NewAuditCommand() *cobra.Command {
+	flags := &auditFlags{}
+	var limit, lines int
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query the audit log of tool calls (SQLite or JSONL)",
+	}
+	cmd.PersistentFlags().StringVar(&flags.db, "db", "", "SQLite audit DB path (as passed to --audit-db)")
+	cmd.PersistentFlags().StringVar(&flags.file, "file", "", "JSONL audit file path (as passed to --audit-file)")
+	cmd.PersistentFlags().StringVar(&flags.tool, "tool", "", "Only show calls to this tool")
+	cmd.PersistentFlags().StringVar(&flags.subject, "subject", "", "Only show calls by this subject")
+	cmd.PersistentFlags().StringVar(&flags.status, "status", "", "Only show calls with this status (ok, tool_error, error, denied, approved, rejected)")
+	cmd.PersistentFlags().StringVar(&flags.since, "since", "", "Only show calls since a duration ago (e.g. 1h) or an RFC3339 timestamp")
+	cmd.PersistentFlags().BoolVar(&flags.json, "json", false, "Print records as JSON lines")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded tool calls",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reader, closer, err := openAuditReader(flags)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = closer.Close()
+			}()
+
+			query, err := flags.query(limit)
+			if err != nil {
+				return err
+			}
+			records, err := reader.ListAuditRecords(cmd.Context(), query)
+			if err != nil {
+				return err
+			}
+			for _, record := range records {
+				printAuditRecord(record, flags.json)
+			}
+			return nil
+		},
+	}
+	list.Flags().IntVar(&limit, "limit", 50, "Maximum number of most recent records to show (0 for all)")
+	cmd.AddCommand(list)
+
+	var interval time.Duration
+	tail := &cobra.Command{
+		Use:   "tail",
+		Short: "Show the latest tool calls and follow new ones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reader, closer, err := openAuditReader(flags)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = closer.Close()
+			}()
+
+			query, err := flags.query(lines)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				records, err := reader.ListAuditRecords(ctx, query)
+				if err != nil {
+					if errors.Is(err, context.Canceled) {
+						return nil
+					}
+					return err
+				}
+				for _, record := range records {
+					printAuditRecord(record, flags.json)
+					query.AfterID = record.ID
+				}
+				// Only the initial batch is limited
+				query.Limit = 0
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	tail.Flags().IntVarP(&lines, "lines", "n", 10, "Number of most recent records to show before following")
+	tail.Flags().DurationVar(&interval, "interval", time.Second, "Polling interval for new records")
+	cmd.AddCommand(tail)
+
+	return cmd
+}
+
+func openAuditReader(flags *auditFlags) (embeddable.AuditReader, io.Closer, error) {
+	switch {
+	case flags.db != "" && flags.file != "":
+		return nil, nil, errors.New("--db and --file are mutually exclusive")
+	case flags.db != "":
+		// Don't create an empty database for a mistyped path
+		if _, err := os.Stat(flags.db); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to open audit database")
+		}
+		sink, err := embeddable.NewSQLiteAuditSink(flags.db)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sink, sink, nil
+	case flags.file != "":
+		sink := embeddable.OpenJSONLAuditFile(flags.file)
+		return sink, sink, nil
+	default:
+		return nil, nil, errors.New("one of --db or --file is required")
+	}
+}
+
+func (f *auditFlags) query(limit int) (embeddable.AuditQuery, error) {
+	query := embeddable.AuditQuery{
+		Tool:    f.tool,
+		Subject: f.subject,
+		Status:  embeddable.AuditStatus(f.status),
+		Limit:   limit,
+	}
+	if f.since != "" {
+		if d, err := time.ParseDuration(f.since); err == nil {
+			query.Since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, f.since); err == nil {
+			query.Since = t
+		} else {
+			return query, errors.Errorf("invalid --since value %q", f.since)
+		}
+	}
+	return query, nil
+}
+
+func printAuditRecord(record embeddable.AuditRecord, asJSON bool) {
+	if asJSON {
+		data, err := json.Marshal(record)
+		if err == nil {
+			fmt.Println(string(data))
+		}
+		return
+	}
+
+	args, _ := json.Marshal(record.Arguments)
+	fmt.Printf("%s\t%s\t%s\tsub=%s\tclient=%s\tsession=%s\t%dms\t%s\t%s\n",
+		record.Timestamp.Local().Format("2006-01-02 15:04:05"),
+		record.Status,
+		record.Tool,
+		record.Subject,
+		record.ClientID,
+		record.SessionID,
+		record.DurationMs,
+		args,
+		record.Result,
+	)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
 	"github.com/go-go-golems/go-go-mcp/cmd/go-go-mcp/cmds/client/layers"
 	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	mcp "github.com/mark3labs/mcp-go/mcp"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -49,18 +50,18 @@ func CreateClientFromSettings(parsedValues *values.Values) (*mcpclient.Client, e
 }
 
 func createClient(s *ClientSettings) (*mcpclient.Client, error) {
-	var c *mcpclient.Client
+	var t transport.Interface
 	var err error
 	switch s.Transport {
 	case "sse":
 		log.Debug().Msgf("Creating SSE client with server URL: %s", s.Server)
-		c, err = mcpclient.NewSSEMCPClient(s.Server)
+		t, err = transport.NewSSE(s.Server)
 		if err != nil {
 			return nil, err
 		}
 	case "streamable_http":
 		log.Debug().Msgf("Creating Streamable HTTP client with server URL: %s", s.Server)
-		c, err = mcpclient.NewStreamableHttpClient(s.Server)
+		t, err = transport.NewStreamableHTTP(s.Server)
 		if err != nil {
 			return nil, err
 		}
@@ -69,19 +70,29 @@ func createClient(s *ClientSettings) (*mcpclient.Client, error) {
 			return nil, fmt.Errorf("command is required for command transport")
 		}
 		log.Debug().Msgf("Creating stdio client to command: %v", s.Command)
-		c, err = mcpclient.NewStdioMCPClient(s.Command[0], nil, s.Command[1:]...)
-		if err != nil {
-			return nil, err
-		}
+		t = transport.NewStdio(s.Command[0], nil, s.Command[1:]...)
 	default:
 		return nil, fmt.Errorf("invalid transport type: %s", s.Transport)
 	}
 
-	// Start transport for non-stdio clients
-	if s.Transport != "command" {
-		if err := c.Start(context.Background()); err != nil {
-			return nil, fmt.Errorf("failed to start client transport: %w", err)
-		}
+	var options []mcpclient.ClientOption
+	if samplingHandler := newSamplingHandler(s); samplingHandler != nil {
+		options = append(options, mcpclient.WithSamplingHandler(samplingHandler))
+	}
+	roots, err := newRootsProvider(s)
+	if err != nil {
+		return nil, err
+	}
+	elicitation, err := newElicitationHandler(s)
+	if err != nil {
+		return nil, err
+	}
+	t = withServerRequests(t, roots, elicitation)
+
+	// Start also installs the handler for requests sent by the server
+	c := mcpclient.NewClient(t, options...)
+	if err := c.Start(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to start client transport: %w", err)
 	}
 
 	log.Debug().Msgf("Initializing client")
@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/client"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/rs/zerolog/log"
+)
+
+// newElicitationHandler returns nil if no elicitation responder is
+// configured.
+func newElicitationHandler(s *ClientSettings) (client.ElicitationHandler, error) {
+	switch {
+	case s.ElicitationResponse != "" && s.ElicitationInteractive:
+		return nil, fmt.Errorf("elicitation-response and elicitation-interactive are mutually exclusive")
+	case s.ElicitationResponse != "":
+		var content map[string]any
+		if err := json.Unmarshal([]byte(s.ElicitationResponse), &content); err != nil {
+			return nil, fmt.Errorf("elicitation-response must be a JSON object: %w", err)
+		}
+		return &scriptedElicitationHandler{content: content}, nil
+	case s.ElicitationInteractive:
+		return &interactiveElicitationHandler{in: bufio.NewReader(os.Stdin), out: os.Stderr}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// scriptedElicitationHandler accepts every elicitation request with the same
+// content, which makes it possible to test elicitation flows unattended.
+type scriptedElicitationHandler struct {
+	content map[string]any
+}
+
+var _ client.ElicitationHandler = &scriptedElicitationHandler{}
+
+func (h *scriptedElicitationHandler) Elicit(_ context.Context, request *protocol.ElicitationRequest) (*protocol.ElicitationResult, error) {
+	log.Debug().Str("message", request.Message).Msg("Received elicitation request")
+	return &protocol.ElicitationResult{Action: protocol.ElicitationActionAccept, Content: h.content}, nil
+}
+
+// interactiveElicitationHandler asks the user on the terminal whether to
+// accept an elicitation request, and for the value of each requested field.
+type interactiveElicitationHandler struct {
+	// mu keeps concurrent requests from interleaving their questions
+	mu  sync.Mutex
+	in  *bufio.Reader
+	out io.Writer
+}
+
+var _ client.ElicitationHandler = &interactiveElicitationHandler{}
+
+// elicitationSchema is the flat object schema elicitation requests use
+type elicitationSchema struct {
+	Properties map[string]struct {
+		Type        string `json:"type"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+func (h *interactiveElicitationHandler) Elicit(_ context.Context, request *protocol.ElicitationRequest) (*protocol.ElicitationResult, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var schema elicitationSchema
+	if len(request.RequestedSchema) > 0 {
+		if err := json.Unmarshal(request.RequestedSchema, &schema); err != nil {
+			return nil, fmt.Errorf("invalid requested schema: %w", err)
+		}
+	}
+
+	_, _ = fmt.Fprintf(h.out, "The server asks: %s\n", request.Message)
+	answer, err := h.ask("Answer? [y]es, [n]o (decline), [c]ancel: ")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+	case "n", "no":
+		return &protocol.ElicitationResult{Action: protocol.ElicitationActionDecline}, nil
+	default:
+		return &protocol.ElicitationResult{Action: protocol.ElicitationActionCancel}, nil
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	content := map[string]any{}
+	for _, name := range names {
+		property := schema.Properties[name]
+		required := contains(schema.Required, name)
+		label := name
+		if property.Title != "" {
+			label = property.Title
+		}
+		if property.Description != "" {
+			label += " - " + property.Description
+		}
+		if !required {
+			label += " (optional)"
+		}
+
+		for {
+			text, err := h.ask(fmt.Sprintf("%s [%s]: ", label, property.Type))
+			if err != nil {
+				return nil, err
+			}
+			if text == "" {
+				if !required {
+					break
+				}
+				_, _ = fmt.Fprintln(h.out, "A value is required")
+				continue
+			}
+			value, err := parseElicitationValue(property.Type, text)
+			if err != nil {
+				_, _ = fmt.Fprintf(h.out, "%v\n", err)
+				continue
+			}
+			content[name] = value
+			break
+		}
+	}
+
+	return &protocol.ElicitationResult{Action: protocol.ElicitationActionAccept, Content: content}, nil
+}
+
+// ask prints prompt and returns the next line of input, without surrounding
+// spaces.
+func (h *interactiveElicitationHandler) ask(prompt string) (string, error) {
+	_, _ = fmt.Fprint(h.out, prompt)
+	line, err := h.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// parseElicitationValue converts text to the primitive type of a field
+func parseElicitationValue(typ string, text string) (any, error) {
+	switch typ {
+	case "number":
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", text)
+		}
+		return v, nil
+	case "integer":
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", text)
+		}
+		return v, nil
+	case "boolean":
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean (true or false)", text)
+		}
+		return v, nil
+	default:
+		return text, nil
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/client"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/mark3labs/mcp-go/client/transport"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog/log"
+)
+
+// serverRequestTransport answers the roots/list and elicitation/create
+// requests the mcp-go client doesn't handle, and advertises the matching
+// capabilities in the initialize request. Other server requests, such as
+// sampling, are left to the mcp-go client.
+type serverRequestTransport struct {
+	transport.Interface
+	roots       client.RootsProvider
+	elicitation client.ElicitationHandler
+}
+
+var (
+	_ transport.BidirectionalInterface = &serverRequestTransport{}
+	_ transport.HTTPConnection         = &serverRequestTransport{}
+)
+
+// withServerRequests wraps t if roots or elicitation is set.
+func withServerRequests(t transport.Interface, roots client.RootsProvider, elicitation client.ElicitationHandler) transport.Interface {
+	if roots == nil && elicitation == nil {
+		return t
+	}
+	if _, ok := t.(transport.BidirectionalInterface); !ok {
+		log.Warn().Msg("Transport does not support requests from the server, roots and elicitation are not answered")
+		return t
+	}
+	return &serverRequestTransport{Interface: t, roots: roots, elicitation: elicitation}
+}
+
+func (t *serverRequestTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == string(mcp.MethodInitialize) {
+		params, err := t.addCapabilities(request.Params)
+		if err != nil {
+			return nil, err
+		}
+		request.Params = params
+	}
+	return t.Interface.SendRequest(ctx, request)
+}
+
+// addCapabilities adds the roots and elicitation capabilities to the params
+// of an initialize request.
+func (t *serverRequestTransport) addCapabilities(params any) (map[string]any, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal initialize params: %w", err)
+	}
+	ret := map[string]any{}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal initialize params: %w", err)
+	}
+	capabilities, _ := ret["capabilities"].(map[string]any)
+	if capabilities == nil {
+		capabilities = map[string]any{}
+	}
+	if t.roots != nil {
+		capabilities["roots"] = protocol.RootsCapability{}
+	}
+	if t.elicitation != nil {
+		capabilities["elicitation"] = protocol.ElicitationCapability{}
+	}
+	ret["capabilities"] = capabilities
+	return ret, nil
+}
+
+// SetRequestHandler installs a handler answering roots and elicitation
+// requests, and passing the other ones to handler.
+func (t *serverRequestTransport) SetRequestHandler(handler transport.RequestHandler) {
+	t.Interface.(transport.BidirectionalInterface).SetRequestHandler(func(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+		switch {
+		case request.Method == "roots/list" && t.roots != nil:
+			roots, err := t.roots.ListRoots(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if roots == nil {
+				roots = []protocol.Root{}
+			}
+			return resultResponse(request, protocol.ListRootsResult{Roots: roots})
+
+		case request.Method == "elicitation/create" && t.elicitation != nil:
+			var params protocol.ElicitationRequest
+			data, err := json.Marshal(request.Params)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal params: %w", err)
+			}
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal params: %w", err)
+			}
+			result, err := t.elicitation.Elicit(ctx, &params)
+			if err != nil {
+				return nil, err
+			}
+			return resultResponse(request, result)
+		}
+		return handler(ctx, request)
+	})
+}
+
+// SetProtocolVersion passes the negotiated protocol version on to HTTP
+// transports.
+func (t *serverRequestTransport) SetProtocolVersion(version string) {
+	if httpConn, ok := t.Interface.(transport.HTTPConnection); ok {
+		httpConn.SetProtocolVersion(version)
+	}
+}
+
+// SetConnectionLostHandler passes handler on to transports reporting lost
+// connections.
+func (t *serverRequestTransport) SetConnectionLostHandler(handler func(error)) {
+	type connectionLostSetter interface {
+		SetConnectionLostHandler(func(error))
+	}
+	if setter, ok := t.Interface.(connectionLostSetter); ok {
+		setter.SetConnectionLostHandler(handler)
+	}
+}
+
+func resultResponse(request transport.JSONRPCRequest, result any) (*transport.JSONRPCResponse, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return &transport.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      request.ID,
+		Result:  data,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/client"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+)
+
+// staticRoots answers roots/list requests with the directories given on the
+// command line.
+type staticRoots struct {
+	roots []protocol.Root
+}
+
+var _ client.RootsProvider = &staticRoots{}
+
+// newRootsProvider returns nil if no root is configured. Roots are either
+// file:// URIs or paths, which are made absolute.
+func newRootsProvider(s *ClientSettings) (client.RootsProvider, error) {
+	if len(s.Roots) == 0 {
+		return nil, nil
+	}
+
+	ret := &staticRoots{}
+	for _, root := range s.Roots {
+		if strings.HasPrefix(root, "file://") {
+			ret.roots = append(ret.roots, protocol.Root{URI: root, Name: filepath.Base(strings.TrimPrefix(root, "file://"))})
+			continue
+		}
+		path, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %s: %w", root, err)
+		}
+		uri := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+		ret.roots = append(ret.roots, protocol.Root{URI: uri.String(), Name: filepath.Base(path)})
+	}
+	return ret, nil
+}
+
+func (r *staticRoots) ListRoots(_ context.Context) ([]protocol.Root, error) {
+	return r.roots, nil
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog/log"
+)
+
+// scriptedSamplingHandler answers sampling requests from the server with a
+// fixed text or with the output of a command, which makes it possible to test
+// sampling flows without a full LLM client.
+type scriptedSamplingHandler struct {
+	response string
+	command  []string
+}
+
+var _ mcpclient.SamplingHandler = &scriptedSamplingHandler{}
+
+// newSamplingHandler returns nil if no sampling responder is configured.
+func newSamplingHandler(s *ClientSettings) mcpclient.SamplingHandler {
+	if len(s.SamplingCommand) == 0 && s.SamplingResponse == "" {
+		return nil
+	}
+	return &scriptedSamplingHandler{
+		response: s.SamplingResponse,
+		command:  s.SamplingCommand,
+	}
+}
+
+func (h *scriptedSamplingHandler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	log.Debug().Int("messages", len(request.Messages)).Msg("Received sampling request")
+
+	text := h.response
+	model := "go-go-mcp-scripted"
+	if len(h.command) > 0 {
+		input, err := json.Marshal(request.CreateMessageParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal sampling request: %w", err)
+		}
+
+		cmd := exec.CommandContext(ctx, h.command[0], h.command[1:]...)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stderr = os.Stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("sampling command failed: %w", err)
+		}
+		text = strings.TrimSpace(string(output))
+		model = h.command[0]
+	}
+
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{
+			Role:    mcp.RoleAssistant,
+			Content: mcp.NewTextContent(text),
+		},
+		Model:      model,
+		StopReason: "endTurn",
+	}, nil
+}
@@ -6,9 +6,16 @@ import (
 )
 
 type ClientSettings struct {
-	Transport string   `glazed:"transport"`
-	Server    string   `glazed:"server"`
-	Command   []string `glazed:"command"`
+	Transport        string   `glazed:"transport"`
+	Server           string   `glazed:"server"`
+	Command          []string `glazed:"command"`
+	SamplingResponse string   `glazed:"sampling-response"`
+	SamplingCommand  []string `glazed:"sampling-command"`
+	Roots            []string `glazed:"root"`
+	// ElicitationResponse is a JSON object accepted as the answer to every
+	// elicitation request
+	ElicitationResponse    string `glazed:"elicitation-response"`
+	ElicitationInteractive bool   `glazed:"elicitation-interactive"`
 }
 
 const ClientLayerSlug = "mcp-client"
@@ -34,6 +41,32 @@ func NewClientParameterLayer() (schema.Section, error) {
 				fields.WithHelp("Command and arguments for command transport (starts go-go-mcp in stdio mode per default)"),
 				fields.WithDefault([]string{"mcp", "server", "start", "--transport", "stdio"}),
 			),
+			fields.New(
+				"sampling-response",
+				fields.TypeString,
+				fields.WithHelp("Answer sampling/createMessage requests from the server with this fixed text"),
+			),
+			fields.New(
+				"sampling-command",
+				fields.TypeStringList,
+				fields.WithHelp("Answer sampling/createMessage requests by running this command with the request JSON on stdin, its output is used as the reply"),
+			),
+			fields.New(
+				"root",
+				fields.TypeStringList,
+				fields.WithHelp("Answer roots/list requests from the server with this directory (path or file:// URI), can be repeated"),
+			),
+			fields.New(
+				"elicitation-response",
+				fields.TypeString,
+				fields.WithHelp("Accept elicitation/create requests from the server with this JSON object as content"),
+			),
+			fields.New(
+				"elicitation-interactive",
+				fields.TypeBool,
+				fields.WithHelp("Ask on the terminal how to answer elicitation/create requests from the server"),
+				fields.WithDefault(false),
+			),
 		),
 	)
 }
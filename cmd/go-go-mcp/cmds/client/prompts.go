@@ -211,9 +211,29 @@ func (c *ExecutePromptCommand) RunIntoWriter(
 		return err
 	}
 
+	if res.Description != "" {
+		if _, err := fmt.Fprintf(w, "# %s\n\n", res.Description); err != nil {
+			return err
+		}
+	}
 	for _, message := range res.Messages {
-		if tc, ok := message.Content.(mcp.TextContent); ok {
-			_, _ = fmt.Fprintf(w, "%s: %s\n", message.Role, tc.Text)
+		switch c := message.Content.(type) {
+		case mcp.TextContent:
+			_, err = fmt.Fprintf(w, "%s: %s\n", message.Role, c.Text)
+		case mcp.ImageContent:
+			_, err = fmt.Fprintf(w, "%s: [image %s, %d bytes base64]\n", message.Role, c.MIMEType, len(c.Data))
+		case mcp.EmbeddedResource:
+			r := embeddedResourceContents(c)
+			if r.blob != "" {
+				_, err = fmt.Fprintf(w, "%s: [embedded resource %s %s, %d bytes base64]\n", message.Role, r.uri, r.mimeType, len(r.blob))
+			} else {
+				_, err = fmt.Fprintf(w, "%s: [embedded resource %s %s]\n%s\n", message.Role, r.uri, r.mimeType, r.text)
+			}
+		default:
+			_, err = fmt.Fprintf(w, "%s: [unknown content]\n", message.Role)
+		}
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -252,19 +272,61 @@ func (c *ExecutePromptCommand) RunIntoGlazeProcessor(
 	}
 
 	for _, message := range res.Messages {
-		if tc, ok := message.Content.(mcp.TextContent); ok {
-			row := types.NewRow(
-				types.MRP("role", message.Role),
-				types.MRP("text", tc.Text),
-			)
-			if err := gp.AddRow(ctx, row); err != nil {
-				return err
+		row := types.NewRow(
+			types.MRP("role", message.Role),
+			types.MRP("description", res.Description),
+		)
+		switch c := message.Content.(type) {
+		case mcp.TextContent:
+			row.Set("type", "text")
+			row.Set("text", c.Text)
+		case mcp.ImageContent:
+			row.Set("type", "image")
+			row.Set("mime", c.MIMEType)
+			row.Set("data", c.Data)
+		case mcp.EmbeddedResource:
+			r := embeddedResourceContents(c)
+			row.Set("type", "resource")
+			row.Set("uri", r.uri)
+			row.Set("mime", r.mimeType)
+			if r.blob != "" {
+				row.Set("data", r.blob)
+			} else {
+				row.Set("text", r.text)
 			}
+		default:
+			row.Set("type", "unknown")
+		}
+		if err := gp.AddRow(ctx, row); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// resourceContents are the fields of an embedded text or blob resource
+type resourceContents struct {
+	uri      string
+	mimeType string
+	text     string
+	blob     string
+}
+
+func embeddedResourceContents(r mcp.EmbeddedResource) resourceContents {
+	switch c := r.Resource.(type) {
+	case mcp.TextResourceContents:
+		return resourceContents{uri: c.URI, mimeType: c.MIMEType, text: c.Text}
+	case *mcp.TextResourceContents:
+		return resourceContents{uri: c.URI, mimeType: c.MIMEType, text: c.Text}
+	case mcp.BlobResourceContents:
+		return resourceContents{uri: c.URI, mimeType: c.MIMEType, blob: c.Blob}
+	case *mcp.BlobResourceContents:
+		return resourceContents{uri: c.URI, mimeType: c.MIMEType, blob: c.Blob}
+	default:
+		return resourceContents{}
+	}
+}
+
 func init() {
 	listCmd, err := NewListPromptsCommand()
 	cobra.CheckErr(err)
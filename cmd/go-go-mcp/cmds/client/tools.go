@@ -16,6 +16,7 @@ import (
 	"github.com/go-go-golems/glazed/pkg/types"
 	"github.com/go-go-golems/go-go-mcp/cmd/go-go-mcp/cmds/client/helpers"
 	"github.com/go-go-golems/go-go-mcp/cmd/go-go-mcp/cmds/client/layers"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
 	mcp "github.com/mark3labs/mcp-go/mcp"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -162,6 +163,7 @@ func (c *ListToolsCommand) RunIntoGlazeProcessor(
 		row := types.NewRow(
 			types.MRP("name", tool.Name),
 			types.MRP("description", tool.Description),
+			types.MRP("annotations", toolAnnotations(tool).String()),
 			types.MRP("schema", schemaObj),
 		)
 		if err := gp.AddRow(ctx, row); err != nil {
@@ -207,11 +209,27 @@ func (c *ListToolsCommand) RunIntoWriter(
 		return nil
 	}
 	for _, tool := range res.Tools {
-		_, _ = fmt.Fprintf(w, "- %s: %s\n", tool.Name, tool.Description)
+		if annotations := toolAnnotations(tool).String(); annotations != "" {
+			_, _ = fmt.Fprintf(w, "- %s: %s [%s]\n", tool.Name, tool.Description, annotations)
+		} else {
+			_, _ = fmt.Fprintf(w, "- %s: %s\n", tool.Name, tool.Description)
+		}
 	}
 	return nil
 }
 
+// toolAnnotations converts the annotations of an mcp-go tool
+func toolAnnotations(tool mcp.Tool) *protocol.ToolAnnotations {
+	a := tool.Annotations
+	return &protocol.ToolAnnotations{
+		Title:           a.Title,
+		ReadOnlyHint:    a.ReadOnlyHint,
+		DestructiveHint: a.DestructiveHint,
+		IdempotentHint:  a.IdempotentHint,
+		OpenWorldHint:   a.OpenWorldHint,
+	}
+}
+
 func (c *CallToolCommand) RunIntoWriter(
 	ctx context.Context,
 	parsedValues *values.Values,
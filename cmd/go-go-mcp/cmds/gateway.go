@@ -0,0 +1,165 @@
+package cmds
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/go-go-golems/go-go-mcp/pkg/embeddable"
+	"github.com/go-go-golems/go-go-mcp/pkg/gateway"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type GatewayCommandSettings struct {
+	Transport  string `glazed:"transport"`
+	Port       int    `glazed:"port"`
+	ConfigFile string `glazed:"config-file"`
+	Profile    string `glazed:"profile"`
+}
+
+type GatewayCommand struct {
+	*cmds.CommandDescription
+}
+
+func NewGatewayCommand() (*GatewayCommand, error) {
+	defaultConfigFile, err := config.GetDefaultProfilesPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get default profiles path")
+	}
+
+	return &GatewayCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"gateway",
+			cmds.WithShort("Aggregate several MCP servers behind one endpoint"),
+			cmds.WithLong(`Connect to the upstream MCP servers declared in the upstreams section of a
+profile and re-expose their tools, prompts and resources as a single server.
+
+Tool and prompt names are prefixed with the upstream name (or its configured
+prefix). When two upstreams expose the same name, the one declared first wins.
+
+Example:
+  go-go-mcp gateway --profile dev --transport sse --port 3001`),
+			cmds.WithFlags(
+				fields.New(
+					"transport",
+					fields.TypeString,
+					fields.WithHelp("Transport type (stdio, sse, or streamable_http)"),
+					fields.WithDefault("stdio"),
+				),
+				fields.New(
+					"port",
+					fields.TypeInteger,
+					fields.WithHelp("Port to listen on for SSE and streamable HTTP transport"),
+					fields.WithDefault(3001),
+				),
+				fields.New(
+					"config-file",
+					fields.TypeString,
+					fields.WithHelp("Configuration file declaring the upstream servers"),
+					fields.WithDefault(defaultConfigFile),
+				),
+				fields.New(
+					"profile",
+					fields.TypeString,
+					fields.WithHelp("Profile to use from configuration file (defaults to the default profile)"),
+					fields.WithDefault(""),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *GatewayCommand) Run(
+	ctx context.Context,
+	parsedValues *values.Values,
+) error {
+	s := &GatewayCommandSettings{}
+	if err := parsedValues.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadFromFile(s.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration file")
+	}
+
+	profile := s.Profile
+	if profile == "" {
+		profile = cfg.DefaultProfile
+	}
+	profileConfig, ok := cfg.Profiles[profile]
+	if !ok {
+		return errors.Errorf("profile %s not found", profile)
+	}
+	if len(profileConfig.Upstreams) == 0 {
+		return errors.Errorf("profile %s does not declare any upstreams", profile)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	gw, err := gateway.ConnectAll(ctx, profileConfig.Upstreams)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := gw.Close(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to close upstreams")
+		}
+	}()
+	if len(gw.Upstreams()) == 0 {
+		return errors.New("could not connect to any upstream")
+	}
+
+	options := []embeddable.ServerOption{
+		embeddable.WithName("go-go-mcp-gateway"),
+		embeddable.WithDefaultTransport(s.Transport),
+		embeddable.WithDefaultPort(s.Port),
+		embeddable.WithGateway(gw),
+	}
+	if len(profileConfig.Quotas) > 0 {
+		quotas := make([]embeddable.Quota, 0, len(profileConfig.Quotas))
+		for _, quota := range profileConfig.Quotas {
+			quotas = append(quotas, embeddable.Quota(quota))
+		}
+		options = append(options, embeddable.WithQuotas(quotas...))
+	}
+	if len(profileConfig.Policies) > 0 {
+		policies := make([]embeddable.ToolPolicy, 0, len(profileConfig.Policies))
+		for _, policy := range profileConfig.Policies {
+			policies = append(policies, embeddable.ToolPolicy(policy))
+		}
+		options = append(options, embeddable.WithToolPolicies(policies...))
+	}
+
+	serverConfig := embeddable.NewServerConfig()
+	for _, option := range options {
+		if err := option(serverConfig); err != nil {
+			return err
+		}
+	}
+
+	backend, err := embeddable.NewBackend(serverConfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to create backend")
+	}
+
+	log.Info().
+		Str("transport", s.Transport).
+		Int("port", s.Port).
+		Int("upstreams", len(gw.Upstreams())).
+		Msg("Starting gateway")
+
+	if err := backend.Start(ctx); err != nil && err != io.EOF && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
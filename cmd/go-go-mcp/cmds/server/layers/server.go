@@ -3,25 +3,40 @@ package layers
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/go-go-golems/glazed/pkg/cmds/fields"
 	"github.com/go-go-golems/glazed/pkg/cmds/schema"
 	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	prompt_config_provider "github.com/go-go-golems/go-go-mcp/pkg/prompts/providers/config-provider"
+	"github.com/go-go-golems/go-go-mcp/pkg/resources/providers/filesystem"
+	"github.com/go-go-golems/go-go-mcp/pkg/session"
 	config_provider "github.com/go-go-golems/go-go-mcp/pkg/tools/providers/config-provider"
 	"github.com/pkg/errors"
 )
 
 // ServerSettings contains settings for the server
 type ServerSettings struct {
-	ServerConfigFile string   `glazed:"server-config-file"`
-	Profile          string   `glazed:"profile"`
-	Directories      []string `glazed:"directories"`
-	Files            []string `glazed:"files"`
-	Debug            bool     `glazed:"debug"`
-	TracingDir       string   `glazed:"tracing-dir"`
-	Watch            bool     `glazed:"watch"`
-	ConvertDashes    bool     `glazed:"convert-dashes"`
-	InternalServers  []string `glazed:"internal-servers"`
+	ServerConfigFile   string   `glazed:"server-config-file"`
+	Profile            string   `glazed:"profile"`
+	Directories        []string `glazed:"directories"`
+	Files              []string `glazed:"files"`
+	Debug              bool     `glazed:"debug"`
+	TracingDir         string   `glazed:"tracing-dir"`
+	Watch              bool     `glazed:"watch"`
+	ConvertDashes      bool     `glazed:"convert-dashes"`
+	InternalServers    []string `glazed:"internal-servers"`
+	AuditDB            string   `glazed:"audit-db"`
+	AuditFile          string   `glazed:"audit-file"`
+	AuditLog           bool     `glazed:"audit-log"`
+	ApprovalTools      []string `glazed:"approval-tools"`
+	ApproveDestructive bool     `glazed:"approve-destructive"`
+	ApprovalTimeout    int      `glazed:"approval-timeout"`
+	ApprovalAddr       string   `glazed:"approval-addr"`
+	ApprovalToken      string   `glazed:"approval-token"`
+	SessionDB          string   `glazed:"session-db"`
+	SessionTTL         int      `glazed:"session-ttl"`
+	SessionIdleTimeout int      `glazed:"session-idle-timeout"`
 }
 
 const ServerLayerSlug = "mcp-server"
@@ -89,10 +104,97 @@ func NewServerParameterLayer() (schema.Section, error) {
 				fields.WithHelp("List of internal servers to register (comma-separated). Available: sqlite,fetch,echo,scholarly"),
 				fields.WithDefault([]string{}),
 			),
+			fields.New(
+				"audit-db",
+				fields.TypeString,
+				fields.WithHelp("SQLite DB path to record tool calls to"),
+				fields.WithDefault(""),
+			),
+			fields.New(
+				"audit-file",
+				fields.TypeString,
+				fields.WithHelp("JSONL file to record tool calls to"),
+				fields.WithDefault(""),
+			),
+			fields.New(
+				"audit-log",
+				fields.TypeBool,
+				fields.WithHelp("Log tool calls as structured log events"),
+				fields.WithDefault(false),
+			),
+			fields.New(
+				"approval-tools",
+				fields.TypeStringList,
+				fields.WithHelp("Tools (glob patterns) whose calls must be approved before they run"),
+				fields.WithDefault([]string{}),
+			),
+			fields.New(
+				"approve-destructive",
+				fields.TypeBool,
+				fields.WithHelp("Require approval for tools annotated as destructive"),
+				fields.WithDefault(true),
+			),
+			fields.New(
+				"approval-timeout",
+				fields.TypeInteger,
+				fields.WithHelp("Seconds to wait for an approval before rejecting the call"),
+				fields.WithDefault(300),
+			),
+			fields.New(
+				"approval-addr",
+				fields.TypeString,
+				fields.WithHelp("Address to serve the approval queue on (default: /approvals on the HTTP transports if --approval-token is set, localhost:3001 for stdio)"),
+				fields.WithDefault(""),
+			),
+			fields.New(
+				"approval-token",
+				fields.TypeString,
+				fields.WithHelp("Bearer token required by the approval queue, needed to serve it under /approvals"),
+				fields.WithDefault(""),
+			),
+			fields.New(
+				"session-db",
+				fields.TypeString,
+				fields.WithHelp("SQLite DB path to keep sessions in across restarts (default: in memory)"),
+				fields.WithDefault(""),
+			),
+			fields.New(
+				"session-ttl",
+				fields.TypeInteger,
+				fields.WithHelp("Seconds after which sessions expire (0 to keep them until the client disconnects)"),
+				fields.WithDefault(0),
+			),
+			fields.New(
+				"session-idle-timeout",
+				fields.TypeInteger,
+				fields.WithHelp("Seconds of inactivity after which sessions expire (0 to disable)"),
+				fields.WithDefault(0),
+			),
 		),
 	)
 }
 
+// CreateSessionStore creates the session store configured by the server
+// settings. The returned function stops the store's background eviction and
+// closes its database.
+func CreateSessionStore(serverSettings *ServerSettings) (session.SessionStore, func() error, error) {
+	opts := []session.StoreOption{
+		session.WithTTL(time.Duration(serverSettings.SessionTTL) * time.Second),
+		session.WithIdleTimeout(time.Duration(serverSettings.SessionIdleTimeout) * time.Second),
+	}
+
+	if serverSettings.SessionDB == "" {
+		store := session.NewInMemorySessionStore(opts...)
+		return store, store.Close, nil
+	}
+
+	store, err := session.NewSQLiteSessionStore(serverSettings.SessionDB, opts...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to open session store")
+	}
+	return store, store.Close, nil
+}
+
 // CreateToolProvider creates a tool provider from the given server settings
 func CreateToolProvider(serverSettings *ServerSettings) (*config_provider.ConfigToolProvider, error) {
 	// Create tool provider options
@@ -142,3 +244,100 @@ func CreateToolProvider(serverSettings *ServerSettings) (*config_provider.Config
 
 	return toolProvider, nil
 }
+
+// loadProfileConfig loads the configuration file and selected profile from the
+// server settings. It returns a nil profile if there is no configuration file
+// or no profile is selected.
+func loadProfileConfig(serverSettings *ServerSettings) (*config.Config, string, *config.Profile, error) {
+	if serverSettings.ServerConfigFile == "" {
+		return nil, "", nil, nil
+	}
+
+	cfg, err := config.LoadFromFile(serverSettings.ServerConfigFile)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, "", nil, nil
+		}
+		return nil, "", nil, errors.Wrap(err, "failed to load configuration file")
+	}
+
+	profile := serverSettings.Profile
+	if profile == "" {
+		profile = cfg.DefaultProfile
+	}
+	if profile == "" {
+		return nil, "", nil, nil
+	}
+
+	profileConfig, ok := cfg.Profiles[profile]
+	if !ok {
+		return nil, "", nil, errors.Errorf("profile %s not found", profile)
+	}
+
+	return cfg, profile, profileConfig, nil
+}
+
+// LoadQuotas returns the tool call quotas of the profile selected in the server settings, if any.
+func LoadQuotas(serverSettings *ServerSettings) ([]config.Quota, error) {
+	_, _, profileConfig, err := loadProfileConfig(serverSettings)
+	if err != nil {
+		return nil, err
+	}
+	if profileConfig == nil {
+		return nil, nil
+	}
+	return profileConfig.Quotas, nil
+}
+
+// LoadPolicies returns the tool authorization policies of the profile selected in the server settings, if any.
+func LoadPolicies(serverSettings *ServerSettings) ([]config.ToolPolicy, error) {
+	_, _, profileConfig, err := loadProfileConfig(serverSettings)
+	if err != nil {
+		return nil, err
+	}
+	if profileConfig == nil {
+		return nil, nil
+	}
+	return profileConfig.Policies, nil
+}
+
+// CreatePromptProvider creates a prompt provider from the profile selected in the server settings.
+// It returns nil if there is no configuration file or the profile does not declare any prompts.
+func CreatePromptProvider(serverSettings *ServerSettings) (*prompt_config_provider.ConfigPromptProvider, error) {
+	cfg, profile, profileConfig, err := loadProfileConfig(serverSettings)
+	if err != nil {
+		return nil, err
+	}
+	if profileConfig == nil || profileConfig.Prompts == nil {
+		return nil, nil
+	}
+
+	promptProvider, err := prompt_config_provider.NewConfigPromptProvider(
+		cfg, profile,
+		prompt_config_provider.WithWatch(serverSettings.Watch),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create prompt provider from config")
+	}
+
+	return promptProvider, nil
+}
+
+// CreateResourceProvider creates a file-system resource provider from the profile selected in the server settings.
+// It returns nil if there is no configuration file or the profile does not declare any resources.
+func CreateResourceProvider(serverSettings *ServerSettings) (*filesystem.FileSystemResourceProvider, error) {
+	cfg, profile, profileConfig, err := loadProfileConfig(serverSettings)
+	if err != nil {
+		return nil, err
+	}
+	if profileConfig == nil || profileConfig.Resources == nil {
+		return nil, nil
+	}
+
+	resourceProvider, err := filesystem.NewFileSystemResourceProvider(filesystem.WithConfig(cfg, profile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create resource provider from config")
+	}
+
+	return resourceProvider, nil
+}
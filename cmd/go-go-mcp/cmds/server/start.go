@@ -15,9 +15,7 @@ import (
 	"github.com/go-go-golems/go-go-mcp/cmd/go-go-mcp/cmds/server/layers"
 	"github.com/go-go-golems/go-go-mcp/pkg"
 	"github.com/go-go-golems/go-go-mcp/pkg/embeddable"
-	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
 	"github.com/go-go-golems/go-go-mcp/pkg/resources"
-	"github.com/go-go-golems/go-go-mcp/pkg/tools"
 	tool_registry "github.com/go-go-golems/go-go-mcp/pkg/tools/providers/tool-registry"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -108,29 +106,34 @@ func (c *StartCommand) Run(
 	var toolProvider pkg.ToolProvider = configToolProvider
 	_ = toolProvider
 
-	// Create resource provider (not yet wired into mcp-go backend)
-	_ = resources.NewRegistry()
+	// Create prompt provider from the profile configuration, if any
+	promptProvider, err := layers.CreatePromptProvider(serverSettings)
+	if err != nil {
+		return err
+	}
+
+	// Create resource providers: the registry for programmatic resources and
+	// the file-system provider from the profile configuration, if any
+	resourceRegistry := resources.NewRegistry()
+	fileResourceProvider, err := layers.CreateResourceProvider(serverSettings)
+	if err != nil {
+		return err
+	}
 
 	// Build a registry adapter that proxies calls to the tool provider
 	reg := tool_registry.NewRegistry()
-	// List tools once at startup and register into our registry with proxy handler
-	toolsList, _, err := toolProvider.ListTools(ctx, "")
-	if err != nil {
-		return errors.Wrap(err, "failed to list tools from provider")
+	if err := reg.SyncFromProvider(ctx, toolProvider); err != nil {
+		return err
 	}
-	logger.Debug().Int("tool_count", len(toolsList)).Msg("Registering tools from provider")
-	for _, t := range toolsList {
-		toolImpl, err := tools.NewToolImpl(t.Name, t.Description, t.InputSchema)
-		if err != nil {
-			return errors.Wrapf(err, "failed to create tool %s", t.Name)
-		}
-		name := t.Name
-		logger.Debug().Str("tool", name).Str("description", t.Description).Msg("Registering tool")
-		reg.RegisterToolWithHandler(toolImpl, func(ctx context.Context, _ tools.Tool, arguments map[string]interface{}) (*protocol.ToolResult, error) {
-			logger.Debug().Str("tool", name).Interface("args", arguments).Msg("Invoking tool via provider")
-			return toolProvider.CallTool(ctx, name, arguments)
-		})
+	toolsList, _, err := reg.ListTools(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "failed to list registered tools")
 	}
+	logger.Debug().Int("tool_count", len(toolsList)).Msg("Registered tools from provider")
+
+	// Subscribe before the watcher starts so that no change is missed
+	toolChanges, unsubscribe := configToolProvider.SubscribeToChanges()
+	defer unsubscribe()
 
 	// Create embeddable server config
 	cfg := embeddable.NewServerConfig()
@@ -138,10 +141,83 @@ func (c *StartCommand) Run(
 	_ = embeddable.WithDefaultTransport(transportType)(cfg)
 	_ = embeddable.WithDefaultPort(port)(cfg)
 	_ = embeddable.WithToolRegistry(reg)(cfg)
+	_ = embeddable.WithResourceProvider(resourceRegistry)(cfg)
+	if fileResourceProvider != nil {
+		_ = embeddable.WithResourceProvider(fileResourceProvider)(cfg)
+	}
+	if promptProvider != nil {
+		_ = embeddable.WithPromptProvider(promptProvider)(cfg)
+	}
 	if len(serverSettings.InternalServers) > 0 {
 		_ = embeddable.WithInternalServers(serverSettings.InternalServers...)(cfg)
 	}
 
+	// Record tool calls to the configured audit sinks
+	auditSinks, closeAudit, err := embeddable.OpenAuditSinks(embeddable.AuditSettings{
+		DBPath:   serverSettings.AuditDB,
+		FilePath: serverSettings.AuditFile,
+		Log:      serverSettings.AuditLog,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to open audit sinks")
+	}
+	defer closeAudit()
+	for _, sink := range auditSinks {
+		_ = embeddable.WithAuditSink(sink)(cfg)
+	}
+
+	// Keep per-client session state, optionally across restarts
+	sessionStore, closeSessions, err := layers.CreateSessionStore(serverSettings)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeSessions() }()
+	_ = embeddable.WithSessionStore(sessionStore)(cfg)
+
+	// Rate limit tool calls as configured in the profile
+	quotas, err := layers.LoadQuotas(serverSettings)
+	if err != nil {
+		return err
+	}
+	if len(quotas) > 0 {
+		embeddableQuotas := make([]embeddable.Quota, 0, len(quotas))
+		for _, quota := range quotas {
+			embeddableQuotas = append(embeddableQuotas, embeddable.Quota(quota))
+		}
+		if err := embeddable.WithQuotas(embeddableQuotas...)(cfg); err != nil {
+			return errors.Wrap(err, "invalid quotas")
+		}
+	}
+
+	// Restrict tools to the callers allowed by the profile's policies
+	policies, err := layers.LoadPolicies(serverSettings)
+	if err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		embeddablePolicies := make([]embeddable.ToolPolicy, 0, len(policies))
+		for _, policy := range policies {
+			embeddablePolicies = append(embeddablePolicies, embeddable.ToolPolicy(policy))
+		}
+		if err := embeddable.WithToolPolicies(embeddablePolicies...)(cfg); err != nil {
+			return errors.Wrap(err, "invalid policies")
+		}
+	}
+
+	// Hold destructive and explicitly listed tools until someone approves them
+	if serverSettings.ApproveDestructive || len(serverSettings.ApprovalTools) > 0 {
+		err = embeddable.WithApproval(embeddable.ApprovalSettings{
+			Tools:       serverSettings.ApprovalTools,
+			Destructive: serverSettings.ApproveDestructive,
+			Timeout:     time.Duration(serverSettings.ApprovalTimeout) * time.Second,
+			ListenAddr:  serverSettings.ApprovalAddr,
+			Token:       serverSettings.ApprovalToken,
+		})(cfg)
+		if err != nil {
+			return errors.Wrap(err, "failed to configure tool approval")
+		}
+	}
+
 	// Create backend
 	backend, err := embeddable.NewBackend(cfg)
 	if err != nil {
@@ -172,6 +248,37 @@ func (c *StartCommand) Run(
 		return nil
 	})
 
+	// Watch prompt directories; the backend picks up changed prompts and
+	// notifies clients with notifications/prompts/list_changed.
+	if promptProvider != nil {
+		g.Go(func() error {
+			if err := promptProvider.Watch(gctx); err != nil {
+				if !errors.Is(err, context.Canceled) {
+					logger.Error().Err(err).Msg("failed to run prompt watcher")
+				}
+				return err
+			}
+			return nil
+		})
+	}
+
+	// Re-sync the registry whenever the watcher picks up changed tool files.
+	// The backend then adds/removes the tools on the running server and
+	// notifies clients with notifications/tools/list_changed.
+	g.Go(func() error {
+		for {
+			select {
+			case <-gctx.Done():
+				return nil
+			case <-toolChanges:
+				logger.Info().Msg("Tool definitions changed, reloading")
+				if err := reg.SyncFromProvider(gctx, toolProvider); err != nil {
+					logger.Error().Err(err).Msg("failed to reload tools")
+				}
+			}
+		}
+	})
+
 	// Start backend
 	g.Go(func() error {
 		defer cancel()
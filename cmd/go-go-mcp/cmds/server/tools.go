@@ -146,6 +146,7 @@ func (c *ListToolsCommand) RunIntoGlazeProcessor(
 		row := types.NewRow(
 			types.MRP("name", tool.Name),
 			types.MRP("description", tool.Description),
+			types.MRP("annotations", tool.Annotations.String()),
 			types.MRP("schema", schemaObj),
 		)
 		if err := gp.AddRow(ctx, row); err != nil {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-go-golems/go-go-mcp/pkg/cmds"
 	"github.com/go-go-golems/go-go-mcp/pkg/doc"
+	"github.com/go-go-golems/go-go-mcp/pkg/sandbox"
 
 	clay "github.com/go-go-golems/clay/pkg"
 
@@ -84,6 +85,22 @@ func initRootCmd() (*help.HelpSystem, error) {
 
 	rootCmd.AddCommand(server_cmds.ServerCmd)
 
+	// Add gateway command aggregating several upstream servers
+	gatewayCmd, err := mcp_cmds.NewGatewayCommand()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create gateway command")
+	}
+	cobraGatewayCmd, err := cli.BuildCobraCommandFromCommand(
+		gatewayCmd,
+		cli.WithParserConfig(cli.CobraParserConfig{
+			SkipCommandSettingsSection: true,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not build gateway command")
+	}
+	rootCmd.AddCommand(cobraGatewayCmd)
+
 	rootCmd.AddCommand(runCommandCmd)
 
 	// Create and add schema command
@@ -114,10 +131,19 @@ func initRootCmd() (*help.HelpSystem, error) {
 	oidcCmd := mcp_cmds.NewOIDCCommand()
 	rootCmd.AddCommand(oidcCmd)
 
+	// Add audit log query group
+	rootCmd.AddCommand(mcp_cmds.NewAuditCommand())
+
+	// Add tool call approval group
+	rootCmd.AddCommand(mcp_cmds.NewApprovalsCommand())
+
 	return helpSystem, nil
 }
 
 func main() {
+	// Sandboxed shell commands re-execute this binary as their sandbox helper
+	sandbox.MaybeRunHelper()
+
 	// first, check if the args are "run-command file.yaml",
 	// because we need to load the file and then run the command itself.
 	// we need to do this before cobra, because we don't know which flags to load yet
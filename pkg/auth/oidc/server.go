@@ -591,18 +591,7 @@ func (s *Server) InitSQLite(path string) error {
     );`); err != nil {
 		return err
 	}
-	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS mcp_tool_calls (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        ts TIMESTAMP NOT NULL,
-        subject TEXT,
-        client_id TEXT,
-        request_id TEXT,
-        tool_name TEXT NOT NULL,
-        args_json TEXT,
-        result_json TEXT,
-        status TEXT NOT NULL,
-        duration_ms INTEGER NOT NULL
-    );`); err != nil {
+	if err := MigrateMCPCallTable(db); err != nil {
 		return err
 	}
 
@@ -992,6 +981,7 @@ type MCPCallLog struct {
 	Timestamp  time.Time
 	Subject    string
 	ClientID   string
+	SessionID  string
 	RequestID  string
 	ToolName   string
 	ArgsJSON   string
@@ -1000,6 +990,68 @@ type MCPCallLog struct {
 	DurationMs int64
 }
 
+// MigrateMCPCallTable creates the mcp_tool_calls table in db, or adds the
+// columns that older versions didn't have.
+func MigrateMCPCallTable(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS mcp_tool_calls (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        ts TIMESTAMP NOT NULL,
+        subject TEXT,
+        client_id TEXT,
+        request_id TEXT,
+        tool_name TEXT NOT NULL,
+        args_json TEXT,
+        result_json TEXT,
+        status TEXT NOT NULL,
+        duration_ms INTEGER NOT NULL,
+        session_id TEXT
+    );`); err != nil {
+		return err
+	}
+
+	rows, err := db.Query(`PRAGMA table_info(mcp_tool_calls)`)
+	if err != nil {
+		return err
+	}
+	hasSessionID := false
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultValue, &pk); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if name == "session_id" {
+			hasSessionID = true
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if !hasSessionID {
+		if _, err := db.Exec(`ALTER TABLE mcp_tool_calls ADD COLUMN session_id TEXT`); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_ts ON mcp_tool_calls (ts)`)
+	return err
+}
+
+// InsertMCPCall adds entry to the mcp_tool_calls table of db.
+func InsertMCPCall(ctx context.Context, db *sql.DB, entry MCPCallLog) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO mcp_tool_calls (ts, subject, client_id, session_id, request_id, tool_name, args_json, result_json, status, duration_ms)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Timestamp.UTC(), entry.Subject, entry.ClientID, entry.SessionID, entry.RequestID, entry.ToolName, entry.ArgsJSON, entry.ResultJSON, entry.Status, entry.DurationMs)
+	return err
+}
+
 func (s *Server) LogMCPCall(entry MCPCallLog) error {
 	if s.dbPath == "" {
 		return fosite.ErrServerError.WithHint("db not enabled")
@@ -1013,11 +1065,5 @@ func (s *Server) LogMCPCall(entry MCPCallLog) error {
 			log.Error().Err(err).Msg("failed to close db")
 		}
 	}()
-	if entry.Timestamp.IsZero() {
-		entry.Timestamp = time.Now()
-	}
-	_, err = db.Exec(`INSERT INTO mcp_tool_calls (ts, subject, client_id, request_id, tool_name, args_json, result_json, status, duration_ms)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		entry.Timestamp, entry.Subject, entry.ClientID, entry.RequestID, entry.ToolName, entry.ArgsJSON, entry.ResultJSON, entry.Status, entry.DurationMs)
-	return err
+	return InsertMCPCall(context.Background(), db, entry)
 }
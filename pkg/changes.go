@@ -0,0 +1,61 @@
+package pkg
+
+import "sync"
+
+// ChangeNotifier signals subscribers that a list of tools, prompts or
+// resources changed. Providers embed it to implement SubscribeToChanges and
+// call NotifyChanged whenever their list changes. The zero value is ready to
+// use.
+type ChangeNotifier struct {
+	mu          sync.Mutex
+	subscribers []chan struct{}
+}
+
+// SubscribeToChanges returns a channel that receives a signal whenever the
+// list changes, along with a cleanup function that unsubscribes and closes
+// the channel. Signals are coalesced: a slow reader sees at most one pending
+// signal.
+func (n *ChangeNotifier) SubscribeToChanges() (chan struct{}, func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	n.subscribers = append(n.subscribers, ch)
+
+	cleanup := func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		for i, sub := range n.subscribers {
+			if sub == ch {
+				n.subscribers = append(n.subscribers[:i], n.subscribers[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+
+	return ch, cleanup
+}
+
+// NotifyChanged signals all subscribers without blocking.
+func (n *ChangeNotifier) NotifyChanged() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, ch := range n.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// CloseSubscribers closes the channels of all subscribers, for providers
+// that shut down. Their cleanup functions then do nothing.
+func (n *ChangeNotifier) CloseSubscribers() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, ch := range n.subscribers {
+		close(ch)
+	}
+	n.subscribers = nil
+}
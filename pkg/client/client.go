@@ -19,6 +19,8 @@ type Transport interface {
 	Close(ctx context.Context) error
 	// SetNotificationHandler sets a handler for notifications
 	SetNotificationHandler(handler func(*protocol.Response))
+	// SetRequestHandler sets a handler for requests initiated by the server
+	SetRequestHandler(handler RequestHandler)
 	// SetCookies sets the cookies to be used for requests
 	SetCookies(cookies []*http.Cookie)
 	// GetCookies returns the current cookies
@@ -30,27 +32,46 @@ type Client struct {
 	mu        sync.Mutex
 	logger    zerolog.Logger
 	transport Transport
-	nextID    int
+
+	// idMu guards nextID so that requests can be issued concurrently
+	idMu   sync.Mutex
+	nextID int
 
 	// Client capabilities declared during initialization
 	capabilities protocol.ClientCapabilities
 	// Server capabilities received during initialization
 	serverCapabilities protocol.ServerCapabilities
 	initialized        bool
+
+	// Handlers for requests initiated by the server
+	samplingHandler    SamplingHandler
+	rootsProvider      RootsProvider
+	elicitationHandler ElicitationHandler
+
+	// notificationHandler receives the notifications sent by the server
+	notificationHandler func(*protocol.Response)
 }
 
 // NewClient creates a new client instance
-func NewClient(logger zerolog.Logger, transport Transport) *Client {
+func NewClient(logger zerolog.Logger, transport Transport, options ...ClientOption) *Client {
 	client := &Client{
 		logger:    logger,
 		transport: transport,
 		nextID:    1,
 	}
 
-	// Set default notification handler to log notifications
+	for _, option := range options {
+		option(client)
+	}
+
+	// Log notifications before passing them on
 	transport.SetNotificationHandler(func(response *protocol.Response) {
 		logger.Debug().Interface("notification", response).Msg("Received notification")
+		if client.notificationHandler != nil {
+			client.notificationHandler(response)
+		}
 	})
+	transport.SetRequestHandler(client.handleServerRequest)
 
 	return client
 }
@@ -66,6 +87,8 @@ func (c *Client) Initialize(ctx context.Context, capabilities protocol.ClientCap
 		return fmt.Errorf("client already initialized")
 	}
 
+	capabilities = c.withHandlerCapabilities(capabilities)
+
 	params := protocol.InitializeParams{
 		ProtocolVersion: "2024-11-05",
 		Capabilities:    capabilities,
@@ -157,8 +180,9 @@ func (c *Client) ListPrompts(ctx context.Context, cursor string) ([]protocol.Pro
 	return result.Prompts, result.NextCursor, nil
 }
 
-// GetPrompt retrieves a specific prompt from the server
-func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*protocol.PromptMessage, error) {
+// GetPrompt retrieves a specific prompt from the server, rendered with the
+// given arguments
+func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*protocol.GetPromptResult, error) {
 	if !c.initialized {
 		return nil, fmt.Errorf("client not initialized")
 	}
@@ -187,9 +211,7 @@ func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[strin
 		return nil, fmt.Errorf("server returned error: %s", response.Error.Message)
 	}
 
-	var result struct {
-		Messages []protocol.PromptMessage `json:"messages"`
-	}
+	var result protocol.GetPromptResult
 	if err := json.Unmarshal(response.Result, &result); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal prompts/get result: %w", err)
 	}
@@ -198,7 +220,7 @@ func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[strin
 		return nil, fmt.Errorf("server returned no messages")
 	}
 
-	return &result.Messages[0], nil
+	return &result, nil
 }
 
 // ListResources retrieves the list of available resources from the server
@@ -281,6 +303,44 @@ func (c *Client) ReadResource(ctx context.Context, uri string) (*protocol.Resour
 	return &result.Contents[0], nil
 }
 
+// ListResourceTemplates retrieves the list of available resource templates from the server
+func (c *Client) ListResourceTemplates(ctx context.Context, cursor string) ([]protocol.ResourceTemplate, string, error) {
+	if !c.initialized {
+		return nil, "", fmt.Errorf("client not initialized")
+	}
+
+	params := map[string]string{}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
+
+	request := &protocol.Request{
+		JSONRPC: "2.0",
+		Method:  "resources/templates/list",
+		Params:  mustMarshal(params),
+	}
+	c.setRequestID(request)
+
+	response, err := c.transport.Send(ctx, request)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to send resources/templates/list request: %w", err)
+	}
+
+	if response.Error != nil {
+		return nil, "", fmt.Errorf("server returned error: %s", response.Error.Message)
+	}
+
+	var result struct {
+		ResourceTemplates []protocol.ResourceTemplate `json:"resourceTemplates"`
+		NextCursor        string                      `json:"nextCursor"`
+	}
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal resources/templates/list result: %w", err)
+	}
+
+	return result.ResourceTemplates, result.NextCursor, nil
+}
+
 // ListTools retrieves the list of available tools from the server
 func (c *Client) ListTools(ctx context.Context, cursor string) ([]protocol.Tool, string, error) {
 	if !c.initialized {
@@ -419,6 +479,13 @@ func (c *Client) Ping(ctx context.Context) error {
 	return nil
 }
 
+// ServerCapabilities returns the capabilities announced by the server during initialization
+func (c *Client) ServerCapabilities() protocol.ServerCapabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.serverCapabilities
+}
+
 // Close closes the client connection
 func (c *Client) Close(ctx context.Context) error {
 	c.logger.Debug().Msg("closing client")
@@ -433,8 +500,10 @@ func (c *Client) setRequestID(request *protocol.Request) {
 
 	// According to MCP spec, request IDs can be either numbers or strings
 	// We'll use numbers for simplicity and compatibility
+	c.idMu.Lock()
 	request.ID = json.RawMessage(fmt.Sprintf("%d", c.nextID))
 	c.nextID++
+	c.idMu.Unlock()
 
 	c.logger.Debug().
 		Str("method", request.Method).
@@ -0,0 +1,213 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+)
+
+// RequestHandler answers a request sent by the server to the client, such as
+// sampling/createMessage or roots/list. Returning nil answers the request
+// with a method not found error.
+type RequestHandler func(ctx context.Context, request *protocol.Request) *protocol.Response
+
+// SamplingHandler answers sampling/createMessage requests from the server,
+// typically by forwarding them to an LLM.
+type SamplingHandler interface {
+	CreateMessage(ctx context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResponse, error)
+}
+
+// RootsProvider answers roots/list requests from the server.
+type RootsProvider interface {
+	ListRoots(ctx context.Context) ([]protocol.Root, error)
+}
+
+// ElicitationHandler answers elicitation/create requests from the server,
+// typically by asking the user to fill in the requested schema.
+type ElicitationHandler interface {
+	Elicit(ctx context.Context, request *protocol.ElicitationRequest) (*protocol.ElicitationResult, error)
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithSamplingHandler answers sampling requests with handler and advertises
+// the sampling capability during initialization.
+func WithSamplingHandler(handler SamplingHandler) ClientOption {
+	return func(c *Client) {
+		c.samplingHandler = handler
+	}
+}
+
+// WithRootsProvider answers roots/list requests with provider and advertises
+// the roots capability during initialization.
+func WithRootsProvider(provider RootsProvider) ClientOption {
+	return func(c *Client) {
+		c.rootsProvider = provider
+	}
+}
+
+// WithElicitationHandler answers elicitation requests with handler and
+// advertises the elicitation capability during initialization.
+func WithElicitationHandler(handler ElicitationHandler) ClientOption {
+	return func(c *Client) {
+		c.elicitationHandler = handler
+	}
+}
+
+// WithNotificationHandler passes the notifications sent by the server, such
+// as notifications/tools/list_changed, to handler. Their method and params
+// are set in the Method and Params fields.
+func WithNotificationHandler(handler func(*protocol.Response)) ClientOption {
+	return func(c *Client) {
+		c.notificationHandler = handler
+	}
+}
+
+// handleServerRequest dispatches a server-to-client request to the
+// configured handlers.
+func (c *Client) handleServerRequest(ctx context.Context, request *protocol.Request) *protocol.Response {
+	c.logger.Debug().Str("method", request.Method).Msg("Received request from server")
+
+	switch request.Method {
+	case "ping":
+		return resultResponse(struct{}{})
+
+	case "sampling/createMessage":
+		if c.samplingHandler == nil {
+			return nil
+		}
+		var params protocol.CreateMessageRequest
+		if err := unmarshalParams(request, &params); err != nil {
+			return errorResponse(protocol.ErrCodeInvalidParams, err)
+		}
+		result, err := c.samplingHandler.CreateMessage(ctx, &params)
+		if err != nil {
+			return errorResponse(protocol.ErrCodeInternalError, err)
+		}
+		return resultResponse(result)
+
+	case "roots/list":
+		if c.rootsProvider == nil {
+			return nil
+		}
+		roots, err := c.rootsProvider.ListRoots(ctx)
+		if err != nil {
+			return errorResponse(protocol.ErrCodeInternalError, err)
+		}
+		if roots == nil {
+			roots = []protocol.Root{}
+		}
+		return resultResponse(protocol.ListRootsResult{Roots: roots})
+
+	case "elicitation/create":
+		if c.elicitationHandler == nil {
+			return nil
+		}
+		var params protocol.ElicitationRequest
+		if err := unmarshalParams(request, &params); err != nil {
+			return errorResponse(protocol.ErrCodeInvalidParams, err)
+		}
+		result, err := c.elicitationHandler.Elicit(ctx, &params)
+		if err != nil {
+			return errorResponse(protocol.ErrCodeInternalError, err)
+		}
+		return resultResponse(result)
+	}
+
+	return nil
+}
+
+// NotifyRootsChanged tells the server that the list of roots has changed, so
+// that it can request it again.
+func (c *Client) NotifyRootsChanged(ctx context.Context) error {
+	if !c.initialized {
+		return fmt.Errorf("client not initialized")
+	}
+
+	notification := &protocol.Request{
+		JSONRPC: "2.0",
+		Method:  "notifications/roots/list_changed",
+	}
+	if _, err := c.transport.Send(ctx, notification); err != nil {
+		return fmt.Errorf("failed to send roots/list_changed notification: %w", err)
+	}
+	return nil
+}
+
+// withHandlerCapabilities adds the capabilities backed by the configured
+// handlers to capabilities.
+func (c *Client) withHandlerCapabilities(capabilities protocol.ClientCapabilities) protocol.ClientCapabilities {
+	if c.samplingHandler != nil && capabilities.Sampling == nil {
+		capabilities.Sampling = &protocol.SamplingCapability{}
+	}
+	if c.rootsProvider != nil && capabilities.Roots == nil {
+		capabilities.Roots = &protocol.RootsCapability{ListChanged: true}
+	}
+	if c.elicitationHandler != nil && capabilities.Elicitation == nil {
+		capabilities.Elicitation = &protocol.ElicitationCapability{}
+	}
+	return capabilities
+}
+
+func unmarshalParams(request *protocol.Request, v interface{}) error {
+	if len(request.Params) == 0 {
+		return fmt.Errorf("missing params for %s", request.Method)
+	}
+	if err := json.Unmarshal(request.Params, v); err != nil {
+		return fmt.Errorf("invalid params for %s: %w", request.Method, err)
+	}
+	return nil
+}
+
+func resultResponse(result interface{}) *protocol.Response {
+	return &protocol.Response{Result: mustMarshal(result)}
+}
+
+func errorResponse(code int, err error) *protocol.Response {
+	return &protocol.Response{
+		Error: &protocol.Error{
+			Code:    code,
+			Message: err.Error(),
+		},
+	}
+}
+
+// parseServerRequest returns the request contained in data if it is a
+// request initiated by the server, i.e. it has both a method and an id.
+func parseServerRequest(data []byte) (*protocol.Request, bool) {
+	var message struct {
+		ID     json.RawMessage `json:"id,omitempty"`
+		Method string          `json:"method,omitempty"`
+		Params json.RawMessage `json:"params,omitempty"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, false
+	}
+	if message.Method == "" || len(message.ID) == 0 || string(message.ID) == "null" {
+		return nil, false
+	}
+	return &protocol.Request{
+		JSONRPC: "2.0",
+		ID:      message.ID,
+		Method:  message.Method,
+		Params:  message.Params,
+	}, true
+}
+
+// answerServerRequest runs handler for request and returns the response to
+// send back, answering with method not found if nobody handles it.
+func answerServerRequest(ctx context.Context, handler RequestHandler, request *protocol.Request) *protocol.Response {
+	var response *protocol.Response
+	if handler != nil {
+		response = handler(ctx, request)
+	}
+	if response == nil {
+		response = errorResponse(protocol.ErrCodeMethodNotFound, fmt.Errorf("method not found: %s", request.Method))
+	}
+	response.JSONRPC = "2.0"
+	response.ID = request.ID
+	return response
+}
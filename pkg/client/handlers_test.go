@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type testSamplingHandler struct{}
+
+func (testSamplingHandler) CreateMessage(_ context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResponse, error) {
+	return &protocol.CreateMessageResponse{
+		Role:    "assistant",
+		Content: protocol.MessageContent{Type: "text", Text: "echo: " + request.Messages[0].Content.Text},
+		Model:   "test-model",
+	}, nil
+}
+
+type testRootsProvider struct{}
+
+func (testRootsProvider) ListRoots(context.Context) ([]protocol.Root, error) {
+	return []protocol.Root{{URI: "file:///src", Name: "src"}}, nil
+}
+
+type testElicitationHandler struct{}
+
+func (testElicitationHandler) Elicit(context.Context, *protocol.ElicitationRequest) (*protocol.ElicitationResult, error) {
+	return nil, errors.New("user went away")
+}
+
+func TestClientHandlesServerRequests(t *testing.T) {
+	transport, peer := newStdioPeer(t)
+	client := NewClient(zerolog.Nop(), transport,
+		WithSamplingHandler(testSamplingHandler{}),
+		WithRootsProvider(testRootsProvider{}),
+		WithElicitationHandler(testElicitationHandler{}),
+	)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.Initialize(context.Background(), protocol.ClientCapabilities{})
+	}()
+
+	// The handlers are advertised as client capabilities
+	initialize := peer.read()
+	require.Equal(t, "initialize", initialize.Method)
+	var params protocol.InitializeParams
+	require.NoError(t, json.Unmarshal(initialize.Params, &params))
+	require.NotNil(t, params.Capabilities.Sampling)
+	require.NotNil(t, params.Capabilities.Elicitation)
+	require.Equal(t, &protocol.RootsCapability{ListChanged: true}, params.Capabilities.Roots)
+
+	peer.write(`{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":"2024-11-05","capabilities":{}}}`, initialize.ID)
+	require.Equal(t, "notifications/initialized", peer.read().Method)
+	require.NoError(t, <-errCh)
+
+	// Server-to-client requests are answered through the transport
+	peer.write(`{"jsonrpc":"2.0","id":"s-1","method":"sampling/createMessage","params":{"messages":[{"role":"user","content":{"type":"text","text":"hi"}}],"maxTokens":10}}`)
+	peer.write(`{"jsonrpc":"2.0","id":2,"method":"roots/list"}`)
+	peer.write(`{"jsonrpc":"2.0","id":3,"method":"elicitation/create","params":{"message":"Approve?","requestedSchema":{"type":"object"}}}`)
+	peer.write(`{"jsonrpc":"2.0","id":4,"method":"sampling/createMessage"}`)
+	peer.write(`{"jsonrpc":"2.0","id":5,"method":"unknown/method"}`)
+
+	responses := map[string]incomingMessage{}
+	for i := 0; i < 5; i++ {
+		response := peer.read()
+		responses[string(response.ID)] = response
+	}
+
+	sampling := responses[`"s-1"`]
+	require.Nil(t, sampling.Error)
+	var message protocol.CreateMessageResponse
+	require.NoError(t, json.Unmarshal(sampling.Result, &message))
+	require.Equal(t, "echo: hi", message.Content.Text)
+	require.Equal(t, "test-model", message.Model)
+
+	require.JSONEq(t, `{"roots":[{"uri":"file:///src","name":"src"}]}`, string(responses["2"].Result))
+
+	require.Equal(t, protocol.ErrCodeInternalError, responses["3"].Error.Code)
+	require.Equal(t, "user went away", responses["3"].Error.Message)
+
+	require.Equal(t, protocol.ErrCodeInvalidParams, responses["4"].Error.Code)
+
+	require.Equal(t, protocol.ErrCodeMethodNotFound, responses["5"].Error.Code)
+}
+
+func TestClientWithoutHandlers(t *testing.T) {
+	transport, peer := newStdioPeer(t)
+	client := NewClient(zerolog.Nop(), transport)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.Initialize(context.Background(), protocol.ClientCapabilities{})
+	}()
+
+	initialize := peer.read()
+	var params protocol.InitializeParams
+	require.NoError(t, json.Unmarshal(initialize.Params, &params))
+	require.Equal(t, protocol.ClientCapabilities{}, params.Capabilities)
+
+	peer.write(`{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":"2024-11-05","capabilities":{}}}`, initialize.ID)
+	peer.read()
+	require.NoError(t, <-errCh)
+
+	// Requests for capabilities the client didn't advertise are rejected
+	peer.write(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`)
+	response := peer.read()
+	require.Equal(t, protocol.ErrCodeMethodNotFound, response.Error.Code)
+}
+
+func TestClientNotificationHandler(t *testing.T) {
+	transport, peer := newStdioPeer(t)
+	notifications := make(chan *protocol.Response, 1)
+	NewClient(zerolog.Nop(), transport, WithNotificationHandler(func(notification *protocol.Response) {
+		notifications <- notification
+	}))
+
+	// Reading starts with the first request
+	go func() {
+		_, _ = transport.Send(context.Background(), request(1, "ping"))
+	}()
+	peer.read()
+	peer.write(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed","params":{"reason":"reload"}}`)
+
+	notification := <-notifications
+	require.Equal(t, "notifications/tools/list_changed", notification.Method)
+	require.JSONEq(t, `{"reason":"reload"}`, string(notification.Params))
+}
@@ -17,7 +17,9 @@ import (
 
 // SSETransport implements Transport using Server-Sent Events
 type SSETransport struct {
-	mu                  sync.Mutex
+	mu sync.Mutex
+	// sendMu serializes request round trips, responses are matched in order
+	sendMu              sync.Mutex
 	baseURL             string
 	client              *http.Client
 	sseClient           *sse.Client
@@ -31,6 +33,7 @@ type SSETransport struct {
 	endpoint            string
 	subscriptionCancel  context.CancelFunc
 	notificationHandler func(*protocol.Response)
+	requestHandler      RequestHandler
 	cookies             []*http.Cookie
 }
 
@@ -54,6 +57,13 @@ func (t *SSETransport) SetNotificationHandler(handler func(*protocol.Response))
 	t.notificationHandler = handler
 }
 
+// SetRequestHandler sets the handler for requests initiated by the server
+func (t *SSETransport) SetRequestHandler(handler RequestHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requestHandler = handler
+}
+
 // isNotification checks if an event is a notification
 func isNotification(event *sse.Event) bool {
 	var response protocol.Response
@@ -79,17 +89,19 @@ func (t *SSETransport) GetCookies() []*http.Cookie {
 
 // Send sends a request and returns the response
 func (t *SSETransport) Send(ctx context.Context, request *protocol.Request) (*protocol.Response, error) {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+
 	t.mu.Lock()
-	if !t.initialized {
-		t.mu.Unlock()
+	initialized := t.initialized
+	t.mu.Unlock()
+	if !initialized {
 		t.logger.Debug().Msg("Initializing SSE connection")
 		if err := t.initializeSSE(ctx); err != nil {
 			t.logger.Error().Err(err).Msg("Failed to initialize SSE")
 			return nil, fmt.Errorf("failed to initialize SSE: %w", err)
 		}
-		t.mu.Lock()
 	}
-	defer t.mu.Unlock()
 
 	t.logger.Debug().
 		Str("method", request.Method).
@@ -103,25 +115,7 @@ func (t *SSETransport) Send(ctx context.Context, request *protocol.Request) (*pr
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	t.logger.Debug().
-		Str("url", t.endpoint).
-		RawJSON("request", reqBody).
-		Msg("Sending HTTP POST request")
-
-	// Create a new request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", t.endpoint, bytes.NewReader(reqBody))
-	if err != nil {
-		t.logger.Error().Err(err).Msg("Failed to create HTTP request")
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Add cookies to the request
-	for _, cookie := range t.cookies {
-		req.AddCookie(cookie)
-	}
-
-	resp, err := t.client.Do(req)
+	resp, err := t.post(ctx, reqBody)
 	if err != nil {
 		t.logger.Error().Err(err).Msg("Failed to send HTTP request")
 		return nil, fmt.Errorf("failed to send request: %w", err)
@@ -177,6 +171,61 @@ func (t *SSETransport) Send(ctx context.Context, request *protocol.Request) (*pr
 	}
 }
 
+// post sends a JSON-RPC message to the endpoint announced by the server
+func (t *SSETransport) post(ctx context.Context, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	endpoint := t.endpoint
+	cookies := append([]*http.Cookie{}, t.cookies...)
+	t.mu.Unlock()
+
+	t.logger.Debug().
+		Str("url", endpoint).
+		RawJSON("request", body).
+		Msg("Sending HTTP POST request")
+
+	// Create a new request with context
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		t.logger.Error().Err(err).Msg("Failed to create HTTP request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Add cookies to the request
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	return t.client.Do(req)
+}
+
+// handleRequest answers a server-to-client request
+func (t *SSETransport) handleRequest(ctx context.Context, request *protocol.Request) {
+	t.mu.Lock()
+	handler := t.requestHandler
+	t.mu.Unlock()
+
+	response := answerServerRequest(ctx, handler, request)
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.logger.Error().Err(err).Str("method", request.Method).Msg("Failed to marshal response")
+		return
+	}
+
+	resp, err := t.post(ctx, body)
+	if err != nil {
+		t.logger.Error().Err(err).Str("method", request.Method).Msg("Failed to answer server request")
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		t.logger.Error().
+			Int("status", resp.StatusCode).
+			Str("method", request.Method).
+			Msg("Server rejected response to its request")
+	}
+}
+
 // initializeSSE sets up the SSE connection
 func (t *SSETransport) initializeSSE(ctx context.Context) error {
 	t.logger.Debug().Str("url", t.baseURL+"/sse").Msg("Setting up SSE connection")
@@ -240,6 +289,12 @@ func (t *SSETransport) initializeSSE(ctx context.Context) error {
 				}
 			}
 
+			// Requests initiated by the server are answered in the background
+			if request, ok := parseServerRequest(msg.Data); ok {
+				go t.handleRequest(subCtx, request)
+				return
+			}
+
 			// Route event to appropriate channel
 			if isNotification(msg) {
 				select {
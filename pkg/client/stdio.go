@@ -17,28 +17,47 @@ import (
 	"github.com/rs/zerolog"
 )
 
-// StdioTransport implements Transport using standard input/output
+// StdioTransport implements Transport using standard input/output.
+//
+// A single reader goroutine consumes everything the server writes and
+// dispatches responses by JSON-RPC ID to the pending Send calls, so several
+// requests can be in flight at once. Notifications are passed to the
+// notification handler and server-to-client requests to the request handler.
 type StdioTransport struct {
 	mu                  sync.Mutex
+	writeMu             sync.Mutex
 	scanner             *bufio.Scanner
 	writer              *json.Encoder
 	cmd                 *exec.Cmd
 	logger              zerolog.Logger
 	notificationHandler func(*protocol.Response)
+	requestHandler      RequestHandler
 	cookies             []*http.Cookie
+
+	readerOnce sync.Once
+	pending    map[string]chan *protocol.Response
+	readErr    error
+	done       chan struct{}
 }
 
 // NewStdioTransport creates a new stdio transport
 func NewStdioTransport(logger zerolog.Logger) *StdioTransport {
-	scanner := bufio.NewScanner(os.Stdin)
+	return newStdioTransport(logger, os.Stdin, os.Stdout, nil)
+}
+
+func newStdioTransport(logger zerolog.Logger, r io.Reader, w io.Writer, cmd *exec.Cmd) *StdioTransport {
+	scanner := bufio.NewScanner(r)
 	// Set 1MB buffer size to avoid "token too long" errors
 	buf := make([]byte, 1024*1024)
 	scanner.Buffer(buf, len(buf))
 
 	return &StdioTransport{
 		scanner: scanner,
-		writer:  json.NewEncoder(os.Stdout),
+		writer:  json.NewEncoder(w),
+		cmd:     cmd,
 		logger:  logger,
+		pending: map[string]chan *protocol.Response{},
+		done:    make(chan struct{}),
 	}
 }
 
@@ -49,6 +68,13 @@ func (t *StdioTransport) SetNotificationHandler(handler func(*protocol.Response)
 	t.notificationHandler = handler
 }
 
+// SetRequestHandler sets the handler for requests initiated by the server
+func (t *StdioTransport) SetRequestHandler(handler RequestHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requestHandler = handler
+}
+
 // SetCookies sets the cookies to be used for requests
 func (t *StdioTransport) SetCookies(cookies []*http.Cookie) {
 	t.mu.Lock()
@@ -67,7 +93,19 @@ var _ Transport = &StdioTransport{}
 
 // NewCommandStdioTransport creates a new stdio transport that launches a command
 func NewCommandStdioTransport(logger zerolog.Logger, command string, args ...string) (*StdioTransport, error) {
+	return NewCommandStdioTransportWithEnv(logger, nil, command, args...)
+}
+
+// NewCommandStdioTransportWithEnv creates a new stdio transport that launches a
+// command with env added to the environment of the current process
+func NewCommandStdioTransportWithEnv(logger zerolog.Logger, env map[string]string, command string, args ...string) (*StdioTransport, error) {
 	cmd := exec.Command(command, args...)
+	if len(env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
 
 	logger.Debug().
 		Str("command", command).
@@ -103,95 +141,197 @@ func NewCommandStdioTransport(logger zerolog.Logger, command string, args ...str
 		Int("pid", cmd.Process.Pid).
 		Msg("Command started successfully in new process group")
 
-	return &StdioTransport{
-		scanner: bufio.NewScanner(stdout),
-		writer:  json.NewEncoder(stdin),
-		cmd:     cmd,
-		logger:  logger,
-	}, nil
+	return newStdioTransport(logger, stdout, stdin, cmd), nil
 }
 
-// Send sends a request and returns the response
+// Send sends a request and returns the response. It is safe to call Send
+// concurrently; responses are matched to requests by their ID.
 func (t *StdioTransport) Send(ctx context.Context, request *protocol.Request) (*protocol.Response, error) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+	t.readerOnce.Do(func() {
+		go t.readLoop()
+	})
 
 	t.logger.Debug().
 		Str("method", request.Method).
 		Interface("params", request.Params).
 		Msg("Sending request")
 
-	// Write request
-	if err := t.writer.Encode(request); err != nil {
-		t.logger.Error().Err(err).Msg("Failed to write request")
-		return nil, fmt.Errorf("failed to write request: %w", err)
-	}
-
 	// If this is a notification, don't wait for response
 	if len(request.ID) == 0 || string(request.ID) == "null" || strings.HasPrefix(request.Method, "notifications/") {
+		if err := t.write(request); err != nil {
+			return nil, err
+		}
 		t.logger.Debug().Msg("Request is a notification, not waiting for response")
 		return nil, nil
 	}
 
-	t.logger.Debug().Msg("Waiting for response")
+	// Register the waiter before writing, so that a fast response isn't lost
+	id := string(request.ID)
+	responseCh := make(chan *protocol.Response, 1)
+	t.mu.Lock()
+	if t.readErr != nil {
+		err := t.readErr
+		t.mu.Unlock()
+		return nil, err
+	}
+	if _, ok := t.pending[id]; ok {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("request with id %s is already in flight", id)
+	}
+	t.pending[id] = responseCh
+	t.mu.Unlock()
 
-	// Create a channel for the response
-	responseCh := make(chan struct {
-		response *protocol.Response
-		err      error
-	}, 1)
+	if err := t.write(request); err != nil {
+		t.removePending(id)
+		return nil, err
+	}
 
-	// Read response in a goroutine
-	go func() {
-		for t.scanner.Scan() {
-			data := t.scanner.Bytes()
-			t.logger.Debug().RawJSON("data", data).Msg("Received data")
+	t.logger.Debug().Str("id", id).Msg("Waiting for response")
 
-			var response protocol.Response
-			if err := json.Unmarshal(data, &response); err != nil {
-				t.logger.Error().Err(err).Msg("Failed to parse response")
-				continue
-			}
+	// Wait for either response or context cancellation
+	select {
+	case response := <-responseCh:
+		return response, nil
+	case <-t.done:
+		// The reader may have delivered the response right before stopping
+		select {
+		case response := <-responseCh:
+			return response, nil
+		default:
+		}
+		t.mu.Lock()
+		err := t.readErr
+		t.mu.Unlock()
+		return nil, err
+	case <-ctx.Done():
+		t.logger.Debug().Str("id", id).Msg("Context cancelled while waiting for response")
+		t.removePending(id)
+		t.sendCancelled(request.ID, ctx.Err())
+		return nil, ctx.Err()
+	}
+}
 
-			// If this is a notification, handle it and continue scanning
-			if len(response.ID) == 0 || string(response.ID) == "null" {
-				t.mu.Lock()
-				if t.notificationHandler != nil {
-					t.notificationHandler(&response)
+// write encodes a single message to the server
+func (t *StdioTransport) write(message interface{}) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if err := t.writer.Encode(message); err != nil {
+		t.logger.Error().Err(err).Msg("Failed to write request")
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+	return nil
+}
+
+func (t *StdioTransport) removePending(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, id)
+}
+
+// sendCancelled tells the server that the client is no longer waiting for
+// the request with the given id.
+func (t *StdioTransport) sendCancelled(id json.RawMessage, reason error) {
+	notification := &protocol.Request{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params: mustMarshal(map[string]interface{}{
+			"requestId": id,
+			"reason":    reason.Error(),
+		}),
+	}
+	if err := t.write(notification); err != nil {
+		t.logger.Warn().Err(err).Msg("Failed to send cancellation notification")
+	}
+}
+
+// incomingMessage is the union of the messages a server can send
+type incomingMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *protocol.Error `json:"error,omitempty"`
+}
+
+// readLoop reads all messages from the server and dispatches them until the
+// stream ends. Pending requests are failed once the stream is closed.
+func (t *StdioTransport) readLoop() {
+	for t.scanner.Scan() {
+		data := t.scanner.Bytes()
+		t.logger.Debug().RawJSON("data", data).Msg("Received data")
+
+		var message incomingMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			t.logger.Error().Err(err).Msg("Failed to parse response")
+			continue
+		}
+
+		hasID := len(message.ID) > 0 && string(message.ID) != "null"
+		switch {
+		case message.Method != "" && hasID:
+			request := &protocol.Request{
+				JSONRPC: message.JSONRPC,
+				ID:      message.ID,
+				Method:  message.Method,
+				Params:  message.Params,
+			}
+			go t.handleRequest(request)
+
+		case !hasID:
+			t.mu.Lock()
+			handler := t.notificationHandler
+			t.mu.Unlock()
+			if handler != nil {
+				var notification protocol.Response
+				if err := json.Unmarshal(data, &notification); err != nil {
+					t.logger.Error().Err(err).Msg("Failed to parse notification")
+					continue
 				}
-				t.mu.Unlock()
-				continue
+				handler(&notification)
 			}
 
-			// This is a response, send it to the channel
-			responseCh <- struct {
-				response *protocol.Response
-				err      error
-			}{&response, nil}
-			return
+		default:
+			id := string(message.ID)
+			t.mu.Lock()
+			responseCh, ok := t.pending[id]
+			delete(t.pending, id)
+			t.mu.Unlock()
+			if !ok {
+				t.logger.Debug().Str("id", id).Msg("Dropping response for unknown or cancelled request")
+				continue
+			}
+			responseCh <- &protocol.Response{
+				JSONRPC: message.JSONRPC,
+				ID:      message.ID,
+				Result:  message.Result,
+				Error:   message.Error,
+			}
 		}
+	}
 
-		// Handle scanner errors
-		if err := t.scanner.Err(); err != nil {
-			responseCh <- struct {
-				response *protocol.Response
-				err      error
-			}{nil, fmt.Errorf("failed to read response: %w", err)}
-		} else {
-			responseCh <- struct {
-				response *protocol.Response
-				err      error
-			}{nil, io.EOF}
-		}
-	}()
+	err := io.EOF
+	if scanErr := t.scanner.Err(); scanErr != nil {
+		err = fmt.Errorf("failed to read response: %w", scanErr)
+	}
+	t.logger.Debug().Err(err).Msg("Reader stopped")
 
-	// Wait for either response or context cancellation
-	select {
-	case result := <-responseCh:
-		return result.response, result.err
-	case <-ctx.Done():
-		t.logger.Debug().Msg("Context cancelled while waiting for response")
-		return nil, ctx.Err()
+	t.mu.Lock()
+	t.readErr = err
+	t.pending = map[string]chan *protocol.Response{}
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// handleRequest answers a server-to-client request
+func (t *StdioTransport) handleRequest(request *protocol.Request) {
+	t.mu.Lock()
+	handler := t.requestHandler
+	t.mu.Unlock()
+
+	response := answerServerRequest(context.Background(), handler, request)
+	if err := t.write(response); err != nil {
+		t.logger.Error().Err(err).Str("method", request.Method).Msg("Failed to answer server request")
 	}
 }
 
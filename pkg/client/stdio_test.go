@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// stdioPeer is the server end of a StdioTransport connected through pipes
+type stdioPeer struct {
+	t      *testing.T
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+func newStdioPeer(t *testing.T) (*StdioTransport, *stdioPeer) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	t.Cleanup(func() {
+		_ = serverWriter.Close()
+		_ = clientWriter.Close()
+	})
+
+	transport := newStdioTransport(zerolog.Nop(), clientReader, clientWriter, nil)
+	return transport, &stdioPeer{t: t, reader: bufio.NewReader(serverReader), writer: serverWriter}
+}
+
+// read returns the next message written by the client
+func (p *stdioPeer) read() incomingMessage {
+	p.t.Helper()
+	line, err := p.reader.ReadBytes('\n')
+	require.NoError(p.t, err)
+	var message incomingMessage
+	require.NoError(p.t, json.Unmarshal(line, &message))
+	return message
+}
+
+func (p *stdioPeer) write(format string, args ...interface{}) {
+	p.t.Helper()
+	_, err := fmt.Fprintf(p.writer, format+"\n", args...)
+	require.NoError(p.t, err)
+}
+
+func request(id int, method string) *protocol.Request {
+	return &protocol.Request{JSONRPC: "2.0", ID: json.RawMessage(fmt.Sprint(id)), Method: method}
+}
+
+func TestStdioTransportConcurrentSends(t *testing.T) {
+	transport, peer := newStdioPeer(t)
+	ctx := context.Background()
+
+	const n = 5
+	results := make([]string, n+1)
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			response, err := transport.Send(ctx, request(id, "tools/call"))
+			if err != nil {
+				t.Errorf("Send %d: %v", id, err)
+				return
+			}
+			results[id] = string(response.Result)
+		}(i)
+	}
+
+	// Answer once every request is in flight, in reverse order
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ids = append(ids, string(peer.read().ID))
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		peer.write(`{"jsonrpc":"2.0","id":%s,"result":{"for":%s}}`, ids[i], ids[i])
+	}
+	wg.Wait()
+
+	for i := 1; i <= n; i++ {
+		require.JSONEq(t, fmt.Sprintf(`{"for":%d}`, i), results[i])
+	}
+}
+
+func TestStdioTransportCancel(t *testing.T) {
+	transport, peer := newStdioPeer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := transport.Send(ctx, request(1, "tools/call"))
+		errCh <- err
+	}()
+	require.Equal(t, "tools/call", peer.read().Method)
+
+	// The server is told that the client stopped waiting
+	cancel()
+	cancelled := peer.read()
+	require.Equal(t, "notifications/cancelled", cancelled.Method)
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+		Reason    string          `json:"reason"`
+	}
+	require.NoError(t, json.Unmarshal(cancelled.Params, &params))
+	require.Equal(t, "1", string(params.RequestID))
+	require.Equal(t, context.Canceled.Error(), params.Reason)
+	require.ErrorIs(t, <-errCh, context.Canceled)
+
+	// A late response to the cancelled request doesn't reach the next waiter
+	responseCh := make(chan *protocol.Response, 1)
+	go func() {
+		response, err := transport.Send(context.Background(), request(2, "tools/call"))
+		if err != nil {
+			t.Errorf("Send: %v", err)
+		}
+		responseCh <- response
+	}()
+	require.Equal(t, "2", string(peer.read().ID))
+	peer.write(`{"jsonrpc":"2.0","id":1,"result":{"late":true}}`)
+	peer.write(`{"jsonrpc":"2.0","id":2,"result":{"late":false}}`)
+
+	response := <-responseCh
+	require.Equal(t, "2", string(response.ID))
+	require.JSONEq(t, `{"late":false}`, string(response.Result))
+}
+
+func TestStdioTransportClosedStream(t *testing.T) {
+	transport, peer := newStdioPeer(t)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := transport.Send(context.Background(), request(1, "tools/call"))
+		errCh <- err
+	}()
+	peer.read()
+	require.NoError(t, peer.writer.(io.Closer).Close())
+
+	require.ErrorIs(t, <-errCh, io.EOF)
+}
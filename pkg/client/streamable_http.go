@@ -0,0 +1,563 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// headerSessionID carries the session id assigned by the server on initialize
+	headerSessionID = "Mcp-Session-Id"
+	// headerLastEventID asks the server to replay events after a reconnect
+	headerLastEventID = "Last-Event-ID"
+
+	listenInitialBackoff = 500 * time.Millisecond
+	listenMaxBackoff     = 30 * time.Second
+)
+
+// StreamableHTTPTransport implements Transport using the MCP streamable HTTP
+// transport: requests are POSTed to a single endpoint and answered either
+// with a JSON body or with a text/event-stream, while a long-lived GET stream
+// delivers server-initiated messages.
+type StreamableHTTPTransport struct {
+	mu                  sync.Mutex
+	url                 string
+	client              *http.Client
+	logger              zerolog.Logger
+	sessionID           string
+	lastEventID         string
+	retryDelay          time.Duration
+	listening           bool
+	listenCancel        context.CancelFunc
+	listenErr           error
+	closeOnce           sync.Once
+	notificationHandler func(*protocol.Response)
+	requestHandler      RequestHandler
+	cookies             []*http.Cookie
+}
+
+var _ Transport = &StreamableHTTPTransport{}
+
+// NewStreamableHTTPTransport creates a new streamable HTTP transport for the
+// given MCP endpoint URL (e.g. http://localhost:3001/mcp)
+func NewStreamableHTTPTransport(url string, logger zerolog.Logger) *StreamableHTTPTransport {
+	return &StreamableHTTPTransport{
+		url:    url,
+		client: &http.Client{},
+		logger: logger,
+	}
+}
+
+// SetNotificationHandler sets the handler for notifications
+func (t *StreamableHTTPTransport) SetNotificationHandler(handler func(*protocol.Response)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notificationHandler = handler
+}
+
+// SetRequestHandler sets the handler for requests initiated by the server
+func (t *StreamableHTTPTransport) SetRequestHandler(handler RequestHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requestHandler = handler
+}
+
+// SetCookies sets the cookies to be used for requests
+func (t *StreamableHTTPTransport) SetCookies(cookies []*http.Cookie) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cookies = cookies
+}
+
+// GetCookies returns the current cookies
+func (t *StreamableHTTPTransport) GetCookies() []*http.Cookie {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*http.Cookie{}, t.cookies...)
+}
+
+// SessionID returns the session id assigned by the server, if any
+func (t *StreamableHTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// Send sends a request and returns the response
+func (t *StreamableHTTPTransport) Send(ctx context.Context, request *protocol.Request) (*protocol.Response, error) {
+	t.logger.Debug().
+		Str("method", request.Method).
+		Interface("params", request.Params).
+		Msg("Sending request")
+
+	reqBody, err := json.Marshal(request)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("Failed to marshal request")
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(reqBody))
+	if err != nil {
+		t.logger.Error().Err(err).Msg("Failed to create HTTP request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.prepareRequest(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("Failed to send HTTP request")
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			t.logger.Error().Err(closeErr).Msg("Failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound && t.SessionID() != "" {
+		// The server no longer knows our session, a new initialize is required
+		t.mu.Lock()
+		t.sessionID = ""
+		t.mu.Unlock()
+		return nil, fmt.Errorf("session expired or terminated by server")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		t.logger.Error().
+			Int("status", resp.StatusCode).
+			Str("body", string(body)).
+			Msg("Server returned error status")
+		return nil, fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if sessionID := resp.Header.Get(headerSessionID); sessionID != "" {
+		t.mu.Lock()
+		if t.sessionID != sessionID {
+			t.logger.Debug().Str("sessionId", sessionID).Msg("Received session id")
+			t.sessionID = sessionID
+		}
+		t.mu.Unlock()
+	}
+
+	// Open the GET stream for server-initiated messages once the session is
+	// fully initialized
+	if request.Method == "notifications/initialized" {
+		t.startListening()
+	}
+
+	// If this is a notification, don't wait for response
+	if isNotificationRequest(request) || resp.StatusCode == http.StatusAccepted {
+		t.logger.Debug().Msg("Request is a notification, not waiting for response")
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream":
+		t.logger.Debug().Msg("Reading response from event stream")
+		return t.readEventStreamResponse(ctx, resp.Body, request.ID)
+	default:
+		var response protocol.Response
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.logger.Error().Err(err).Msg("Failed to parse response")
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		return &response, nil
+	}
+}
+
+// readEventStreamResponse reads SSE events until the response to requestID
+// arrives, dispatching notifications sent in the meantime.
+func (t *StreamableHTTPTransport) readEventStreamResponse(ctx context.Context, body io.Reader, requestID json.RawMessage) (*protocol.Response, error) {
+	var result *protocol.Response
+	err := t.readEvents(body, func(event sseEvent) bool {
+		if request, ok := parseServerRequest(event.data); ok {
+			go t.handleRequest(request)
+			return true
+		}
+		var response protocol.Response
+		if err := json.Unmarshal(event.data, &response); err != nil {
+			t.logger.Error().Err(err).Msg("Failed to parse event")
+			return true
+		}
+		if isNotificationResponse(&response) {
+			t.dispatchNotification(&response)
+			return true
+		}
+		if string(response.ID) != string(requestID) {
+			t.logger.Warn().RawJSON("id", response.ID).Msg("Ignoring response for unexpected request id")
+			return true
+		}
+		result = &response
+		return false
+	})
+	if result != nil {
+		return result, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event stream: %w", err)
+	}
+	return nil, fmt.Errorf("event stream closed before response was received")
+}
+
+// startListening opens the GET stream in the background, if not already open.
+func (t *StreamableHTTPTransport) startListening() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.listening || t.sessionID == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.listening = true
+	t.listenCancel = cancel
+	t.listenErr = nil
+	go t.listen(ctx)
+}
+
+// ListenErr returns the error that made the transport stop listening on the
+// GET stream, such as an expired session or a rejected token. It is cleared
+// when the stream is opened again after a new initialization.
+func (t *StreamableHTTPTransport) ListenErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.listenErr
+}
+
+// listen keeps the GET stream open, reconnecting with Last-Event-ID so that
+// the server can replay messages missed while disconnected. It stops when the
+// server doesn't offer a GET stream (405), or rejects the session (404) or
+// the credentials (401, 403), recording the error for ListenErr.
+func (t *StreamableHTTPTransport) listen(ctx context.Context) {
+	backoff := listenInitialBackoff
+	for {
+		connected, err := t.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == errListenNotSupported {
+			t.logger.Debug().Msg("Server does not offer a GET stream")
+			return
+		}
+		var statusErr *listenStatusError
+		if errors.As(err, &statusErr) && statusErr.permanent() {
+			// Retrying can't succeed until the client initializes again or
+			// gets new credentials
+			t.logger.Error().Err(err).Msg("GET stream rejected, no longer listening")
+			t.mu.Lock()
+			t.listening = false
+			t.listenErr = err
+			t.mu.Unlock()
+			return
+		}
+		if err != nil {
+			t.logger.Debug().Err(err).Msg("GET stream disconnected")
+		}
+		if connected {
+			backoff = listenInitialBackoff
+		}
+
+		t.mu.Lock()
+		delay := t.retryDelay
+		t.mu.Unlock()
+		if delay <= 0 {
+			delay = backoff
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > listenMaxBackoff {
+			backoff = listenMaxBackoff
+		}
+	}
+}
+
+var errListenNotSupported = fmt.Errorf("server does not support GET streams")
+
+// listenStatusError is returned when the server answers the GET stream with
+// an error status.
+type listenStatusError struct {
+	status int
+}
+
+func (e *listenStatusError) Error() string {
+	switch e.status {
+	case http.StatusNotFound:
+		return "GET stream: session expired or terminated by server"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Sprintf("GET stream: not authorized (status %d)", e.status)
+	default:
+		return fmt.Sprintf("server returned status %d", e.status)
+	}
+}
+
+// permanent reports whether reconnecting with the same session and
+// credentials is bound to fail again.
+func (e *listenStatusError) permanent() bool {
+	switch e.status {
+	case http.StatusNotFound, http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	default:
+		return false
+	}
+}
+
+// listenOnce runs a single GET stream connection. It reports whether the
+// connection was established.
+func (t *StreamableHTTPTransport) listenOnce(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	t.prepareRequest(req)
+	t.mu.Lock()
+	if t.lastEventID != "" {
+		req.Header.Set(headerLastEventID, t.lastEventID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		return false, errListenNotSupported
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, &listenStatusError{status: resp.StatusCode}
+	}
+
+	t.logger.Debug().Msg("GET stream connected")
+	err = t.readEvents(resp.Body, func(event sseEvent) bool {
+		if request, ok := parseServerRequest(event.data); ok {
+			go t.handleRequest(request)
+			return true
+		}
+		var response protocol.Response
+		if err := json.Unmarshal(event.data, &response); err != nil {
+			t.logger.Error().Err(err).Msg("Failed to parse event")
+			return true
+		}
+		if isNotificationResponse(&response) {
+			t.dispatchNotification(&response)
+		} else {
+			t.logger.Debug().RawJSON("data", event.data).Msg("Ignoring unexpected response on GET stream")
+		}
+		return true
+	})
+	if err == nil {
+		err = io.EOF
+	}
+	return true, err
+}
+
+// handleRequest answers a server-to-client request by POSTing the response
+// back to the endpoint.
+func (t *StreamableHTTPTransport) handleRequest(request *protocol.Request) {
+	t.mu.Lock()
+	handler := t.requestHandler
+	t.mu.Unlock()
+
+	response := answerServerRequest(context.Background(), handler, request)
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.logger.Error().Err(err).Str("method", request.Method).Msg("Failed to marshal response")
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		t.logger.Error().Err(err).Msg("Failed to create HTTP request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.prepareRequest(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.logger.Error().Err(err).Str("method", request.Method).Msg("Failed to answer server request")
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		t.logger.Error().
+			Int("status", resp.StatusCode).
+			Str("method", request.Method).
+			Msg("Server rejected response to its request")
+	}
+}
+
+// Close closes the transport, terminating the session on the server
+func (t *StreamableHTTPTransport) Close(ctx context.Context) error {
+	t.logger.Debug().Msg("Closing transport")
+	var err error
+	t.closeOnce.Do(func() {
+		t.mu.Lock()
+		cancel := t.listenCancel
+		t.listenCancel = nil
+		sessionID := t.sessionID
+		t.mu.Unlock()
+		if cancel != nil {
+			cancel()
+		}
+
+		if sessionID == "" {
+			return
+		}
+
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+		if reqErr != nil {
+			err = fmt.Errorf("failed to create delete request: %w", reqErr)
+			return
+		}
+		t.prepareRequest(req)
+
+		resp, doErr := t.client.Do(req)
+		if doErr != nil {
+			err = fmt.Errorf("failed to terminate session: %w", doErr)
+			return
+		}
+		_ = resp.Body.Close()
+
+		// 405 means the server doesn't allow clients to terminate sessions
+		if resp.StatusCode != http.StatusMethodNotAllowed && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+			err = fmt.Errorf("server returned status %d when terminating session", resp.StatusCode)
+			return
+		}
+
+		t.mu.Lock()
+		t.sessionID = ""
+		t.mu.Unlock()
+		t.logger.Debug().Msg("Transport closed")
+	})
+	return err
+}
+
+// prepareRequest adds the session id and cookies to req
+func (t *StreamableHTTPTransport) prepareRequest(req *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
+	}
+	for _, cookie := range t.cookies {
+		req.AddCookie(cookie)
+	}
+}
+
+func (t *StreamableHTTPTransport) dispatchNotification(response *protocol.Response) {
+	t.mu.Lock()
+	handler := t.notificationHandler
+	t.mu.Unlock()
+	if handler != nil {
+		handler(response)
+	}
+}
+
+// sseEvent is a single parsed server-sent event
+type sseEvent struct {
+	id    string
+	event string
+	data  []byte
+}
+
+// readEvents parses a text/event-stream body and calls handle for every
+// message event until handle returns false or the stream ends. Event ids and
+// retry hints are remembered for reconnects.
+func (t *StreamableHTTPTransport) readEvents(body io.Reader, handle func(sseEvent) bool) error {
+	reader := bufio.NewReader(body)
+	var event sseEvent
+	var data bytes.Buffer
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// Blank line dispatches the event
+			if data.Len() > 0 {
+				event.data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+				if event.id != "" {
+					t.mu.Lock()
+					t.lastEventID = event.id
+					t.mu.Unlock()
+				}
+				if event.event == "" || event.event == "message" {
+					t.logger.Debug().RawJSON("data", event.data).Msg("Received event")
+					if !handle(event) {
+						return nil
+					}
+				}
+			}
+			event = sseEvent{}
+			data.Reset()
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			// Comment, used as keep-alive
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				t.mu.Lock()
+				t.retryDelay = time.Duration(ms) * time.Millisecond
+				t.mu.Unlock()
+			}
+		}
+	}
+}
+
+// isNotificationRequest checks if a request is a notification that expects no response
+func isNotificationRequest(request *protocol.Request) bool {
+	return len(request.ID) == 0 || string(request.ID) == "null" || strings.HasPrefix(request.Method, "notifications/")
+}
+
+// isNotificationResponse checks if a received message is a notification
+func isNotificationResponse(response *protocol.Response) bool {
+	return len(response.ID) == 0 || string(response.ID) == "null"
+}
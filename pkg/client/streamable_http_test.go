@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// streamableServer is a fake streamable HTTP MCP endpoint that records the
+// requests it receives.
+type streamableServer struct {
+	t *testing.T
+
+	mu           sync.Mutex
+	sessionIDs   map[string]string // method or HTTP verb -> Mcp-Session-Id header
+	lastEventIDs []string
+	gets         int
+	deleted      bool
+}
+
+func (s *streamableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		s.gets++
+		s.lastEventIDs = append(s.lastEventIDs, r.Header.Get(headerLastEventID))
+		s.sessionIDs["GET"] = r.Header.Get(headerSessionID)
+		w.Header().Set("Content-Type", "text/event-stream")
+		if s.gets == 1 {
+			// Drop the first stream after one event, the client reconnects
+			_, _ = fmt.Fprintf(w, "retry: 10\nid: ev-1\ndata: %s\n\n", notification("notifications/tools/list_changed"))
+			return
+		}
+		_, _ = fmt.Fprintf(w, "id: ev-2\ndata: %s\n\n", notification("notifications/resources/list_changed"))
+		w.(http.Flusher).Flush()
+		s.mu.Unlock()
+		<-r.Context().Done()
+		s.mu.Lock()
+
+	case http.MethodDelete:
+		s.sessionIDs["DELETE"] = r.Header.Get(headerSessionID)
+		s.deleted = true
+
+	case http.MethodPost:
+		var request protocol.Request
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&request))
+		s.sessionIDs[request.Method] = r.Header.Get(headerSessionID)
+
+		switch request.Method {
+		case "initialize":
+			w.Header().Set(headerSessionID, "session-1")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":"2024-11-05","capabilities":{}}}`, request.ID)
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			// Answer on an event stream, preceded by a notification
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, ": keep-alive\n\ndata: %s\n\n", notification("notifications/message"))
+			_, _ = fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":%s,\n", request.ID)
+			_, _ = fmt.Fprintf(w, "data: \"result\":{\"tools\":[]}}\n\n")
+		default:
+			http.Error(w, "unexpected method "+request.Method, http.StatusBadRequest)
+		}
+	}
+}
+
+func notification(method string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":%q}`, method)
+}
+
+func TestStreamableHTTPTransport(t *testing.T) {
+	fake := &streamableServer{t: t, sessionIDs: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	transport := NewStreamableHTTPTransport(server.URL, zerolog.Nop())
+	notifications := make(chan struct{}, 10)
+	transport.SetNotificationHandler(func(*protocol.Response) {
+		notifications <- struct{}{}
+	})
+	ctx := context.Background()
+
+	// The session id assigned on initialize is captured from a JSON response
+	response, err := transport.Send(ctx, &protocol.Request{JSONRPC: "2.0", ID: json.RawMessage(`1`), Method: "initialize"})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	require.Equal(t, "session-1", transport.SessionID())
+
+	response, err = transport.Send(ctx, &protocol.Request{JSONRPC: "2.0", Method: "notifications/initialized"})
+	require.NoError(t, err)
+	require.Nil(t, response)
+
+	// Responses sent as an event stream are read past the notifications
+	// preceding them, and split data lines are joined
+	response, err = transport.Send(ctx, &protocol.Request{JSONRPC: "2.0", ID: json.RawMessage(`2`), Method: "tools/list"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"tools":[]}`, string(response.Result))
+
+	// One notification arrives with the tools/list response, and one on each
+	// GET stream: the client reconnects after the first one is dropped, with
+	// the id of the last event it received
+	for i := 0; i < 3; i++ {
+		receive(t, notifications)
+	}
+
+	require.NoError(t, transport.Close(ctx))
+	require.Equal(t, "", transport.SessionID())
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	require.Equal(t, []string{"", "ev-1"}, fake.lastEventIDs)
+	require.True(t, fake.deleted)
+	require.Equal(t, map[string]string{
+		"initialize":                "",
+		"notifications/initialized": "session-1",
+		"tools/list":                "session-1",
+		"GET":                       "session-1",
+		"DELETE":                    "session-1",
+	}, fake.sessionIDs)
+}
+
+func TestStreamableHTTPTransportSessionExpired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerSessionID) != "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set(headerSessionID, "session-1")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	defer server.Close()
+
+	transport := NewStreamableHTTPTransport(server.URL, zerolog.Nop())
+	ctx := context.Background()
+	_, err := transport.Send(ctx, &protocol.Request{JSONRPC: "2.0", ID: json.RawMessage(`1`), Method: "initialize"})
+	require.NoError(t, err)
+	require.Equal(t, "session-1", transport.SessionID())
+
+	_, err = transport.Send(ctx, &protocol.Request{JSONRPC: "2.0", ID: json.RawMessage(`2`), Method: "tools/list"})
+	require.ErrorContains(t, err, "session expired")
+	require.Equal(t, "", transport.SessionID())
+}
+
+func TestStreamableHTTPTransportStopsListeningOnRejection(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusUnauthorized, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var mu sync.Mutex
+			gets := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					mu.Lock()
+					gets++
+					mu.Unlock()
+					w.WriteHeader(status)
+					return
+				}
+				w.Header().Set(headerSessionID, "session-1")
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			transport := NewStreamableHTTPTransport(server.URL, zerolog.Nop())
+			_, err := transport.Send(context.Background(), &protocol.Request{JSONRPC: "2.0", Method: "notifications/initialized"})
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool {
+				return transport.ListenErr() != nil
+			}, 5*time.Second, 10*time.Millisecond)
+			// Wait past the first reconnect delay to check there is no retry
+			time.Sleep(2 * listenInitialBackoff)
+			mu.Lock()
+			defer mu.Unlock()
+			require.Equal(t, 1, gets)
+		})
+	}
+}
+
+func receive(t *testing.T, ch chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a notification")
+	}
+}
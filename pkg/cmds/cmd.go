@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"syscall"
 	"time"
 
 	"github.com/go-go-golems/glazed/pkg/cmds"
@@ -15,36 +16,57 @@ import (
 	"github.com/go-go-golems/glazed/pkg/cmds/schema"
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
 	"github.com/go-go-golems/glazed/pkg/helpers/templating"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/go-go-golems/go-go-mcp/pkg/sandbox"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
 )
 
+// commandWaitDelay bounds how long a cancelled command may keep its output
+// pipes open after it was killed.
+const commandWaitDelay = 5 * time.Second
+
 // ShellCommandDescription represents the YAML structure for shell commands
 type ShellCommandDescription struct {
-	Name          string                `yaml:"name"`
-	Short         string                `yaml:"short"`
-	Long          string                `yaml:"long,omitempty"`
-	Flags         []*fields.Definition  `yaml:"flags,omitempty"`
-	Arguments     []*fields.Definition  `yaml:"arguments,omitempty"`
-	Layers        []*schema.SectionImpl `yaml:"layers,omitempty"`
-	ShellScript   string                `yaml:"shell-script,omitempty"`
-	Command       []string              `yaml:"command,omitempty"`
-	Cwd           string                `yaml:"cwd,omitempty"`
-	Environment   map[string]string     `yaml:"environment,omitempty"`
-	CaptureStderr bool                  `yaml:"capture-stderr,omitempty"`
-	Debug         bool                  `yaml:"debug,omitempty"`
-	SaveScriptDir string                `yaml:"save-script-dir,omitempty"`
+	Name             string                    `yaml:"name"`
+	Short            string                    `yaml:"short"`
+	Long             string                    `yaml:"long,omitempty"`
+	Flags            []*fields.Definition      `yaml:"flags,omitempty"`
+	Arguments        []*fields.Definition      `yaml:"arguments,omitempty"`
+	Layers           []*schema.SectionImpl     `yaml:"layers,omitempty"`
+	ShellScript      string                    `yaml:"shell-script,omitempty"`
+	Command          []string                  `yaml:"command,omitempty"`
+	Cwd              string                    `yaml:"cwd,omitempty"`
+	Environment      map[string]string         `yaml:"environment,omitempty"`
+	CaptureStderr    bool                      `yaml:"capture-stderr,omitempty"`
+	Stream           bool                      `yaml:"stream,omitempty"`
+	MaxOutputSize    int                       `yaml:"max-output-size,omitempty"`
+	TruncationMarker string                    `yaml:"truncation-marker,omitempty"`
+	Sandbox          *sandbox.Config           `yaml:"sandbox,omitempty"`
+	OutputSchema     interface{}               `yaml:"output-schema,omitempty"`
+	Annotations      *protocol.ToolAnnotations `yaml:"annotations,omitempty"`
+	Debug            bool                      `yaml:"debug,omitempty"`
+	SaveScriptDir    string                    `yaml:"save-script-dir,omitempty"`
 }
 
 // ShellCommand is the runtime representation of a shell command
 type ShellCommand struct {
 	*cmds.CommandDescription
-	ShellScript   string
-	Command       []string
-	Cwd           string
-	Environment   map[string]string
-	CaptureStderr bool
+	ShellScript      string
+	Command          []string
+	Cwd              string
+	Environment      map[string]string
+	CaptureStderr    bool
+	Stream           bool
+	MaxOutputSize    int
+	TruncationMarker string
+	Sandbox          *sandbox.Config
+	// OutputSchema is the JSON schema of the command's stdout. When set, the
+	// output is parsed as JSON and returned as structured content.
+	OutputSchema json.RawMessage
+	// Annotations are hints about the command's behavior sent to clients
+	Annotations   *protocol.ToolAnnotations
 	Debug         bool
 	SaveScriptDir string
 }
@@ -83,6 +105,48 @@ func WithCaptureStderr(capture bool) ShellCommandOption {
 	}
 }
 
+// WithStream sends stdout to the client of the tool call while the command
+// runs, in addition to returning it as the result.
+func WithStream(stream bool) ShellCommandOption {
+	return func(c *ShellCommand) {
+		c.Stream = stream
+	}
+}
+
+// WithMaxOutputSize caps the returned output at size bytes, 0 means unlimited.
+// Output beyond the limit is dropped and the truncation marker appended, or
+// the command fails if it has an output schema.
+func WithMaxOutputSize(size int) ShellCommandOption {
+	return func(c *ShellCommand) {
+		c.MaxOutputSize = size
+	}
+}
+
+func WithTruncationMarker(marker string) ShellCommandOption {
+	return func(c *ShellCommand) {
+		c.TruncationMarker = marker
+	}
+}
+
+// WithSandbox runs the command inside a sandbox configured by cfg
+func WithSandbox(cfg *sandbox.Config) ShellCommandOption {
+	return func(c *ShellCommand) {
+		c.Sandbox = cfg
+	}
+}
+
+func WithOutputSchema(schema json.RawMessage) ShellCommandOption {
+	return func(c *ShellCommand) {
+		c.OutputSchema = schema
+	}
+}
+
+func WithAnnotations(annotations *protocol.ToolAnnotations) ShellCommandOption {
+	return func(c *ShellCommand) {
+		c.Annotations = annotations
+	}
+}
+
 func WithSaveScriptDir(dir string) ShellCommandOption {
 	return func(c *ShellCommand) {
 		c.SaveScriptDir = dir
@@ -103,6 +167,7 @@ func NewShellCommand(
 	ret := &ShellCommand{
 		CommandDescription: description,
 		Environment:        make(map[string]string),
+		TruncationMarker:   DefaultTruncationMarker,
 	}
 
 	for _, option := range options {
@@ -112,6 +177,14 @@ func NewShellCommand(
 	if ret.ShellScript == "" && len(ret.Command) == 0 {
 		return nil, fmt.Errorf("either shell script or command must be specified")
 	}
+	if ret.MaxOutputSize < 0 {
+		return nil, fmt.Errorf("max-output-size must not be negative")
+	}
+	if ret.Sandbox != nil {
+		if err := ret.Sandbox.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	return ret, nil
 }
@@ -153,6 +226,13 @@ func (c *ShellCommand) ExecuteCommand(
 	w io.Writer,
 ) error {
 	var cmd *exec.Cmd
+	var scriptPath string
+
+	if c.Sandbox != nil && c.Sandbox.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Sandbox.Timeout)
+		defer cancel()
+	}
 
 	// Create temp JSON file with args
 	argsJSON, err := json.MarshalIndent(args, "", "  ")
@@ -268,7 +348,8 @@ func (c *ShellCommand) ExecuteCommand(
 			}
 		}
 
-		cmd = exec.CommandContext(ctx, "/bin/bash", tmpFile.Name())
+		scriptPath = tmpFile.Name()
+		cmd = exec.CommandContext(ctx, "/bin/bash", scriptPath)
 		log.Debug().Str("command", cmd.String()).Msg("command")
 	} else {
 		// Process command template
@@ -306,21 +387,99 @@ func (c *ShellCommand) ExecuteCommand(
 			env = append(env, fmt.Sprintf("%s=%s", k, processed))
 		}
 	}
+	if c.Sandbox != nil {
+		keep := []string{"MCP_ARGUMENTS_JSON_PATH"}
+		for k := range c.Environment {
+			keep = append(keep, k)
+		}
+		env = c.Sandbox.FilterEnv(env, keep...)
+	}
 	cmd.Env = env
 
-	// Setup output streams
-	cmd.Stdout = w
+	// Setup output streams. Stderr lines are also reported as progress of
+	// the tool call, and stdout is sent to the client as it comes when
+	// streaming, up to the same max-output-size as the result.
+	var out io.Writer = w
+	var limited *LimitWriter
+	if c.MaxOutputSize > 0 {
+		limited = NewLimitWriter(w, c.MaxOutputSize)
+		out = limited
+	}
+	if c.CaptureStderr {
+		// stdout and stderr are copied by separate goroutines
+		out = &lockedWriter{w: out}
+	}
+
+	progress := newProgressWriter(ctx)
+	var stream *streamWriter
+	cmd.Stdout = out
+	if c.Stream {
+		stream = newStreamWriter(ctx)
+		var streamOut io.Writer = stream
+		if c.MaxOutputSize > 0 {
+			streamOut = NewLimitWriter(stream, c.MaxOutputSize)
+		}
+		cmd.Stdout = io.MultiWriter(out, streamOut)
+	}
 	if c.CaptureStderr {
 		log.Debug().Msg("capturing stderr")
-		cmd.Stderr = w
+		cmd.Stderr = io.MultiWriter(out, progress)
 	} else {
 		log.Debug().Msg("not capturing stderr")
-		cmd.Stderr = os.Stderr
+		cmd.Stderr = io.MultiWriter(os.Stderr, progress)
+	}
+
+	// Run the command in its own process group, so that cancelling the call
+	// also kills the processes started by the script.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		log.Info().Int("pid", cmd.Process.Pid).Msg("killing cancelled command")
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 	}
+	cmd.WaitDelay = commandWaitDelay
 
 	log.Info().Str("command", fmt.Sprintf("%v", cmd.Args)).Msg("executing command")
 
-	return cmd.Run()
+	if c.Sandbox != nil {
+		readOnly := []string{argsTmpFile.Name()}
+		if scriptPath != "" {
+			readOnly = append(readOnly, scriptPath)
+		}
+		cleanup, err := c.Sandbox.Apply(cmd, readOnly...)
+		if err != nil {
+			return errors.Wrap(err, "failed to sandbox command")
+		}
+		defer cleanup()
+	}
+
+	if err := cmd.Start(); err != nil {
+		if c.Sandbox != nil {
+			return errors.Wrap(err, "failed to start sandboxed command")
+		}
+		return err
+	}
+	err = cmd.Wait()
+	progress.Flush()
+	if stream != nil {
+		stream.Flush()
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errors.Wrap(ctx.Err(), "command timed out")
+	}
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "command cancelled")
+	}
+	if limited != nil && limited.truncated {
+		// Truncated JSON can't be returned as structured content
+		if len(c.OutputSchema) > 0 && err == nil {
+			return errors.Errorf("output exceeds max-output-size of %d bytes", c.MaxOutputSize)
+		}
+		log.Info().Int("max_output_size", c.MaxOutputSize).Msg("truncated command output")
+		if _, writeErr := io.WriteString(w, c.TruncationMarker); writeErr != nil {
+			return errors.Wrap(writeErr, "failed to write truncation marker")
+		}
+	}
+	return err
 }
 
 // RunIntoWriter implements the WriterCommand interface
@@ -362,14 +521,36 @@ func LoadShellCommandFromYAML(data []byte) (*ShellCommand, error) {
 		cmds.WithSections(sections...),
 	)
 
-	return NewShellCommand(
-		cmdDesc,
+	options := []ShellCommandOption{
 		WithShellScript(desc.ShellScript),
 		WithCommand(desc.Command),
 		WithCwd(desc.Cwd),
 		WithEnvironment(desc.Environment),
 		WithCaptureStderr(desc.CaptureStderr),
+		WithStream(desc.Stream),
+		WithMaxOutputSize(desc.MaxOutputSize),
+		WithSandbox(desc.Sandbox),
 		WithSaveScriptDir(desc.SaveScriptDir),
 		WithDebug(desc.Debug),
-	)
+	}
+	if desc.Sandbox != nil {
+		if err := sandbox.Check(); err != nil {
+			log.Warn().Err(err).Str("command", desc.Name).Msg("sandboxed command will fail to run on this system")
+		}
+	}
+	if desc.OutputSchema != nil {
+		outputSchema, err := json.Marshal(desc.OutputSchema)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal output-schema")
+		}
+		options = append(options, WithOutputSchema(outputSchema))
+	}
+	if desc.Annotations != nil {
+		options = append(options, WithAnnotations(desc.Annotations))
+	}
+	if desc.TruncationMarker != "" {
+		options = append(options, WithTruncationMarker(desc.TruncationMarker))
+	}
+
+	return NewShellCommand(cmdDesc, options...)
 }
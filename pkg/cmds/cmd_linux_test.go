@@ -0,0 +1,88 @@
+//go:build linux
+
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteCommandReportsProgress(t *testing.T) {
+	c := newTestShellCommand(t, WithShellScript(`#!/bin/bash
+echo "1/3 fetching" >&2
+echo "2/3 building" >&2
+echo "installing" >&2
+echo ok
+`))
+	r := &recorder{}
+	var out bytes.Buffer
+	require.NoError(t, c.ExecuteCommand(r.context(context.Background()), map[string]interface{}{}, &out))
+
+	require.Equal(t, "ok\n", out.String())
+	require.Equal(t, []progressReport{
+		{1, 3, "fetching"},
+		{2, 3, "building"},
+		{3, 3, "installing"},
+	}, r.progress)
+}
+
+func TestExecuteCommandCancelKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	c := newTestShellCommand(t, WithShellScript(fmt.Sprintf(`#!/bin/bash
+sleep 60 &
+echo $! > %s
+echo "1/2 waiting" >&2
+wait
+`, pidFile)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &recorder{}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.ExecuteCommand(r.context(ctx), map[string]interface{}{}, &bytes.Buffer{})
+	}()
+
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.progress) > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	data, err := os.ReadFile(pidFile)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	require.NoError(t, err)
+
+	// Cancelling the call, as notifications/cancelled does, kills the script
+	// along with the processes it started
+	cancel()
+	select {
+	case err := <-errCh:
+		require.ErrorContains(t, err, "command cancelled")
+	case <-time.After(10 * time.Second):
+		t.Fatal("command was not killed")
+	}
+	require.Eventually(t, func() bool {
+		return processGone(pid)
+	}, 5*time.Second, 10*time.Millisecond, "background process %d is still running", pid)
+}
+
+// processGone returns whether pid has exited, including zombies nobody reaped
+func processGone(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	// The state follows the command name, which is in parentheses
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
@@ -0,0 +1,133 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/tools"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTruncationMarker is appended to the output of commands that exceed
+// their max-output-size.
+const DefaultTruncationMarker = "\n... [output truncated]\n"
+
+const (
+	// streamChunkSize is the amount of buffered output that is sent right away
+	streamChunkSize = 4096
+	// streamInterval is how often complete lines are sent otherwise
+	streamInterval = 250 * time.Millisecond
+)
+
+// lockedWriter serializes writes from the stdout and stderr copy goroutines
+// of a command when both go to the same writer.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// LimitWriter writes at most limit bytes to w. Further output is discarded
+// without error, so that the command isn't killed by a broken pipe. The
+// output is cut before a UTF-8 sequence that doesn't fit. It is used for
+// max-output-size, and by other tools running commands.
+type LimitWriter struct {
+	w         io.Writer
+	remaining int
+	truncated bool
+}
+
+func NewLimitWriter(w io.Writer, limit int) *LimitWriter {
+	return &LimitWriter{w: w, remaining: limit}
+}
+
+// Truncated reports whether output was discarded
+func (l *LimitWriter) Truncated() bool {
+	return l.truncated
+}
+
+func (l *LimitWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if n > l.remaining {
+		p = trimPartialRune(p[:l.remaining])
+		l.remaining = len(p)
+		l.truncated = true
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	l.remaining -= len(p)
+	if _, err := l.w.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// trimPartialRune drops the incomplete UTF-8 sequence p ends with, if any
+func trimPartialRune(p []byte) []byte {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return p[:i]
+			}
+			break
+		}
+	}
+	return p
+}
+
+// streamWriter sends the output of a command to the client of the tool call
+// running in ctx while the command runs. Output is sent in chunks of complete
+// lines as info log messages. Progress is left to the command's stderr.
+type streamWriter struct {
+	ctx      context.Context
+	buf      []byte
+	lastSent time.Time
+}
+
+func newStreamWriter(ctx context.Context) *streamWriter {
+	return &streamWriter{ctx: ctx}
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	for len(s.buf) >= streamChunkSize {
+		n := bytes.LastIndexByte(s.buf[:streamChunkSize], '\n') + 1
+		if n == 0 {
+			n = streamChunkSize
+		}
+		s.send(n)
+	}
+	if time.Since(s.lastSent) >= streamInterval {
+		if n := bytes.LastIndexByte(s.buf, '\n') + 1; n > 0 {
+			s.send(n)
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends the remaining output
+func (s *streamWriter) Flush() {
+	if len(s.buf) > 0 {
+		s.send(len(s.buf))
+	}
+}
+
+func (s *streamWriter) send(n int) {
+	chunk := string(s.buf[:n])
+	s.buf = s.buf[n:]
+	s.lastSent = time.Now()
+
+	if err := tools.ReportLog(s.ctx, "info", chunk); err != nil {
+		log.Debug().Err(err).Msg("failed to stream command output")
+	}
+}
@@ -0,0 +1,101 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewLimitWriter(&buf, 5)
+
+	n, err := w.Write([]byte("abc"))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.False(t, w.truncated)
+
+	// Writes past the limit report success, so that the command keeps running
+	n, err = w.Write([]byte("defgh"))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	n, err = w.Write([]byte("ijk"))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	require.True(t, w.truncated)
+	require.Equal(t, "abcde", buf.String())
+}
+
+func TestLimitWriterKeepsRunesWhole(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewLimitWriter(&buf, 5)
+
+	_, err := w.Write([]byte("abcé"))
+	require.NoError(t, err)
+	// "€" is three bytes, only one of which fits
+	_, err = w.Write([]byte("€"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("f"))
+	require.NoError(t, err)
+
+	require.True(t, w.truncated)
+	require.Equal(t, "abcé", buf.String())
+}
+
+func TestStreamWriter(t *testing.T) {
+	r := &recorder{}
+	w := newStreamWriter(r.context(context.Background()))
+
+	// Full chunks are sent right away, cut after the last complete line
+	line := strings.Repeat("x", 99) + "\n"
+	_, err := w.Write([]byte(strings.Repeat(line, 50)))
+	require.NoError(t, err)
+	require.Equal(t, []string{strings.Repeat(line, 40)}, r.logs)
+
+	_, err = w.Write([]byte("tail"))
+	require.NoError(t, err)
+	w.Flush()
+	require.Equal(t, []string{strings.Repeat(line, 40), strings.Repeat(line, 10) + "tail"}, r.logs)
+
+	// Streamed output doesn't count as progress
+	require.Empty(t, r.progress)
+}
+
+func TestExecuteCommandStreamsWithinLimit(t *testing.T) {
+	c := newTestShellCommand(t,
+		WithStream(true),
+		WithMaxOutputSize(1000),
+		WithShellScript(`#!/bin/bash
+echo "1/2 generating" >&2
+head -c 100000 /dev/zero | tr '\0' 'x'
+echo "2/2 done" >&2
+`),
+	)
+	r := &recorder{}
+	var out bytes.Buffer
+	require.NoError(t, c.ExecuteCommand(r.context(context.Background()), map[string]interface{}{}, &out))
+
+	require.Equal(t, strings.Repeat("x", 1000)+DefaultTruncationMarker, out.String())
+	require.Equal(t, strings.Repeat("x", 1000), strings.Join(r.logs, ""))
+	require.Equal(t, []progressReport{
+		{1, 2, "generating"},
+		{2, 2, "done"},
+	}, r.progress)
+}
+
+func TestExecuteCommandFailsOnTruncatedJSON(t *testing.T) {
+	c := newTestShellCommand(t,
+		WithMaxOutputSize(10),
+		WithOutputSchema([]byte(`{"type": "object"}`)),
+		WithShellScript(`#!/bin/bash
+echo '{"message": "more than ten bytes"}'
+`),
+	)
+	var out bytes.Buffer
+	err := c.ExecuteCommand(context.Background(), map[string]interface{}{}, &out)
+	require.EqualError(t, err, "output exceeds max-output-size of 10 bytes")
+}
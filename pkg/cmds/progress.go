@@ -0,0 +1,88 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/tools"
+	"github.com/rs/zerolog/log"
+)
+
+// progressLineRegexp matches stderr lines of the form "3/10 message"
+var progressLineRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*/\s*(\d+(?:\.\d+)?)\s*(.*)$`)
+
+const maxProgressLineLength = 4096
+
+// progressWriter reports every line written to it as progress of the tool
+// call running in ctx. Lines starting with "N/M" report N out of M, other
+// lines advance the progress by one and are sent as the progress message.
+type progressWriter struct {
+	ctx      context.Context
+	mu       sync.Mutex
+	buf      []byte
+	progress float64
+	total    float64
+}
+
+func newProgressWriter(ctx context.Context) *progressWriter {
+	return &progressWriter{ctx: ctx}
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.report(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxProgressLineLength {
+		w.report(string(w.buf))
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Flush reports the last line if it wasn't terminated by a newline
+func (w *progressWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.report(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func (w *progressWriter) report(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	progress, message := w.progress+1, line
+	if m := progressLineRegexp.FindStringSubmatch(line); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		total, _ := strconv.ParseFloat(m[2], 64)
+		// progress has to increase with every notification
+		if n > w.progress {
+			progress, message = n, m[3]
+			w.total = total
+		}
+	}
+	w.progress = progress
+	w.send(message)
+}
+
+func (w *progressWriter) send(message string) {
+	if err := tools.ReportProgress(w.ctx, w.progress, w.total, message); err != nil {
+		log.Debug().Err(err).Msg("failed to report progress")
+	}
+}
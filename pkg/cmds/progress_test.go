@@ -0,0 +1,79 @@
+package cmds
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/go-go-mcp/pkg/tools"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the log messages and progress notifications of a tool call
+type recorder struct {
+	mu       sync.Mutex
+	logs     []string
+	progress []progressReport
+}
+
+type progressReport struct {
+	progress, total float64
+	message         string
+}
+
+func (r *recorder) context(ctx context.Context) context.Context {
+	ctx = tools.WithLogReporter(ctx, func(level string, data interface{}) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.logs = append(r.logs, data.(string))
+		return nil
+	})
+	return tools.WithProgressReporter(ctx, func(progress, total float64, message string) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.progress = append(r.progress, progressReport{progress, total, message})
+		return nil
+	})
+}
+
+func newTestShellCommand(t *testing.T, options ...ShellCommandOption) *ShellCommand {
+	t.Helper()
+	c, err := NewShellCommand(cmds.NewCommandDescription("test"), options...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestProgressWriter(t *testing.T) {
+	r := &recorder{}
+	w := newProgressWriter(r.context(context.Background()))
+
+	_, err := w.Write([]byte("starting\n\n3/10 copying"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte(" files\n2 / 10 behind\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("7.5/10 almost"))
+	require.NoError(t, err)
+	w.Flush()
+
+	require.Equal(t, []progressReport{
+		// Other lines advance the progress by one
+		{1, 0, "starting"},
+		{3, 10, "copying files"},
+		// Progress has to increase, so lines going backwards count as a step
+		{4, 10, "2 / 10 behind"},
+		// The last line is reported when the command exits
+		{7.5, 10, "almost"},
+	}, r.progress)
+}
+
+func TestProgressWriterLongLines(t *testing.T) {
+	r := &recorder{}
+	w := newProgressWriter(r.context(context.Background()))
+
+	_, err := w.Write([]byte(strings.Repeat("x", maxProgressLineLength+1)))
+	require.NoError(t, err)
+	require.Len(t, r.progress, 1)
+	require.Equal(t, float64(1), r.progress[0].progress)
+}
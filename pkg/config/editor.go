@@ -34,6 +34,7 @@ func (c *ConfigEditor) AddProfile(name, description string) error {
 		"description", description,
 		"tools", &yaml.Node{Kind: yaml.MappingNode},
 		"prompts", &yaml.Node{Kind: yaml.MappingNode},
+		"resources", &yaml.Node{Kind: yaml.MappingNode},
 	)
 	if err != nil {
 		return fmt.Errorf("could not create profile node: %w", err)
@@ -226,6 +227,65 @@ func (c *ConfigEditor) AddPromptFile(profile, path string) error {
 	return nil
 }
 
+// AddResourceDirectory adds a resource directory to a profile, creating the
+// profile's resources section if needed
+func (c *ConfigEditor) AddResourceDirectory(profile string, dir ResourceDirectory) error {
+	// Create the directory source node
+	pairs := []interface{}{"path", dir.Path}
+	if len(dir.Include) > 0 {
+		pairs = append(pairs, "include", stringsToInterfaces(dir.Include))
+	}
+	if len(dir.Exclude) > 0 {
+		pairs = append(pairs, "exclude", stringsToInterfaces(dir.Exclude))
+	}
+	if dir.MaxSize > 0 {
+		pairs = append(pairs, "max_size", int(dir.MaxSize))
+	}
+	dirNode, err := c.editor.CreateMap(pairs...)
+	if err != nil {
+		return fmt.Errorf("could not create directory node: %w", err)
+	}
+
+	// Get or create the resources node, profiles created before resources
+	// were supported do not have one
+	resourcesNode, err := c.editor.GetNode("profiles", profile, "resources")
+	if err != nil {
+		if _, err := c.GetProfile(profile); err != nil {
+			return fmt.Errorf("could not get profile %s: %w", profile, err)
+		}
+		resourcesNode = &yaml.Node{Kind: yaml.MappingNode}
+		if err := c.editor.SetNode(resourcesNode, "profiles", profile, "resources"); err != nil {
+			return fmt.Errorf("could not create resources node: %w", err)
+		}
+	}
+
+	// Get or create the directories sequence
+	_, err = c.editor.GetMapNode("directories", resourcesNode)
+	if err != nil {
+		dirSeqNode := &yaml.Node{Kind: yaml.SequenceNode}
+		err = c.editor.SetNode(dirSeqNode, "profiles", profile, "resources", "directories")
+		if err != nil {
+			return fmt.Errorf("could not create directories sequence: %w", err)
+		}
+	}
+
+	// Append the new directory
+	err = c.editor.AppendToSequence(dirNode, "profiles", profile, "resources", "directories")
+	if err != nil {
+		return fmt.Errorf("could not append directory: %w", err)
+	}
+
+	return nil
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	ret := make([]interface{}, len(values))
+	for i, v := range values {
+		ret[i] = v
+	}
+	return ret
+}
+
 func (c *ConfigEditor) SetDefaultProfile(profile string) error {
 	// Create a scalar node with the profile name
 	profileNode := &yaml.Node{
@@ -16,9 +16,13 @@ type Config struct {
 
 // Profile represents a named configuration profile
 type Profile struct {
-	Description string         `yaml:"description"`
-	Tools       *ToolSources   `yaml:"tools"`
-	Prompts     *PromptSources `yaml:"prompts"`
+	Description string           `yaml:"description"`
+	Tools       *ToolSources     `yaml:"tools"`
+	Prompts     *PromptSources   `yaml:"prompts"`
+	Resources   *ResourceSources `yaml:"resources,omitempty"`
+	Upstreams   []Upstream       `yaml:"upstreams,omitempty"`
+	Quotas      []Quota          `yaml:"quotas,omitempty"`
+	Policies    []ToolPolicy     `yaml:"policies,omitempty"`
 }
 
 // Common source configuration for both tools and prompts
@@ -38,16 +42,28 @@ type ParameterFilter map[string][]string
 
 // ToolSources configures where tools are loaded from
 type ToolSources struct {
-	Directories      []SourceConfig `yaml:"directories,omitempty"`
-	Files            []SourceConfig `yaml:"files,omitempty"`
-	ExternalCommands []struct {
-		Command      string   `yaml:"command"`
-		Args         []string `yaml:"args,omitempty"`
-		SourceConfig `yaml:",inline"`
-	} `yaml:"external_commands,omitempty"`
+	Directories      []SourceConfig    `yaml:"directories,omitempty"`
+	Files            []SourceConfig    `yaml:"files,omitempty"`
+	ExternalCommands []ExternalCommand `yaml:"external_commands,omitempty"`
+}
+
+// ExternalCommand is an executable that describes its own tools when run
+// with --describe-tools. Path, if set, is the directory the command is
+// looked up in. Defaults, overrides and filters apply to all of its tools.
+// MaxOutputSize caps the output of a call in bytes (0 means unlimited), as
+// max-output-size does for shell commands.
+type ExternalCommand struct {
+	Command          string   `yaml:"command"`
+	Args             []string `yaml:"args,omitempty"`
+	MaxOutputSize    int      `yaml:"max_output_size,omitempty"`
+	TruncationMarker string   `yaml:"truncation_marker,omitempty"`
+	SourceConfig     `yaml:",inline"`
 }
 
-// PromptSources configures where prompts are loaded from
+// PromptSources configures where prompts are loaded from. Prompts are
+// pinocchio prompt files; Pinocchio points at a pinocchio prompt repository,
+// which is loaded like the other directories. Its command and args are not
+// used.
 type PromptSources struct {
 	Directories []SourceConfig `yaml:"directories,omitempty"`
 	Files       []SourceConfig `yaml:"files,omitempty"`
@@ -58,6 +74,65 @@ type PromptSources struct {
 	} `yaml:"pinocchio,omitempty"`
 }
 
+// ResourceSources configures which files are exposed as resources
+type ResourceSources struct {
+	Directories []ResourceDirectory `yaml:"directories,omitempty"`
+}
+
+// ResourceDirectory exposes the files below Path as file:// resources.
+// Include and Exclude are doublestar glob patterns matched against the path
+// relative to Path. MaxSize caps the size of exposed files in bytes; 0 uses
+// the provider default.
+type ResourceDirectory struct {
+	Path    string   `yaml:"path"`
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+	MaxSize int64    `yaml:"max_size,omitempty"`
+}
+
+// Upstream declares an MCP server whose tools, prompts and resources are
+// re-exposed by the gateway. Transport is one of command, sse or
+// streamable_http; Command, Args and Env are used for command upstreams and
+// URL for the HTTP ones. Tool and prompt names are prefixed with Prefix, which
+// defaults to Name followed by an underscore unless NoPrefix is set.
+type Upstream struct {
+	Name      string            `yaml:"name"`
+	Transport string            `yaml:"transport"`
+	Command   string            `yaml:"command,omitempty"`
+	Args      []string          `yaml:"args,omitempty"`
+	Env       map[string]string `yaml:"env,omitempty"`
+	URL       string            `yaml:"url,omitempty"`
+	Prefix    string            `yaml:"prefix,omitempty"`
+	NoPrefix  bool              `yaml:"no_prefix,omitempty"`
+}
+
+// Quota limits the rate and concurrency of calls to the tools matching the
+// Tools glob patterns (all tools if empty). Calls are counted together, or
+// per tool, session and/or subject as listed in Per. Rate is in calls per
+// second, with bursts of up to Burst calls; MaxConcurrent caps the calls
+// running at once. It mirrors embeddable.Quota, which enforces it.
+type Quota struct {
+	Name          string   `yaml:"name,omitempty"`
+	Tools         []string `yaml:"tools,omitempty"`
+	Per           []string `yaml:"per,omitempty"`
+	Rate          float64  `yaml:"rate,omitempty"`
+	Burst         int      `yaml:"burst,omitempty"`
+	MaxConcurrent int      `yaml:"max_concurrent,omitempty"`
+}
+
+// ToolPolicy restricts the tools matching the Tools glob patterns (all tools
+// if empty), or only the destructive ones if Destructive is set, to the
+// authenticated callers that have all Scopes and, when they are set, are one
+// of Subjects and ClientIDs. It mirrors embeddable.ToolPolicy, which enforces
+// it.
+type ToolPolicy struct {
+	Tools       []string `yaml:"tools,omitempty"`
+	Destructive bool     `yaml:"destructive,omitempty"`
+	Scopes      []string `yaml:"scopes,omitempty"`
+	Subjects    []string `yaml:"subjects,omitempty"`
+	ClientIDs   []string `yaml:"client_ids,omitempty"`
+}
+
 // LoadFromFile loads a configuration from a YAML file
 func LoadFromFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
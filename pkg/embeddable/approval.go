@@ -0,0 +1,456 @@
+package embeddable
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultApprovalTimeout is how long a call waits for approval by default
+const DefaultApprovalTimeout = 5 * time.Minute
+
+// DefaultStdioApprovalAddr is where the stdio transport serves the approval
+// queue when no ListenAddr is set, once a client that doesn't support
+// elicitation calls a tool needing approval. It matches the default URL of
+// `go-go-mcp approvals`.
+const DefaultStdioApprovalAddr = "localhost:3001"
+
+// ErrApprovalNotFound is returned when deciding a request that is not pending
+var ErrApprovalNotFound = errors.New("approval request not found")
+
+// ApprovalSettings selects the tool calls that need a human's approval
+// before they run.
+type ApprovalSettings struct {
+	// Tools lists glob patterns (as in path.Match) of tools that always need approval
+	Tools []string
+	// Destructive requires approval for tools annotated with destructiveHint=true
+	Destructive bool
+	// Timeout after which a pending call is rejected, DefaultApprovalTimeout if zero
+	Timeout time.Duration
+
+	// ListenAddr serves the approval queue on its own listener. Otherwise the
+	// queue is mounted under /approvals next to the HTTP transports' /mcp if
+	// a Token is set, and served on DefaultStdioApprovalAddr by the stdio
+	// transport when first needed.
+	ListenAddr string
+	// Token, if set, must be sent as bearer token to the approval queue. It
+	// is required to mount the queue next to /mcp.
+	Token string
+}
+
+// WithApproval pauses calls to the tools selected by settings until they are
+// approved. If the client supports elicitation, the user is asked through an
+// elicitation/create request. Otherwise the call waits on an ApprovalQueue,
+// served over HTTP, where `go-go-mcp approvals` lists, approves and denies
+// it. Calls that are denied or not decided in time return an error result,
+// and every decision is recorded to the audit sinks.
+func WithApproval(settings ApprovalSettings) ServerOption {
+	return func(config *ServerConfig) error {
+		for _, pattern := range settings.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+			}
+		}
+		if settings.Timeout <= 0 {
+			settings.Timeout = DefaultApprovalTimeout
+		}
+		config.approval = &settings
+		if config.approvalQueue == nil {
+			config.approvalQueue = NewApprovalQueue()
+		}
+		return nil
+	}
+}
+
+// WithApprovalQueue uses queue for the calls waiting for approval, for
+// applications that want to decide them themselves.
+func WithApprovalQueue(queue *ApprovalQueue) ServerOption {
+	return func(config *ServerConfig) error {
+		config.approvalQueue = queue
+		return nil
+	}
+}
+
+func (s *ApprovalSettings) matches(name string, annotations *ToolAnnotations) bool {
+	for _, pattern := range s.Tools {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return s.Destructive && isDestructive(annotations)
+}
+
+// ApprovalRequest is a tool call waiting on an ApprovalQueue
+type ApprovalRequest struct {
+	// ID is assigned by the queue
+	ID        string                 `json:"id"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Subject   string                 `json:"subject,omitempty"`
+	ClientID  string                 `json:"client_id,omitempty"`
+	SessionID string                 `json:"session_id,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	Deadline  time.Time              `json:"deadline,omitzero"`
+}
+
+// ApprovalDecision is the answer to an approval request
+type ApprovalDecision struct {
+	Approved bool   `json:"approved"`
+	Approver string `json:"approver,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// String describes the decision in a single line, e.g. "denied by alice: not now"
+func (d ApprovalDecision) String() string {
+	ret := "denied"
+	if d.Approved {
+		ret = "approved"
+	}
+	if d.Approver != "" {
+		ret += " by " + d.Approver
+	}
+	if d.Reason != "" {
+		ret += ": " + d.Reason
+	}
+	return ret
+}
+
+// ApprovalQueue holds tool calls until they are approved or denied out of
+// band, e.g. over HTTP with Handler.
+type ApprovalQueue struct {
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[string]*pendingApproval
+}
+
+type pendingApproval struct {
+	request  ApprovalRequest
+	decision chan ApprovalDecision
+}
+
+// NewApprovalQueue creates an empty approval queue
+func NewApprovalQueue() *ApprovalQueue {
+	return &ApprovalQueue{pending: map[string]*pendingApproval{}}
+}
+
+// Request adds request to the queue and blocks until it is decided or ctx is
+// done. The request's ID is assigned by the queue, and its deadline is taken
+// from ctx.
+func (q *ApprovalQueue) Request(ctx context.Context, request ApprovalRequest) (ApprovalDecision, error) {
+	q.mu.Lock()
+	q.nextID++
+	request.ID = strconv.FormatUint(q.nextID, 10)
+	if request.CreatedAt.IsZero() {
+		request.CreatedAt = time.Now()
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		request.Deadline = deadline
+	}
+	p := &pendingApproval{request: request, decision: make(chan ApprovalDecision, 1)}
+	q.pending[request.ID] = p
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		delete(q.pending, request.ID)
+		q.mu.Unlock()
+	}()
+
+	log.Info().Str("id", request.ID).Str("tool", request.Tool).Time("deadline", request.Deadline).Msg("Tool call waiting for approval")
+
+	select {
+	case decision := <-p.decision:
+		return decision, nil
+	case <-ctx.Done():
+		return ApprovalDecision{}, ctx.Err()
+	}
+}
+
+// Pending returns the requests waiting for a decision, oldest first
+func (q *ApprovalQueue) Pending() []ApprovalRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ret := make([]ApprovalRequest, 0, len(q.pending))
+	for _, p := range q.pending {
+		ret = append(ret, p.request)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if !ret[i].CreatedAt.Equal(ret[j].CreatedAt) {
+			return ret[i].CreatedAt.Before(ret[j].CreatedAt)
+		}
+		a, _ := strconv.ParseUint(ret[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(ret[j].ID, 10, 64)
+		return a < b
+	})
+	return ret
+}
+
+// Decide answers the pending request with the given id
+func (q *ApprovalQueue) Decide(id string, decision ApprovalDecision) error {
+	q.mu.Lock()
+	p, ok := q.pending[id]
+	if ok {
+		delete(q.pending, id)
+	}
+	q.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	p.decision <- decision
+	return nil
+}
+
+// Handler serves the queue over HTTP:
+//
+//	GET  /approvals               lists the pending requests
+//	POST /approvals/{id}/approve  approves a request
+//	POST /approvals/{id}/deny     denies a request
+//
+// POST bodies may carry {"approver": "...", "reason": "..."}. If token is not
+// empty, it must be sent as bearer token.
+func (q *ApprovalQueue) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /approvals", func(w http.ResponseWriter, r *http.Request) {
+		writeApprovalJSON(w, http.StatusOK, q.Pending())
+	})
+	mux.HandleFunc("POST /approvals/{id}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		var decision ApprovalDecision
+		switch r.PathValue("action") {
+		case "approve":
+			decision.Approved = true
+		case "deny":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		var body struct {
+			Approver string `json:"approver"`
+			Reason   string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		decision.Approver = body.Approver
+		decision.Reason = body.Reason
+
+		if err := q.Decide(r.PathValue("id"), decision); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Info().Str("id", r.PathValue("id")).Str("decision", decision.String()).Str("remote", r.RemoteAddr).Msg("Tool call decided")
+		writeApprovalJSON(w, http.StatusOK, decision)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "invalid approval token", http.StatusUnauthorized)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func writeApprovalJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// approvalRequestedSchema is the form shown to the user by elicitation
+var approvalRequestedSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"approve": {"type": "boolean", "title": "Approve", "description": "Run the tool call"}
+	},
+	"required": ["approve"]
+}`)
+
+// approveToolCall blocks until a call that needs approval is approved or
+// denied, asking the user through elicitation if the client supports it and
+// waiting on the approval queue otherwise. required is false if the call
+// doesn't need approval.
+func (c *ServerConfig) approveToolCall(
+	ctx context.Context,
+	name string,
+	args map[string]interface{},
+	elicitation *stdioElicitation,
+) (decision ApprovalDecision, required bool) {
+	if c.approval == nil || !c.approval.matches(name, c.lookupToolAnnotations(name)) {
+		return ApprovalDecision{}, false
+	}
+
+	start := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, c.approval.Timeout)
+	defer cancel()
+
+	var err error
+	if elicitation.supported() {
+		decision, err = c.elicitApproval(waitCtx, elicitation, name, args)
+	} else {
+		if c.stdioApprovals != nil {
+			c.stdioApprovals.serve()
+		}
+		decision, err = c.approvalQueue.Request(waitCtx, c.newApprovalRequest(ctx, name, args))
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		decision = ApprovalDecision{Reason: fmt.Sprintf("not decided within %s", c.approval.Timeout)}
+	case err != nil:
+		decision = ApprovalDecision{Reason: err.Error()}
+	}
+
+	c.recordApproval(ctx, name, args, start, decision)
+	return decision, true
+}
+
+func (c *ServerConfig) elicitApproval(
+	ctx context.Context,
+	elicitation *stdioElicitation,
+	name string,
+	args map[string]interface{},
+) (ApprovalDecision, error) {
+	argsJSON, err := json.MarshalIndent(redactArguments(args, c.auditRedactedKeys), "", "  ")
+	if err != nil {
+		return ApprovalDecision{}, err
+	}
+
+	result, err := elicitation.elicit(ctx, &protocol.ElicitationRequest{
+		Message:         fmt.Sprintf("Allow the tool %s to run with these arguments?\n%s", name, argsJSON),
+		RequestedSchema: approvalRequestedSchema,
+	})
+	if err != nil {
+		return ApprovalDecision{}, err
+	}
+
+	switch result.Action {
+	case protocol.ElicitationActionAccept:
+		approved, _ := result.Content["approve"].(bool)
+		return ApprovalDecision{Approved: approved, Approver: "user", Reason: "answered elicitation"}, nil
+	case protocol.ElicitationActionDecline:
+		return ApprovalDecision{Approver: "user", Reason: "declined elicitation"}, nil
+	default:
+		return ApprovalDecision{Approver: "user", Reason: "cancelled elicitation"}, nil
+	}
+}
+
+func (c *ServerConfig) newApprovalRequest(ctx context.Context, name string, args map[string]interface{}) ApprovalRequest {
+	request := ApprovalRequest{
+		Tool:      name,
+		Arguments: redactArguments(args, c.auditRedactedKeys),
+		CreatedAt: time.Now(),
+	}
+	if principal, ok := GetAuthPrincipal(ctx); ok {
+		request.Subject = principal.Subject
+		request.ClientID = principal.ClientID
+	}
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		request.SessionID = session.SessionID()
+	}
+	return request
+}
+
+// mountApprovalHandlers serves the approval queue on mux, unless it has its
+// own listener. The queue is only mounted next to /mcp when a token protects
+// it, as anyone who can reach /approvals can approve tool calls.
+func mountApprovalHandlers(mux *http.ServeMux, cfg *ServerConfig) {
+	if cfg == nil || cfg.approval == nil || cfg.approval.ListenAddr != "" {
+		return
+	}
+	if cfg.approval.Token == "" {
+		log.Warn().Msg("Approval queue is not served under /approvals without a token, set one or serve it on its own address")
+		return
+	}
+	handler := withRequestLogging(cfg.approvalQueue.Handler(cfg.approval.Token))
+	mux.Handle("/approvals", handler)
+	mux.Handle("/approvals/", handler)
+}
+
+// serveApprovals serves the approval queue on its own listener until ctx is
+// done, if one is configured.
+func serveApprovals(ctx context.Context, cfg *ServerConfig) {
+	if cfg == nil || cfg.approval == nil || cfg.approval.ListenAddr == "" {
+		return
+	}
+	if _, err := listenApprovals(ctx, cfg, cfg.approval.ListenAddr); err != nil {
+		log.Error().Err(err).Str("addr", cfg.approval.ListenAddr).Msg("Failed to serve approval queue")
+	}
+}
+
+// onDemandApprovals serves the approval queue for the stdio transport, which
+// has no HTTP listener to mount it on. The listener is only opened when a
+// call first waits on the queue, because the client doesn't support
+// elicitation; without it, such calls would wait until they time out.
+type onDemandApprovals struct {
+	ctx  context.Context
+	cfg  *ServerConfig
+	addr string
+
+	once sync.Once
+	// listening is the address served on, nil until the first call or if
+	// the listener failed
+	listening net.Addr
+}
+
+func (a *onDemandApprovals) serve() {
+	a.once.Do(func() {
+		log.Warn().Str("addr", a.addr).Msg("Serving approval queue on the default address for clients without elicitation")
+		if a.cfg.approval.Token == "" {
+			log.Warn().Str("addr", a.addr).Msg("Approval queue is served without a token, anyone who can reach it can approve tool calls")
+		}
+		addr, err := listenApprovals(a.ctx, a.cfg, a.addr)
+		if err != nil {
+			log.Error().Err(err).Str("addr", a.addr).Msg("Failed to serve approval queue")
+			return
+		}
+		a.listening = addr
+	})
+}
+
+// listenApprovals serves the approval queue on addr until ctx is done and
+// returns the address it listens on.
+func listenApprovals(ctx context.Context, cfg *ServerConfig, addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	server := &http.Server{
+		Handler:           withRequestLogging(cfg.approvalQueue.Handler(cfg.approval.Token)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		log.Info().Str("addr", listener.Addr().String()).Msg("Serving approval queue")
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Str("addr", addr).Msg("Approval queue server failed")
+		}
+	}()
+	return listener.Addr(), nil
+}
@@ -0,0 +1,252 @@
+package embeddable
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryAuditSink struct {
+	records []AuditRecord
+}
+
+func (s *memoryAuditSink) Record(_ context.Context, record AuditRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestApprovalQueueHandler(t *testing.T) {
+	queue := NewApprovalQueue()
+	server := httptest.NewServer(queue.Handler("s3cret"))
+	defer server.Close()
+
+	decided := make(chan ApprovalDecision, 1)
+	go func() {
+		decision, err := queue.Request(context.Background(), ApprovalRequest{Tool: "backup-db"})
+		if err != nil {
+			t.Errorf("Request: %v", err)
+		}
+		decided <- decision
+	}()
+	for len(queue.Pending()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	do := func(method, path, token, body string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	if resp := do(http.MethodGet, "/approvals", "wrong", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the token, got %s", resp.Status)
+	}
+
+	var pending []ApprovalRequest
+	resp := do(http.MethodGet, "/approvals", "s3cret", "")
+	if err := json.NewDecoder(resp.Body).Decode(&pending); err != nil {
+		t.Fatalf("decode pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Tool != "backup-db" || pending[0].ID == "" {
+		t.Fatalf("unexpected pending requests: %+v", pending)
+	}
+
+	if resp := do(http.MethodPost, "/approvals/nope/approve", "s3cret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown request, got %s", resp.Status)
+	}
+	if resp := do(http.MethodPost, "/approvals/"+pending[0].ID+"/approve", "s3cret", `{"approver":"alice"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("approve: %s", resp.Status)
+	}
+
+	decision := <-decided
+	if !decision.Approved || decision.Approver != "alice" {
+		t.Fatalf("unexpected decision: %+v", decision)
+	}
+	if len(queue.Pending()) != 0 {
+		t.Fatalf("decided request still pending")
+	}
+}
+
+func TestApproveToolCall(t *testing.T) {
+	sink := &memoryAuditSink{}
+	cfg := NewServerConfig()
+	for _, opt := range []ServerOption{
+		WithAuditSink(sink),
+		WithApproval(ApprovalSettings{Tools: []string{"deploy_*"}, Timeout: 50 * time.Millisecond}),
+	} {
+		if err := opt(cfg); err != nil {
+			t.Fatalf("option: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	if _, required := cfg.approveToolCall(ctx, "list_files", nil, nil); required {
+		t.Fatalf("list_files should not need approval")
+	}
+
+	// Without elicitation, the call waits on the queue until it times out
+	decision, required := cfg.approveToolCall(ctx, "deploy_prod", nil, nil)
+	if !required || decision.Approved || !strings.Contains(decision.Reason, "not decided within") {
+		t.Fatalf("unexpected decision: %+v", decision)
+	}
+
+	// With elicitation, the client answers the elicitation/create request
+	pr, pw := io.Pipe()
+	elicitation := newStdioElicitation(pw)
+	elicitation.intercept([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{"elicitation":{}}}}`))
+	go func() {
+		line, err := bufio.NewReader(pr).ReadBytes('\n')
+		if err != nil {
+			t.Errorf("read elicitation request: %v", err)
+			return
+		}
+		var request struct {
+			ID     string `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(line, &request); err != nil || request.Method != methodElicitationCreate {
+			t.Errorf("unexpected request %s: %v", line, err)
+			return
+		}
+		response := `{"jsonrpc":"2.0","id":"` + request.ID + `","result":{"action":"accept","content":{"approve":true}}}`
+		if !elicitation.intercept([]byte(response)) {
+			t.Errorf("elicitation response was not consumed")
+		}
+	}()
+
+	cfg.approval.Timeout = time.Second
+	decision, _ = cfg.approveToolCall(ctx, "deploy_prod", map[string]interface{}{"token": "x"}, elicitation)
+	if !decision.Approved {
+		t.Fatalf("expected approval, got %+v", decision)
+	}
+
+	if len(sink.records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(sink.records))
+	}
+	if sink.records[0].Status != AuditStatusRejected || sink.records[1].Status != AuditStatusApproved {
+		t.Fatalf("unexpected audit records: %+v", sink.records)
+	}
+	if sink.records[1].Arguments["token"] != auditRedacted {
+		t.Fatalf("arguments not redacted: %+v", sink.records[1].Arguments)
+	}
+}
+
+func TestApprovalQueueTimeout(t *testing.T) {
+	queue := NewApprovalQueue()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := queue.Request(ctx, ApprovalRequest{Tool: "git-sync"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if err := queue.Decide("1", ApprovalDecision{Approved: true}); !errors.Is(err, ErrApprovalNotFound) {
+		t.Fatalf("expected ErrApprovalNotFound, got %v", err)
+	}
+}
+
+func TestApprovalMatchesDestructive(t *testing.T) {
+	settings := ApprovalSettings{Destructive: true}
+	readOnly, destructive := true, false
+	if !settings.matches("git-sync", nil) {
+		t.Fatalf("tools without annotations are destructive")
+	}
+	if !settings.matches("git-sync", &ToolAnnotations{}) {
+		t.Fatalf("tools without destructiveHint are destructive")
+	}
+	if settings.matches("list_files", &ToolAnnotations{ReadOnlyHint: &readOnly}) {
+		t.Fatalf("read-only tools should not need approval")
+	}
+	if settings.matches("list_files", &ToolAnnotations{DestructiveHint: &destructive}) {
+		t.Fatalf("non-destructive tools should not need approval")
+	}
+}
+
+func TestStdioApprovalQueue(t *testing.T) {
+	cfg := NewServerConfig()
+	if err := WithApproval(ApprovalSettings{
+		Tools:   []string{"backup-*"},
+		Token:   "s3cret",
+		Timeout: 5 * time.Second,
+	})(cfg); err != nil {
+		t.Fatalf("WithApproval: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	approvals := &onDemandApprovals{ctx: ctx, cfg: cfg, addr: "127.0.0.1:0"}
+	cfg.stdioApprovals = approvals
+
+	// Calls that don't need approval don't open the listener
+	if _, required := cfg.approveToolCall(ctx, "list_files", nil, nil); required {
+		t.Fatalf("list_files should not need approval")
+	}
+	if approvals.listening != nil {
+		t.Fatalf("approval queue served before a call waited on it")
+	}
+
+	// A client without elicitation waits until the call is approved over HTTP
+	decided := make(chan ApprovalDecision, 1)
+	go func() {
+		decision, _ := cfg.approveToolCall(ctx, "backup-db", nil, nil)
+		decided <- decision
+	}()
+	for len(cfg.approvalQueue.Pending()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if approvals.listening == nil {
+		t.Fatalf("approval queue not served")
+	}
+
+	id := cfg.approvalQueue.Pending()[0].ID
+	req, err := http.NewRequest(http.MethodPost, "http://"+approvals.listening.String()+"/approvals/"+id+"/approve", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("approve: %s", resp.Status)
+	}
+
+	if decision := <-decided; !decision.Approved {
+		t.Fatalf("expected approval, got %+v", decision)
+	}
+}
+
+func TestMountApprovalHandlersRequiresToken(t *testing.T) {
+	for _, token := range []string{"", "s3cret"} {
+		cfg := NewServerConfig()
+		if err := WithApproval(ApprovalSettings{Destructive: true, Token: token})(cfg); err != nil {
+			t.Fatalf("WithApproval: %v", err)
+		}
+		mux := http.NewServeMux()
+		mountApprovalHandlers(mux, cfg)
+
+		_, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, "/approvals", nil))
+		if mounted := pattern != ""; mounted != (token != "") {
+			t.Fatalf("token %q: mounted = %v", token, mounted)
+		}
+	}
+}
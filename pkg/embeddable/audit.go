@@ -0,0 +1,274 @@
+package embeddable
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// AuditStatus is the outcome of an audited tool call
+type AuditStatus string
+
+const (
+	// AuditStatusOK means the tool returned a successful result
+	AuditStatusOK AuditStatus = "ok"
+	// AuditStatusToolError means the tool returned a result flagged as error
+	AuditStatusToolError AuditStatus = "tool_error"
+	// AuditStatusError means the call failed with an error
+	AuditStatusError AuditStatus = "error"
+	// AuditStatusDenied means the call was rejected by a tool policy
+	AuditStatusDenied AuditStatus = "denied"
+	// AuditStatusApproved records that a call needing approval was approved
+	AuditStatusApproved AuditStatus = "approved"
+	// AuditStatusRejected records that a call needing approval was denied or
+	// not decided in time
+	AuditStatusRejected AuditStatus = "rejected"
+)
+
+// AuditRecord describes a single tool call
+type AuditRecord struct {
+	// ID is assigned by the sink when reading records back
+	ID         int64                  `json:"id,omitempty"`
+	Timestamp  time.Time              `json:"ts"`
+	Subject    string                 `json:"subject,omitempty"`
+	ClientID   string                 `json:"client_id,omitempty"`
+	SessionID  string                 `json:"session_id,omitempty"`
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	Result     string                 `json:"result,omitempty"`
+	Status     AuditStatus            `json:"status"`
+	DurationMs int64                  `json:"duration_ms"`
+}
+
+// AuditSink persists audit records. Record is called synchronously after
+// every tool call, errors are logged and do not fail the call.
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// AuditQuery selects records from an audit sink. Zero values match everything.
+type AuditQuery struct {
+	Tool    string
+	Subject string
+	Status  AuditStatus
+	Since   time.Time
+	// AfterID only returns records with a greater ID, used to follow a sink
+	AfterID int64
+	// Limit returns only the most recent records, 0 means no limit
+	Limit int
+}
+
+// AuditReader is implemented by sinks whose records can be queried back.
+// Records are returned in chronological order.
+type AuditReader interface {
+	ListAuditRecords(ctx context.Context, query AuditQuery) ([]AuditRecord, error)
+}
+
+// DefaultAuditRedactedKeys are the argument names whose values are redacted
+// by default. Matching is case-insensitive on substrings.
+var DefaultAuditRedactedKeys = []string{"password", "secret", "token", "apikey", "api_key", "authorization", "credential"}
+
+const (
+	auditRedacted        = "[REDACTED]"
+	auditMaxValueLength  = 1024
+	auditMaxResultLength = 256
+)
+
+// WithAuditSink records every tool call to sink. It can be given several
+// times to write to multiple sinks.
+func WithAuditSink(sink AuditSink) ServerOption {
+	return func(config *ServerConfig) error {
+		config.auditSinks = append(config.auditSinks, sink)
+		return nil
+	}
+}
+
+// WithAuditRedactedKeys replaces the argument names whose values are redacted
+// in audit records (DefaultAuditRedactedKeys by default).
+func WithAuditRedactedKeys(keys ...string) ServerOption {
+	return func(config *ServerConfig) error {
+		config.auditRedactedKeys = keys
+		return nil
+	}
+}
+
+// auditsTo returns whether a SQLite audit sink already records to path
+func (c *ServerConfig) auditsTo(path string) bool {
+	for _, sink := range c.auditSinks {
+		if s, ok := sink.(*SQLiteAuditSink); ok && filepath.Clean(s.path) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ServerConfig) recordToolCall(
+	ctx context.Context,
+	name string,
+	args map[string]interface{},
+	start time.Time,
+	res *protocol.ToolResult,
+	err error,
+	status AuditStatus,
+) {
+	if len(c.auditSinks) == 0 {
+		return
+	}
+
+	record := c.newAuditRecord(ctx, name, args, start, status)
+	switch {
+	case err != nil:
+		record.Result = truncate(err.Error(), auditMaxResultLength)
+		if record.Status == "" {
+			record.Status = AuditStatusError
+		}
+	case res != nil:
+		record.Result = summarizeToolResult(res)
+		if record.Status == "" {
+			record.Status = AuditStatusOK
+			if res.IsError {
+				record.Status = AuditStatusToolError
+			}
+		}
+	}
+	c.writeAuditRecord(ctx, record)
+}
+
+// recordApproval records the decision on a call that needed approval, ahead
+// of the record of the call itself if it was approved.
+func (c *ServerConfig) recordApproval(
+	ctx context.Context,
+	name string,
+	args map[string]interface{},
+	start time.Time,
+	decision ApprovalDecision,
+) {
+	if len(c.auditSinks) == 0 {
+		return
+	}
+
+	status := AuditStatusRejected
+	if decision.Approved {
+		status = AuditStatusApproved
+	}
+	record := c.newAuditRecord(ctx, name, args, start, status)
+	record.Result = truncate(decision.String(), auditMaxResultLength)
+	c.writeAuditRecord(ctx, record)
+}
+
+func (c *ServerConfig) newAuditRecord(
+	ctx context.Context,
+	name string,
+	args map[string]interface{},
+	start time.Time,
+	status AuditStatus,
+) AuditRecord {
+	record := AuditRecord{
+		Timestamp:  start,
+		Tool:       name,
+		Arguments:  redactArguments(args, c.auditRedactedKeys),
+		Status:     status,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if principal, ok := GetAuthPrincipal(ctx); ok {
+		record.Subject = principal.Subject
+		record.ClientID = principal.ClientID
+	}
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		record.SessionID = session.SessionID()
+	}
+	return record
+}
+
+func (c *ServerConfig) writeAuditRecord(ctx context.Context, record AuditRecord) {
+	// Record even if the call was cancelled
+	ctx = context.WithoutCancel(ctx)
+	for _, sink := range c.auditSinks {
+		if err := sink.Record(ctx, record); err != nil {
+			log.Error().Err(err).Str("tool", record.Tool).Msg("Failed to record tool call")
+		}
+	}
+}
+
+// redactArguments returns a copy of args with sensitive values replaced and
+// long strings truncated. Nested maps are redacted recursively.
+func redactArguments(args map[string]interface{}, keys []string) map[string]interface{} {
+	if args == nil {
+		return nil
+	}
+	if keys == nil {
+		keys = DefaultAuditRedactedKeys
+	}
+
+	ret := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if isRedactedKey(k, keys) {
+			ret[k] = auditRedacted
+			continue
+		}
+		ret[k] = redactValue(v, keys)
+	}
+	return ret
+}
+
+func redactValue(v interface{}, keys []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return redactArguments(v, keys)
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = redactValue(item, keys)
+		}
+		return ret
+	case string:
+		return truncate(v, auditMaxValueLength)
+	default:
+		return v
+	}
+}
+
+func isRedactedKey(key string, keys []string) bool {
+	key = strings.ToLower(key)
+	for _, k := range keys {
+		if strings.Contains(key, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeToolResult describes a tool result in a single short line
+func summarizeToolResult(res *protocol.ToolResult) string {
+	var parts []string
+	for _, content := range res.Content {
+		switch content.Type {
+		case "text":
+			parts = append(parts, content.Text)
+		case "resource":
+			if content.Resource != nil {
+				parts = append(parts, fmt.Sprintf("[resource %s]", content.Resource.URI))
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("[%s %s]", content.Type, content.MimeType))
+		}
+	}
+	return truncate(strings.Join(strings.Fields(strings.Join(parts, " ")), " "), auditMaxResultLength)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,357 @@
+package embeddable
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	embeddedoidc "github.com/go-go-golems/go-go-mcp/pkg/auth/oidc"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// AuditSettings selects the audit sinks opened by OpenAuditSinks
+type AuditSettings struct {
+	// DBPath is the SQLite database to record to, if set
+	DBPath string
+	// FilePath is the JSONL file to record to, if set
+	FilePath string
+	// Log records to the global zerolog logger
+	Log bool
+}
+
+// OpenAuditSinks opens the sinks selected in settings. The returned function
+// closes them and is valid even if no sink was opened.
+func OpenAuditSinks(settings AuditSettings) ([]AuditSink, func(), error) {
+	var sinks []AuditSink
+	var closers []io.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			if err := c.Close(); err != nil {
+				log.Warn().Err(err).Msg("Failed to close audit sink")
+			}
+		}
+	}
+
+	if settings.DBPath != "" {
+		sink, err := NewSQLiteAuditSink(settings.DBPath)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		sinks = append(sinks, sink)
+		closers = append(closers, sink)
+	}
+	if settings.FilePath != "" {
+		sink, err := NewJSONLAuditSink(settings.FilePath)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		sinks = append(sinks, sink)
+		closers = append(closers, sink)
+	}
+	if settings.Log {
+		sinks = append(sinks, NewZerologAuditSink(log.Logger))
+	}
+
+	return sinks, closeAll, nil
+}
+
+// ZerologAuditSink writes audit records as structured log events
+type ZerologAuditSink struct {
+	logger zerolog.Logger
+}
+
+var _ AuditSink = &ZerologAuditSink{}
+
+// NewZerologAuditSink creates a sink logging records at info level to logger
+func NewZerologAuditSink(logger zerolog.Logger) *ZerologAuditSink {
+	return &ZerologAuditSink{logger: logger}
+}
+
+func (s *ZerologAuditSink) Record(_ context.Context, record AuditRecord) error {
+	s.logger.Info().
+		Str("component", "audit").
+		Time("ts", record.Timestamp).
+		Str("subject", record.Subject).
+		Str("client_id", record.ClientID).
+		Str("session_id", record.SessionID).
+		Str("tool", record.Tool).
+		Interface("arguments", record.Arguments).
+		Str("result", record.Result).
+		Str("status", string(record.Status)).
+		Int64("duration_ms", record.DurationMs).
+		Msg("Tool call")
+	return nil
+}
+
+// JSONLAuditSink appends audit records to a file, one JSON object per line
+type JSONLAuditSink struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+var _ AuditSink = &JSONLAuditSink{}
+var _ AuditReader = &JSONLAuditSink{}
+
+// NewJSONLAuditSink opens (or creates) the audit file at path for appending
+func NewJSONLAuditSink(path string) (*JSONLAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &JSONLAuditSink{path: path, f: f}, nil
+}
+
+// OpenJSONLAuditFile opens an existing audit file for reading only
+func OpenJSONLAuditFile(path string) *JSONLAuditSink {
+	return &JSONLAuditSink{path: path}
+}
+
+func (s *JSONLAuditSink) Record(_ context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return fmt.Errorf("audit file %s is not open for writing", s.path)
+	}
+	_, err = s.f.Write(append(data, '\n'))
+	return err
+}
+
+// ListAuditRecords implements AuditReader. Record IDs are line numbers.
+func (s *JSONLAuditSink) ListAuditRecords(ctx context.Context, query AuditQuery) ([]AuditRecord, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var ret []AuditRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var line int64
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line++
+		if line <= query.AfterID || len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid audit record on line %d: %w", line, err)
+		}
+		record.ID = line
+		if query.matches(record) {
+			ret = append(ret, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(ret) > query.Limit {
+		ret = ret[len(ret)-query.Limit:]
+	}
+	return ret, nil
+}
+
+// Close closes the audit file
+func (s *JSONLAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+func (q AuditQuery) matches(record AuditRecord) bool {
+	if q.Tool != "" && record.Tool != q.Tool {
+		return false
+	}
+	if q.Subject != "" && record.Subject != q.Subject {
+		return false
+	}
+	if q.Status != "" && record.Status != q.Status {
+		return false
+	}
+	if !q.Since.IsZero() && record.Timestamp.Before(q.Since) {
+		return false
+	}
+	return true
+}
+
+// SQLiteAuditSink stores audit records in the mcp_tool_calls table of a
+// SQLite database. This is the same table the embedded OIDC server creates,
+// so the OIDC database can be used to keep tokens and audit trail together.
+type SQLiteAuditSink struct {
+	path string
+	db   *sql.DB
+}
+
+var _ AuditSink = &SQLiteAuditSink{}
+var _ AuditReader = &SQLiteAuditSink{}
+
+// NewSQLiteAuditSink opens the database at path and creates the audit table
+// if needed.
+func NewSQLiteAuditSink(path string) (*SQLiteAuditSink, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	if err := embeddedoidc.MigrateMCPCallTable(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create audit table: %w", err)
+	}
+	return &SQLiteAuditSink{path: path, db: db}, nil
+}
+
+func (s *SQLiteAuditSink) Record(ctx context.Context, record AuditRecord) error {
+	entry, err := mcpCallLog(record)
+	if err != nil {
+		return err
+	}
+	return embeddedoidc.InsertMCPCall(ctx, s.db, entry)
+}
+
+// mcpCallLog converts record to a row of the mcp_tool_calls table
+func mcpCallLog(record AuditRecord) (embeddedoidc.MCPCallLog, error) {
+	args, err := json.Marshal(record.Arguments)
+	if err != nil {
+		return embeddedoidc.MCPCallLog{}, err
+	}
+	result, err := json.Marshal(record.Result)
+	if err != nil {
+		return embeddedoidc.MCPCallLog{}, err
+	}
+	return embeddedoidc.MCPCallLog{
+		Timestamp:  record.Timestamp,
+		Subject:    record.Subject,
+		ClientID:   record.ClientID,
+		SessionID:  record.SessionID,
+		ToolName:   record.Tool,
+		ArgsJSON:   string(args),
+		ResultJSON: string(result),
+		Status:     string(record.Status),
+		DurationMs: record.DurationMs,
+	}, nil
+}
+
+// ListAuditRecords implements AuditReader
+func (s *SQLiteAuditSink) ListAuditRecords(ctx context.Context, query AuditQuery) ([]AuditRecord, error) {
+	where := []string{"id > ?"}
+	params := []interface{}{query.AfterID}
+	if query.Tool != "" {
+		where = append(where, "tool_name = ?")
+		params = append(params, query.Tool)
+	}
+	if query.Subject != "" {
+		where = append(where, "subject = ?")
+		params = append(params, query.Subject)
+	}
+	if query.Status != "" {
+		where = append(where, "status = ?")
+		params = append(params, string(query.Status))
+	}
+	if !query.Since.IsZero() {
+		where = append(where, "ts >= ?")
+		params = append(params, query.Since.UTC())
+	}
+
+	q := `SELECT id, ts, subject, client_id, session_id, tool_name, args_json, result_json, status, duration_ms
+        FROM mcp_tool_calls WHERE ` + strings.Join(where, " AND ") + ` ORDER BY id DESC`
+	if query.Limit > 0 {
+		q += " LIMIT ?"
+		params = append(params, query.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, q, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var ret []AuditRecord
+	for rows.Next() {
+		var (
+			record                       AuditRecord
+			subject, clientID, sessionID sql.NullString
+			args, result                 sql.NullString
+			status                       string
+		)
+		if err := rows.Scan(&record.ID, &record.Timestamp, &subject, &clientID, &sessionID, &record.Tool, &args, &result, &status, &record.DurationMs); err != nil {
+			return nil, err
+		}
+		record.Subject = subject.String
+		record.ClientID = clientID.String
+		record.SessionID = sessionID.String
+		record.Status = AuditStatus(status)
+		if args.Valid && args.String != "" {
+			_ = json.Unmarshal([]byte(args.String), &record.Arguments)
+		}
+		if result.Valid && json.Unmarshal([]byte(result.String), &record.Result) != nil {
+			// rows written by oidc.Server.LogMCPCall store raw JSON
+			record.Result = result.String
+		}
+		ret = append(ret, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Rows were selected newest first to apply the limit
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret, nil
+}
+
+// Close closes the database
+func (s *SQLiteAuditSink) Close() error {
+	return s.db.Close()
+}
+
+// OIDCAuditSink records tool calls in the database of an embedded OIDC
+// server. It is added automatically when the embedded_dev auth mode is used
+// with a database.
+type OIDCAuditSink struct {
+	server *embeddedoidc.Server
+}
+
+var _ AuditSink = &OIDCAuditSink{}
+
+// NewOIDCAuditSink creates a sink recording with server.LogMCPCall
+func NewOIDCAuditSink(server *embeddedoidc.Server) *OIDCAuditSink {
+	return &OIDCAuditSink{server: server}
+}
+
+func (s *OIDCAuditSink) Record(_ context.Context, record AuditRecord) error {
+	entry, err := mcpCallLog(record)
+	if err != nil {
+		return err
+	}
+	return s.server.LogMCPCall(entry)
+}
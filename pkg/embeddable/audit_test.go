@@ -0,0 +1,117 @@
+package embeddable
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+)
+
+func TestAuditSinksRecordAndList(t *testing.T) {
+	dir := t.TempDir()
+	db, err := NewSQLiteAuditSink(filepath.Join(dir, "audit.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteAuditSink: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	file, err := NewJSONLAuditSink(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewJSONLAuditSink: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	cfg := NewServerConfig()
+	for _, opt := range []ServerOption{WithAuditSink(db), WithAuditSink(file)} {
+		if err := opt(cfg); err != nil {
+			t.Fatalf("option: %v", err)
+		}
+	}
+
+	ctx := WithAuthPrincipal(context.Background(), AuthPrincipal{Subject: "alice", ClientID: "web"})
+	start := time.Now()
+	args := map[string]interface{}{
+		"cmd":       "ls",
+		"api_token": "secret",
+		"nested":    map[string]interface{}{"Password": "hunter2"},
+	}
+	cfg.recordToolCall(ctx, "shell", args, start, protocol.NewToolResult(protocol.WithText("hello\n  world")), nil, "")
+	cfg.recordToolCall(ctx, "deploy", nil, start, nil, errors.New("not allowed"), AuditStatusDenied)
+
+	for _, reader := range []AuditReader{db, OpenJSONLAuditFile(filepath.Join(dir, "audit.jsonl"))} {
+		records, err := reader.ListAuditRecords(context.Background(), AuditQuery{})
+		if err != nil {
+			t.Fatalf("ListAuditRecords: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		first := records[0]
+		if first.Subject != "alice" || first.ClientID != "web" || first.Status != AuditStatusOK || first.Result != "hello world" {
+			t.Fatalf("unexpected record: %+v", first)
+		}
+		if first.Arguments["api_token"] != auditRedacted || first.Arguments["nested"].(map[string]interface{})["Password"] != auditRedacted {
+			t.Fatalf("arguments not redacted: %+v", first.Arguments)
+		}
+		if records[1].Status != AuditStatusDenied || records[1].Result != "not allowed" {
+			t.Fatalf("unexpected record: %+v", records[1])
+		}
+
+		latest, err := reader.ListAuditRecords(context.Background(), AuditQuery{Limit: 1})
+		if err != nil {
+			t.Fatalf("ListAuditRecords: %v", err)
+		}
+		if len(latest) != 1 || latest[0].Tool != "deploy" {
+			t.Fatalf("expected latest record to be deploy, got %+v", latest)
+		}
+
+		after, err := reader.ListAuditRecords(context.Background(), AuditQuery{AfterID: latest[0].ID})
+		if err != nil {
+			t.Fatalf("ListAuditRecords: %v", err)
+		}
+		if len(after) != 0 {
+			t.Fatalf("expected no records after the latest one, got %+v", after)
+		}
+	}
+}
+
+func TestEmbeddedOIDCRecordsToolCalls(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "oidc.db")
+	cfg := NewServerConfig()
+	if err := WithOIDC(OIDCOptions{Issuer: "http://localhost:3001", DBPath: dbPath})(cfg); err != nil {
+		t.Fatalf("WithOIDC: %v", err)
+	}
+	if _, err := newHTTPAuthProvider(cfg); err != nil {
+		t.Fatalf("newHTTPAuthProvider: %v", err)
+	}
+	if len(cfg.auditSinks) != 1 {
+		t.Fatalf("expected the OIDC database to be audited, got %d sinks", len(cfg.auditSinks))
+	}
+
+	ctx := WithAuthPrincipal(context.Background(), AuthPrincipal{Subject: "alice", ClientID: "web"})
+	cfg.recordToolCall(ctx, "shell", map[string]interface{}{"cmd": "ls"}, time.Now(), protocol.NewToolResult(protocol.WithText("ok")), nil, "")
+
+	sink, err := NewSQLiteAuditSink(dbPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteAuditSink: %v", err)
+	}
+	defer func() { _ = sink.Close() }()
+	records, err := sink.ListAuditRecords(context.Background(), AuditQuery{})
+	if err != nil {
+		t.Fatalf("ListAuditRecords: %v", err)
+	}
+	if len(records) != 1 || records[0].Subject != "alice" || records[0].Status != AuditStatusOK || records[0].Result != "ok" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	// A SQLite sink on the same database isn't doubled
+	cfg.auditSinks = []AuditSink{sink}
+	if _, err := newHTTPAuthProvider(cfg); err != nil {
+		t.Fatalf("newHTTPAuthProvider: %v", err)
+	}
+	if len(cfg.auditSinks) != 1 {
+		t.Fatalf("expected a single sink, got %d", len(cfg.auditSinks))
+	}
+}
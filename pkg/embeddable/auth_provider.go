@@ -40,7 +40,15 @@ func newHTTPAuthProvider(cfg *ServerConfig) (HTTPAuthProvider, error) {
 	case AuthModeNone:
 		return nil, nil
 	case AuthModeEmbeddedDev:
-		return newEmbeddedDevAuthProvider(cfg.authOptions)
+		provider, err := newEmbeddedDevAuthProvider(cfg.authOptions)
+		if err != nil {
+			return nil, err
+		}
+		if dbPath := cfg.authOptions.Embedded.DBPath; dbPath != "" && !cfg.auditsTo(dbPath) {
+			// Keep the audit trail next to the tokens it was authorized with
+			cfg.auditSinks = append(cfg.auditSinks, NewOIDCAuditSink(provider.server))
+		}
+		return provider, nil
 	case AuthModeExternalOIDC:
 		return newExternalOIDCAuthProvider(cfg.authOptions)
 	default:
@@ -0,0 +1,151 @@
+package embeddable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	mcp "github.com/mark3labs/mcp-go/mcp"
+)
+
+// ErrToolForbidden is returned when the caller is not allowed to call a tool
+var ErrToolForbidden = errors.New("tool call not authorized")
+
+// ToolPolicy restricts which callers may list and call the tools it matches.
+//
+// A policy matches the tools whose name matches one of the Tools glob
+// patterns (all tools if Tools is empty). With Destructive set, it only
+// matches tools that are destructive according to their annotations; tools
+// without annotations are considered destructive, as in the MCP spec.
+//
+// A caller satisfies a policy if it has all Scopes, and its subject and
+// client ID are listed in Subjects and ClientIDs when those are set. A tool
+// can only be called if the caller satisfies every policy matching it.
+// Requests without an authenticated principal never satisfy a policy.
+type ToolPolicy struct {
+	Tools       []string `yaml:"tools,omitempty"`
+	Destructive bool     `yaml:"destructive,omitempty"`
+
+	Scopes    []string `yaml:"scopes,omitempty"`
+	Subjects  []string `yaml:"subjects,omitempty"`
+	ClientIDs []string `yaml:"client_ids,omitempty"`
+}
+
+// WithToolPolicies restricts tools to the callers allowed by policies. Tools
+// the caller may not call are hidden from tools/list and rejected on call.
+func WithToolPolicies(policies ...ToolPolicy) ServerOption {
+	return func(config *ServerConfig) error {
+		for _, policy := range policies {
+			for _, pattern := range policy.Tools {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+				}
+			}
+		}
+		config.toolPolicies = append(config.toolPolicies, policies...)
+		return nil
+	}
+}
+
+func (p ToolPolicy) matches(name string, annotations *ToolAnnotations) bool {
+	if len(p.Tools) > 0 {
+		matched := false
+		for _, pattern := range p.Tools {
+			if ok, _ := path.Match(pattern, name); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if p.Destructive && !isDestructive(annotations) {
+		return false
+	}
+
+	return true
+}
+
+func (p ToolPolicy) allows(principal AuthPrincipal) bool {
+	for _, scope := range p.Scopes {
+		if !contains(principal.Scopes, scope) {
+			return false
+		}
+	}
+	if len(p.Subjects) > 0 && !contains(p.Subjects, principal.Subject) {
+		return false
+	}
+	if len(p.ClientIDs) > 0 && !contains(p.ClientIDs, principal.ClientID) {
+		return false
+	}
+	return true
+}
+
+// isDestructive applies the MCP defaults: a tool is destructive unless it is
+// read-only or explicitly marked as non-destructive.
+func isDestructive(annotations *ToolAnnotations) bool {
+	if annotations == nil {
+		return true
+	}
+	if annotations.ReadOnlyHint != nil && *annotations.ReadOnlyHint {
+		return false
+	}
+	if annotations.DestructiveHint == nil {
+		return true
+	}
+	return *annotations.DestructiveHint
+}
+
+// authorizeTool checks the tool policies for the principal stored in ctx
+func (c *ServerConfig) authorizeTool(ctx context.Context, name string) error {
+	if len(c.toolPolicies) == 0 {
+		return nil
+	}
+
+	annotations := c.lookupToolAnnotations(name)
+	principal, hasPrincipal := GetAuthPrincipal(ctx)
+	for _, policy := range c.toolPolicies {
+		if !policy.matches(name, annotations) {
+			continue
+		}
+		if !hasPrincipal || !policy.allows(principal) {
+			return fmt.Errorf("%w: %s", ErrToolForbidden, name)
+		}
+	}
+	return nil
+}
+
+// lookupToolAnnotations returns the annotations of the tool called name, or
+// nil if it has none
+func (c *ServerConfig) lookupToolAnnotations(name string) *ToolAnnotations {
+	if a, ok := c.toolAnnotations[name]; ok {
+		return &a
+	}
+	if tool, ok := c.toolRegistry.GetTool(name); ok {
+		return tool.GetToolDefinition().Annotations
+	}
+	return nil
+}
+
+// filterTools is an mcp-go tool filter hiding the tools the caller may not call
+func (c *ServerConfig) filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	ret := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if c.authorizeTool(ctx, tool.Name) == nil {
+			ret = append(ret, tool)
+		}
+	}
+	return ret
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package embeddable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mcp "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolPolicies(t *testing.T) {
+	cfg := NewServerConfig()
+	readOnly := true
+	cfg.toolAnnotations["read"] = ToolAnnotations{ReadOnlyHint: &readOnly}
+	if err := WithToolPolicies(
+		ToolPolicy{Destructive: true, Scopes: []string{"admin"}},
+		ToolPolicy{Tools: []string{"deploy_*"}, ClientIDs: []string{"ci"}},
+	)(cfg); err != nil {
+		t.Fatalf("WithToolPolicies: %v", err)
+	}
+
+	admin := WithAuthPrincipal(context.Background(), AuthPrincipal{Subject: "alice", ClientID: "web", Scopes: []string{"admin"}})
+	ci := WithAuthPrincipal(context.Background(), AuthPrincipal{Subject: "bot", ClientID: "ci", Scopes: []string{"admin"}})
+	reader := WithAuthPrincipal(context.Background(), AuthPrincipal{Subject: "bob", ClientID: "web"})
+
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		tool    string
+		allowed bool
+	}{
+		{"read-only tool for anyone", reader, "read", true},
+		{"read-only tool without principal", context.Background(), "read", true},
+		{"destructive tool for admin", admin, "shell", true},
+		{"destructive tool without scope", reader, "shell", false},
+		{"destructive tool without principal", context.Background(), "shell", false},
+		{"deploy tool from other client", admin, "deploy_prod", false},
+		{"deploy tool from ci", ci, "deploy_prod", true},
+	}
+	for _, c := range cases {
+		err := cfg.authorizeTool(c.ctx, c.tool)
+		if c.allowed && err != nil {
+			t.Errorf("%s: expected allowed, got %v", c.name, err)
+		}
+		if !c.allowed && !errors.Is(err, ErrToolForbidden) {
+			t.Errorf("%s: expected ErrToolForbidden, got %v", c.name, err)
+		}
+	}
+
+	tools := cfg.filterTools(reader, []mcp.Tool{{Name: "read"}, {Name: "shell"}})
+	if len(tools) != 1 || tools[0].Name != "read" {
+		t.Fatalf("expected only read to be listed, got %v", tools)
+	}
+}
+
+func TestWithToolPoliciesRejectsInvalidPattern(t *testing.T) {
+	if err := WithToolPolicies(ToolPolicy{Tools: []string{"["}})(NewServerConfig()); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
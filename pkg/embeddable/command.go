@@ -74,6 +74,10 @@ func NewMCPCommand(opts ...ServerOption) *cobra.Command {
 	startCmd.Flags().String("embedded-auth-key", config.authOptions.Embedded.AuthKey, "Static bearer token for embedded_dev mode")
 	startCmd.Flags().String("embedded-user", config.authOptions.Embedded.User, "Static login username for embedded_dev mode")
 	startCmd.Flags().String("embedded-pass", config.authOptions.Embedded.Pass, "Static login password for embedded_dev mode")
+	// Audit flags
+	startCmd.Flags().String("audit-db", "", "SQLite DB path to record tool calls to (may be the OIDC DB)")
+	startCmd.Flags().String("audit-file", "", "JSONL file to record tool calls to")
+	startCmd.Flags().Bool("audit-log", false, "Log tool calls as structured log events")
 	if config.enableConfig {
 		startCmd.Flags().String("config", config.configFile, "Configuration file path")
 	}
@@ -220,6 +224,16 @@ func startServer(cmd *cobra.Command, config *ServerConfig) error {
 		config.authOptions.Embedded.Pass = firstNonEmpty(embeddedPass, pass)
 	}
 
+	auditDB, _ := cmd.Flags().GetString("audit-db")
+	auditFile, _ := cmd.Flags().GetString("audit-file")
+	auditLog, _ := cmd.Flags().GetBool("audit-log")
+	auditSinks, closeAudit, err := OpenAuditSinks(AuditSettings{DBPath: auditDB, FilePath: auditFile, Log: auditLog})
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+	config.auditSinks = append(config.auditSinks, auditSinks...)
+
 	// Set up context with cancellation, tied to OS signals
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer stop()
@@ -272,6 +286,9 @@ func listTools(cmd *cobra.Command, config *ServerConfig) error {
 
 		fmt.Printf("Tool: %s\n", tool.Name)
 		fmt.Printf("Description: %s\n", tool.Description)
+		if tool.Annotations != nil {
+			fmt.Printf("Annotations: %s\n", tool.Annotations)
+		}
 
 		// Parse and display input schema
 		if len(tool.InputSchema) > 0 {
@@ -15,14 +15,9 @@ type EnhancedToolHandler func(ctx context.Context, args Arguments) (*protocol.To
 // PropertyOption configures a property in a tool's input schema
 type PropertyOption func(map[string]interface{})
 
-// ToolAnnotations provides metadata about tool behavior
-type ToolAnnotations struct {
-	Title           string `json:"title,omitempty"`
-	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
-	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
-	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
-	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
-}
+// ToolAnnotations provides metadata about tool behavior. They are sent to
+// clients with the tool definition.
+type ToolAnnotations = protocol.ToolAnnotations
 
 // Enhanced tool registration with mark3labs/mcp-go inspired API
 func WithEnhancedTool(name string, handler EnhancedToolHandler, opts ...EnhancedToolOption) ServerOption {
@@ -54,9 +49,12 @@ func WithEnhancedTool(name string, handler EnhancedToolHandler, opts ...Enhanced
 		if err != nil {
 			return err
 		}
+		config.toolAnnotations[name] = toolConfig.Annotations
 
 		// Register the tool with enhanced handler
 		config.toolRegistry.RegisterToolWithHandler(tool, func(ctx context.Context, tool tools.Tool, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+			ctx = withToolName(ctx, name)
+
 			// Wrap arguments with enhanced accessor
 			args := NewArguments(arguments)
 
@@ -102,10 +100,11 @@ func WithEnhancedTool(name string, handler EnhancedToolHandler, opts ...Enhanced
 
 // EnhancedToolConfig holds configuration for enhanced tools
 type EnhancedToolConfig struct {
-	Description string
-	Schema      map[string]interface{}
-	Annotations ToolAnnotations
-	Examples    []ToolExample
+	Description  string
+	Schema       map[string]interface{}
+	OutputSchema map[string]interface{}
+	Annotations  ToolAnnotations
+	Examples     []ToolExample
 }
 
 // EnhancedToolOption configures enhanced tools
@@ -119,6 +118,15 @@ func WithEnhancedDescription(desc string) EnhancedToolOption {
 	}
 }
 
+// WithEnhancedOutputSchema declares the schema of the tool's structured
+// content, see WithOutputSchema.
+func WithEnhancedOutputSchema(schema map[string]interface{}) EnhancedToolOption {
+	return func(config *EnhancedToolConfig) error {
+		config.OutputSchema = schema
+		return nil
+	}
+}
+
 func WithAnnotations(annotations ToolAnnotations) EnhancedToolOption {
 	return func(config *EnhancedToolConfig) error {
 		config.Annotations = annotations
@@ -336,7 +344,18 @@ func createEnhancedTool(name string, config *EnhancedToolConfig) (tools.Tool, er
 		return nil, err
 	}
 
-	return tools.NewToolImpl(name, config.Description, json.RawMessage(schemaBytes))
+	tool, err := tools.NewToolImpl(name, config.Description, json.RawMessage(schemaBytes))
+	if err != nil {
+		return nil, err
+	}
+	if config.OutputSchema != nil {
+		if err := tool.SetOutputSchema(config.OutputSchema); err != nil {
+			return nil, err
+		}
+	}
+	annotations := config.Annotations
+	tool.SetAnnotations(&annotations)
+	return tool, nil
 }
 
 func boolPtr(b bool) *bool {
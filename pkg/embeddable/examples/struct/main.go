@@ -17,7 +17,7 @@ type DatabaseService struct {
 
 type QueryArgs struct {
 	Query string `json:"query" description:"SQL query to execute"`
-	Limit int    `json:"limit,omitempty" description:"Maximum number of rows to return"`
+	Limit int    `json:"limit,omitempty" jsonschema:"description=Maximum number of rows to return,minimum=1,default=100"`
 }
 
 func (db *DatabaseService) ExecuteQuery(ctx context.Context, args QueryArgs) (*protocol.ToolResult, error) {
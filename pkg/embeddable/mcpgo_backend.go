@@ -1,14 +1,21 @@
 package embeddable
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/go-go-golems/go-go-mcp/pkg/tools"
 	"github.com/go-go-golems/go-go-mcp/pkg/tools/providers/tool-registry"
 	mcp "github.com/mark3labs/mcp-go/mcp"
 	mcpserver "github.com/mark3labs/mcp-go/server"
@@ -39,21 +46,15 @@ func NewBackend(cfg *ServerConfig) (Backend, error) {
 		Int("port", cfg.defaultPort).
 		Msg("Creating mcp-go backend")
 
-	// Build mcp-go server
-	s := mcpserver.NewMCPServer(cfg.Name, cfg.Version,
-		mcpserver.WithToolCapabilities(true),
-		mcpserver.WithLogging(),
-	)
-
-	// Register tools from our registry into mcp-go server
-	if err := registerToolsFromRegistry(context.Background(), s, cfg.toolRegistry, cfg); err != nil {
+	s, err := newMCPServer(context.Background(), cfg)
+	if err != nil {
 		return nil, err
 	}
 
 	switch cfg.defaultTransport {
 	case "stdio":
 		log.Debug().Str("transport", "stdio").Msg("Selected transport")
-		return &stdioBackend{server: s}, nil
+		return &stdioBackend{server: s, cfg: cfg}, nil
 	case "sse":
 		log.Debug().Str("transport", "sse").Int("port", cfg.defaultPort).Msg("Selected transport")
 		return &sseBackend{server: s, port: cfg.defaultPort, cfg: cfg}, nil
@@ -67,7 +68,8 @@ func NewBackend(cfg *ServerConfig) (Backend, error) {
 
 // MountHTTPHandlers mounts HTTP-based MCP routes into an existing mux.
 // It is intended for applications that already own an http.Server and want to
-// expose MCP on the same listener.
+// expose MCP on the same listener. Changes to the tool registry are picked up
+// for the lifetime of the process.
 func MountHTTPHandlers(mux *http.ServeMux, cfg *ServerConfig) error {
 	if mux == nil {
 		return fmt.Errorf("nil mux")
@@ -82,13 +84,15 @@ func MountHTTPHandlers(mux *http.ServeMux, cfg *ServerConfig) error {
 		Str("transport", cfg.defaultTransport).
 		Msg("Mounting MCP HTTP handlers")
 
-	s := mcpserver.NewMCPServer(cfg.Name, cfg.Version,
-		mcpserver.WithToolCapabilities(true),
-		mcpserver.WithLogging(),
-	)
-	if err := registerToolsFromRegistry(context.Background(), s, cfg.toolRegistry, cfg); err != nil {
+	s, err := newMCPServer(context.Background(), cfg)
+	if err != nil {
 		return err
 	}
+	go s.tools.watch(context.Background())
+	go s.prompts.watch(context.Background())
+	go s.resources.watch(context.Background())
+	go s.gateways.watch(context.Background())
+	serveApprovals(context.Background(), cfg)
 
 	switch cfg.defaultTransport {
 	case "sse":
@@ -102,73 +106,299 @@ func MountHTTPHandlers(mux *http.ServeMux, cfg *ServerConfig) error {
 	}
 }
 
-func registerToolsFromRegistry(ctx context.Context, s *mcpserver.MCPServer, reg *tool_registry.Registry, cfg *ServerConfig) error {
-	if reg == nil {
+// mcpServer bundles the mcp-go server with the helpers that keep it in line
+// with the registries and providers it was built from.
+type mcpServer struct {
+	*mcpserver.MCPServer
+	// tools mirrors the tool registry once its watch loop is started
+	tools *toolSync
+	// prompts mirrors the prompt providers once its watch loop is started
+	prompts *promptSync
+	// resources mirrors the resource providers once its watch loop is started
+	resources *resourceSync
+	// gateways mirrors upstream tools into the tool registry once its watch
+	// loop is started
+	gateways *gatewaySync
+	// subscriptions is nil when no resource provider is configured
+	subscriptions *resourceSubscriptions
+	// calls tracks running tool calls for cancellation
+	calls *inflightCalls
+	// elicitation is only used by the stdio transport
+	elicitation *stdioElicitation
+	// sessions binds client sessions to the session store
+	sessions *transportSessions
+}
+
+// newMCPServer builds the mcp-go server for cfg and registers tools, prompts
+// and resources from the configured registries and providers.
+func newMCPServer(ctx context.Context, cfg *ServerConfig) (*mcpServer, error) {
+	opts := []mcpserver.ServerOption{
+		mcpserver.WithToolCapabilities(true),
+		mcpserver.WithLogging(),
+	}
+	if len(cfg.promptProviders) > 0 {
+		opts = append(opts, mcpserver.WithPromptCapabilities(true))
+	}
+	if len(cfg.toolPolicies) > 0 {
+		opts = append(opts, mcpserver.WithToolFilter(cfg.filterTools))
+	}
+
+	ret := &mcpServer{
+		calls:       newInflightCalls(),
+		elicitation: newStdioElicitation(os.Stdout),
+		sessions:    newTransportSessions(cfg.sessionStore),
+	}
+	hooks := &mcpserver.Hooks{}
+	if len(cfg.resourceProviders) > 0 {
+		// subscriptions needs the server to send notifications, and the server
+		// needs its hooks, so the server field is filled in once it exists.
+		ret.subscriptions = newResourceSubscriptions(nil, cfg.resourceProviders)
+		hooks = ret.subscriptions.hooks()
+		opts = append(opts, mcpserver.WithResourceCapabilities(true, true))
+	}
+	hooks.AddBeforeCallTool(ret.calls.beforeCallTool)
+	hooks.AddOnUnregisterSession(ret.sessions.unregister)
+	opts = append(opts, mcpserver.WithHooks(hooks))
+
+	s := mcpserver.NewMCPServer(cfg.Name, cfg.Version, opts...)
+	ret.MCPServer = s
+	if ret.subscriptions != nil {
+		ret.subscriptions.server = s
+	}
+	s.AddNotificationHandler("notifications/cancelled", ret.calls.handleCancelled)
+
+	ret.gateways = newGatewaySync(cfg.toolRegistry, cfg.gateways)
+	if err := ret.gateways.sync(ctx); err != nil {
+		return nil, err
+	}
+
+	// Register tools from our registry into mcp-go server
+	ret.tools = newToolSync(s, cfg.toolRegistry, cfg, ret.calls, ret.elicitation)
+	if err := ret.tools.sync(ctx); err != nil {
+		return nil, err
+	}
+	ret.prompts = newPromptSync(s, cfg.promptProviders)
+	if err := ret.prompts.sync(ctx); err != nil {
+		return nil, err
+	}
+	ret.resources = newResourceSync(s, cfg.resourceProviders)
+	if err := ret.resources.sync(ctx); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// toolSync mirrors a tool registry into an mcp-go server. Tools that appear,
+// change or disappear in the registry are added to or deleted from the
+// server, which in turn emits notifications/tools/list_changed to connected
+// sessions.
+type toolSync struct {
+	server *mcpserver.MCPServer
+	reg    *tool_registry.Registry
+	cfg    *ServerConfig
+	calls  *inflightCalls
+	// elicitation asks the user to approve tool calls
+	elicitation *stdioElicitation
+
+	mu         sync.Mutex
+	registered map[string]protocol.Tool
+}
+
+func newToolSync(
+	s *mcpserver.MCPServer,
+	reg *tool_registry.Registry,
+	cfg *ServerConfig,
+	calls *inflightCalls,
+	elicitation *stdioElicitation,
+) *toolSync {
+	return &toolSync{
+		server:      s,
+		reg:         reg,
+		cfg:         cfg,
+		calls:       calls,
+		elicitation: elicitation,
+		registered:  map[string]protocol.Tool{},
+	}
+}
+
+// sync diffs the registry against the tools currently registered on the
+// mcp-go server and applies the difference.
+func (t *toolSync) sync(ctx context.Context) error {
+	if t.reg == nil {
 		log.Debug().Msg("No tool registry set; skipping registration")
 		return nil
 	}
 
-	tools, _, err := reg.ListTools(ctx, "")
+	tools, _, err := t.reg.ListTools(ctx, "")
 	if err != nil {
 		return fmt.Errorf("list tools: %w", err)
 	}
 
-	log.Debug().Int("count", len(tools)).Msg("Registering tools")
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	for _, t := range tools {
-		// Map our protocol.Tool to mcp-go Tool with raw schema
-		mt := mcp.NewToolWithRawSchema(t.Name, t.Description, t.InputSchema)
+	current := make(map[string]protocol.Tool, len(tools))
+	var toAdd []mcpserver.ServerTool
+	for _, tool := range tools {
+		current[tool.Name] = tool
+		if prev, ok := t.registered[tool.Name]; ok && toolDefinitionEqual(prev, tool) {
+			continue
+		}
 
-		name := t.Name
 		log.Debug().
-			Str("tool", name).
-			Str("description_preview", previewDescription(t.Description, toolDescriptionPreviewEdge)).
+			Str("tool", tool.Name).
+			Str("description_preview", previewDescription(tool.Description, toolDescriptionPreviewEdge)).
 			Msg("Adding tool to mcp-go server")
 
-		// Build a wrapped handler that applies middleware and hooks around registry.CallTool
-		baseHandler := func(callCtx context.Context, args map[string]interface{}) (*protocol.ToolResult, error) {
-			return reg.CallTool(callCtx, name, args)
+		// Map our protocol.Tool to mcp-go Tool with raw schemas
+		mcpTool := mcp.NewToolWithRawSchema(tool.Name, tool.Description, tool.InputSchema)
+		mcpTool.RawOutputSchema = tool.OutputSchema
+		if a := tool.Annotations; a != nil {
+			mcpTool.Annotations = mcp.ToolAnnotation{
+				Title:           a.Title,
+				ReadOnlyHint:    a.ReadOnlyHint,
+				DestructiveHint: a.DestructiveHint,
+				IdempotentHint:  a.IdempotentHint,
+				OpenWorldHint:   a.OpenWorldHint,
+			}
 		}
+		toAdd = append(toAdd, mcpserver.ServerTool{
+			Tool:    mcpTool,
+			Handler: newToolHandler(t.reg, t.cfg, t.calls, t.elicitation, tool.Name),
+		})
+	}
 
-		// Apply middleware stack (reverse order)
-		wrapped := baseHandler
-		if len(cfg.middleware) > 0 {
-			log.Debug().Str("tool", name).Int("middleware_count", len(cfg.middleware)).Msg("Applying middleware chain")
-			for i := len(cfg.middleware) - 1; i >= 0; i-- {
-				wrapped = cfg.middleware[i](wrapped)
-			}
+	var toDelete []string
+	for name := range t.registered {
+		if _, ok := current[name]; !ok {
+			log.Debug().Str("tool", name).Msg("Removing tool from mcp-go server")
+			toDelete = append(toDelete, name)
 		}
+	}
 
-		// Adapter for mcp-go handler signature
-		s.AddTool(mt, func(callCtx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			args := req.GetArguments()
+	log.Debug().
+		Int("count", len(tools)).
+		Int("added", len(toAdd)).
+		Int("removed", len(toDelete)).
+		Msg("Registering tools")
 
-			if cfg.hooks != nil && cfg.hooks.BeforeToolCall != nil {
-				if err := cfg.hooks.BeforeToolCall(callCtx, name, args); err != nil {
-					return nil, err
-				}
-			}
+	if len(toAdd) > 0 {
+		t.server.AddTools(toAdd...)
+	}
+	if len(toDelete) > 0 {
+		t.server.DeleteTools(toDelete...)
+	}
+	t.registered = current
 
-			log.Debug().Str("tool", name).Interface("args", args).Msg("Handling tool call")
+	return nil
+}
 
-			res, err := wrapped(callCtx, args)
+// watch re-syncs the server whenever the registry changes, until ctx is done.
+func (t *toolSync) watch(ctx context.Context) {
+	if t.reg == nil {
+		return
+	}
 
-			mcpRes := mapToolResultToMCP(res)
+	ch, cleanup := t.reg.SubscribeToChanges()
+	defer cleanup()
 
-			if cfg.hooks != nil && cfg.hooks.AfterToolCall != nil {
-				cfg.hooks.AfterToolCall(callCtx, name, res, err)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if err := t.sync(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to sync tools into mcp-go server")
 			}
+		}
+	}
+}
 
-			if err != nil {
-				log.Error().Str("tool", name).Err(err).Msg("Tool call errored")
-			} else {
-				log.Debug().Str("tool", name).Bool("is_error", mcpRes.IsError).Msg("Tool call completed")
-			}
-			return mcpRes, err
-		})
+func toolDefinitionEqual(a, b protocol.Tool) bool {
+	return a.Name == b.Name &&
+		a.Description == b.Description &&
+		bytes.Equal(a.InputSchema, b.InputSchema) &&
+		bytes.Equal(a.OutputSchema, b.OutputSchema) &&
+		reflect.DeepEqual(a.Annotations, b.Annotations)
+}
+
+// newToolHandler builds an mcp-go handler that applies middleware and hooks
+// around reg.CallTool. The tool is looked up by name on every call, so the
+// handler stays valid when the tool's definition is replaced. The handler's
+// context is cancelled by notifications/cancelled, carries a log reporter,
+// and a progress reporter when the client sent a progress token. Calls that
+// need approval wait for it before the hooks run.
+func newToolHandler(
+	reg *tool_registry.Registry,
+	cfg *ServerConfig,
+	calls *inflightCalls,
+	elicitation *stdioElicitation,
+	name string,
+) mcpserver.ToolHandlerFunc {
+	baseHandler := func(callCtx context.Context, args map[string]interface{}) (*protocol.ToolResult, error) {
+		return reg.CallTool(callCtx, name, args)
 	}
 
-	return nil
+	// Apply middleware stack (reverse order)
+	wrapped := baseHandler
+	if len(cfg.middleware) > 0 {
+		log.Debug().Str("tool", name).Int("middleware_count", len(cfg.middleware)).Msg("Applying middleware chain")
+		for i := len(cfg.middleware) - 1; i >= 0; i-- {
+			wrapped = cfg.middleware[i](wrapped)
+		}
+	}
+
+	// Adapter for mcp-go handler signature
+	return func(callCtx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetArguments()
+		start := time.Now()
+
+		callCtx, done := calls.start(callCtx, req)
+		defer done()
+		callCtx = withToolName(callCtx, name)
+		callCtx = tools.WithLogReporter(callCtx, newLogReporter(callCtx, name))
+		if req.Params.Meta != nil && req.Params.Meta.ProgressToken != nil {
+			callCtx = tools.WithProgressReporter(callCtx, newProgressReporter(callCtx, req.Params.Meta.ProgressToken))
+		}
+
+		if err := cfg.authorizeTool(callCtx, name); err != nil {
+			log.Warn().Str("tool", name).Err(err).Msg("Rejected tool call")
+			cfg.recordToolCall(callCtx, name, args, start, nil, err, AuditStatusDenied)
+			return nil, err
+		}
+
+		if decision, required := cfg.approveToolCall(callCtx, name, args, elicitation); required && !decision.Approved {
+			log.Warn().Str("tool", name).Str("decision", decision.String()).Msg("Tool call not approved")
+			res := protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("Tool call not approved (%s)", decision)))
+			return mapToolResultToMCP(res), nil
+		}
+
+		if cfg.hooks != nil && cfg.hooks.BeforeToolCall != nil {
+			if err := cfg.hooks.BeforeToolCall(callCtx, name, args); err != nil {
+				cfg.recordToolCall(callCtx, name, args, start, nil, err, "")
+				return nil, err
+			}
+		}
+
+		log.Debug().Str("tool", name).Interface("args", args).Msg("Handling tool call")
+
+		res, err := wrapped(callCtx, args)
+		cfg.recordToolCall(callCtx, name, args, start, res, err, "")
+
+		mcpRes := mapToolResultToMCP(res)
+
+		if cfg.hooks != nil && cfg.hooks.AfterToolCall != nil {
+			cfg.hooks.AfterToolCall(callCtx, name, res, err)
+		}
+
+		if err != nil {
+			log.Error().Str("tool", name).Err(err).Msg("Tool call errored")
+		} else {
+			log.Debug().Str("tool", name).Bool("is_error", mcpRes.IsError).Msg("Tool call completed")
+		}
+		return mcpRes, err
+	}
 }
 
 func mapToolResultToMCP(res *protocol.ToolResult) *mcp.CallToolResult {
@@ -177,7 +407,8 @@ func mapToolResultToMCP(res *protocol.ToolResult) *mcp.CallToolResult {
 	}
 
 	out := &mcp.CallToolResult{
-		IsError: res.IsError,
+		IsError:           res.IsError,
+		StructuredContent: res.StructuredContent,
 	}
 
 	for _, c := range res.Content {
@@ -188,13 +419,7 @@ func mapToolResultToMCP(res *protocol.ToolResult) *mcp.CallToolResult {
 			out.Content = append(out.Content, mcp.ImageContent{Type: "image", Data: c.Data, MIMEType: c.MimeType})
 		case "resource":
 			if c.Resource != nil {
-				var rc mcp.ResourceContents
-				if c.Resource.Blob != "" {
-					rc = mcp.BlobResourceContents{URI: c.Resource.URI, MIMEType: c.Resource.MimeType, Blob: c.Resource.Blob}
-				} else {
-					rc = mcp.TextResourceContents{URI: c.Resource.URI, MIMEType: c.Resource.MimeType, Text: c.Resource.Text}
-				}
-				embedded := mcp.NewEmbeddedResource(rc)
+				embedded := mcp.NewEmbeddedResource(mapResourceContentToMCP(*c.Resource))
 				out.Content = append(out.Content, embedded)
 			}
 		}
@@ -222,23 +447,46 @@ func previewDescription(desc string, edge int) string {
 // stdio backend
 
 type stdioBackend struct {
-	server *mcpserver.MCPServer
+	server *mcpServer
+	cfg    *ServerConfig
 }
 
 func (b *stdioBackend) Start(ctx context.Context) error {
-	// Use ServeStdio convenience which binds to os.Stdin/os.Stdout
-	return mcpserver.ServeStdio(b.server)
+	go b.server.tools.watch(ctx)
+	go b.server.prompts.watch(ctx)
+	go b.server.resources.watch(ctx)
+	go b.server.gateways.watch(ctx)
+	serveApprovals(ctx, b.cfg)
+	if b.cfg.approval != nil && b.cfg.approval.ListenAddr == "" {
+		b.cfg.stdioApprovals = &onDemandApprovals{ctx: ctx, cfg: b.cfg, addr: DefaultStdioApprovalAddr}
+	}
+
+	// Mirror ServeStdio, but route stdin through the elicitation and
+	// subscription interceptors, and stdout through the elicitation writer
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	stdio := mcpserver.NewStdioServer(b.server.MCPServer)
+	stdio.SetContextFunc(b.server.sessions.withSession)
+	in := b.server.subscriptions.stdioReader(ctx, b.server.elicitation.stdioReader(os.Stdin), b.server.elicitation)
+	return stdio.Listen(ctx, in, b.server.elicitation)
 }
 
 // sse backend
 
 type sseBackend struct {
-	server *mcpserver.MCPServer
+	server *mcpServer
 	port   int
 	cfg    *ServerConfig
 }
 
 func (b *sseBackend) Start(ctx context.Context) error {
+	go b.server.tools.watch(ctx)
+	go b.server.prompts.watch(ctx)
+	go b.server.resources.watch(ctx)
+	go b.server.gateways.watch(ctx)
+	serveApprovals(ctx, b.cfg)
+
 	addr := fmt.Sprintf(":%d", b.port)
 	mux := http.NewServeMux()
 	if err := mountSSEHandlers(mux, b.server, b.cfg); err != nil {
@@ -264,12 +512,18 @@ func (b *sseBackend) Start(ctx context.Context) error {
 // streamable-http backend
 
 type streamBackend struct {
-	server *mcpserver.MCPServer
+	server *mcpServer
 	port   int
 	cfg    *ServerConfig
 }
 
 func (b *streamBackend) Start(ctx context.Context) error {
+	go b.server.tools.watch(ctx)
+	go b.server.prompts.watch(ctx)
+	go b.server.resources.watch(ctx)
+	go b.server.gateways.watch(ctx)
+	serveApprovals(ctx, b.cfg)
+
 	addr := fmt.Sprintf(":%d", b.port)
 	mux := http.NewServeMux()
 	if err := mountStreamableHTTPHandlers(mux, b.server, b.cfg); err != nil {
@@ -292,10 +546,13 @@ func (b *streamBackend) Start(ctx context.Context) error {
 	return nil
 }
 
-func mountSSEHandlers(mux *http.ServeMux, server *mcpserver.MCPServer, cfg *ServerConfig) error {
-	sse := mcpserver.NewSSEServer(server, mcpserver.WithStaticBasePath("/mcp"))
+func mountSSEHandlers(mux *http.ServeMux, server *mcpServer, cfg *ServerConfig) error {
+	sse := mcpserver.NewSSEServer(server.MCPServer,
+		mcpserver.WithStaticBasePath("/mcp"),
+		mcpserver.WithSSEContextFunc(server.sessions.httpContextFunc),
+	)
 
-	var handler http.Handler = sse
+	var handler http.Handler = server.subscriptions.httpMiddleware(sseSessionID, sseReply(sse), sse)
 	if cfg != nil && cfg.authEnabled {
 		provider, err := newHTTPAuthProvider(cfg)
 		if err != nil {
@@ -307,13 +564,21 @@ func mountSSEHandlers(mux *http.ServeMux, server *mcpserver.MCPServer, cfg *Serv
 	}
 
 	mux.Handle("/mcp/", withRequestLogging(handler))
+	mountApprovalHandlers(mux, cfg)
+	if cfg != nil && cfg.quotaLimiter != nil {
+		mux.Handle("/debug/quotas", cfg.quotaLimiter.Handler())
+	}
 	return nil
 }
 
-func mountStreamableHTTPHandlers(mux *http.ServeMux, server *mcpserver.MCPServer, cfg *ServerConfig) error {
-	stream := mcpserver.NewStreamableHTTPServer(server)
+func mountStreamableHTTPHandlers(mux *http.ServeMux, server *mcpServer, cfg *ServerConfig) error {
+	server.sessions.endOnDelete = true
+	stream := mcpserver.NewStreamableHTTPServer(server.MCPServer,
+		mcpserver.WithHTTPContextFunc(server.sessions.httpContextFunc),
+		mcpserver.WithSessionIdManager(server.sessions.sessionIDManager()),
+	)
 
-	var handler http.Handler = stream
+	var handler http.Handler = server.subscriptions.httpMiddleware(streamableSessionID, jsonReply, stream)
 	if cfg != nil && cfg.authEnabled {
 		provider, err := newHTTPAuthProvider(cfg)
 		if err != nil {
@@ -326,6 +591,10 @@ func mountStreamableHTTPHandlers(mux *http.ServeMux, server *mcpserver.MCPServer
 
 	mux.Handle("/mcp", withRequestLogging(handler))
 	mux.Handle("/mcp/", withRequestLogging(handler))
+	mountApprovalHandlers(mux, cfg)
+	if cfg != nil && cfg.quotaLimiter != nil {
+		mux.Handle("/debug/quotas", cfg.quotaLimiter.Handler())
+	}
 	return nil
 }
 
@@ -0,0 +1,168 @@
+package embeddable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+)
+
+const methodElicitationCreate = "elicitation/create"
+
+// stdioElicitation sends elicitation/create requests to the stdio client.
+//
+// mcp-go only knows how to send sampling requests to clients, so requests are
+// written to stdout directly, sharing a lock with mcp-go's own writes, and
+// the responses are picked out of stdin before mcp-go sees them. Whether the
+// client supports elicitation is read from its initialize request, which
+// only happens on the stdio transport.
+type stdioElicitation struct {
+	writeMu sync.Mutex
+	out     io.Writer
+
+	mu          sync.Mutex
+	elicitation bool
+	nextID      int64
+	pending     map[string]chan elicitationResponse
+}
+
+type elicitationResponse struct {
+	result *protocol.ElicitationResult
+	err    error
+}
+
+func newStdioElicitation(out io.Writer) *stdioElicitation {
+	return &stdioElicitation{
+		out:     out,
+		pending: map[string]chan elicitationResponse{},
+	}
+}
+
+// Write serializes mcp-go's writes with the elicitation requests
+func (e *stdioElicitation) Write(p []byte) (int, error) {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+	return e.out.Write(p)
+}
+
+// supported returns whether the client announced the elicitation capability
+func (e *stdioElicitation) supported() bool {
+	if e == nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.elicitation
+}
+
+// elicit sends request to the client and waits for its answer
+func (e *stdioElicitation) elicit(ctx context.Context, request *protocol.ElicitationRequest) (*protocol.ElicitationResult, error) {
+	ch := make(chan elicitationResponse, 1)
+	e.mu.Lock()
+	e.nextID++
+	id := fmt.Sprintf("elicitation-%d", e.nextID)
+	e.pending[id] = ch
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		delete(e.pending, id)
+		e.mu.Unlock()
+	}()
+
+	msg, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      id,
+		"method":  methodElicitationCreate,
+		"params":  request,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal elicitation request: %w", err)
+	}
+	if _, err := e.Write(append(msg, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write elicitation request: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case response := <-ch:
+		return response.result, response.err
+	}
+}
+
+// intercept records the client's capabilities from its initialize request
+// and consumes the responses to elicitation requests. It returns true if the
+// message must not be passed on to mcp-go.
+func (e *stdioElicitation) intercept(raw []byte) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			Capabilities struct {
+				Elicitation json.RawMessage `json:"elicitation"`
+			} `json:"capabilities"`
+		} `json:"params"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return false
+	}
+
+	if msg.Method == string(mcp.MethodInitialize) {
+		elicitation := msg.Params.Capabilities.Elicitation
+		e.mu.Lock()
+		e.elicitation = len(elicitation) > 0 && string(elicitation) != "null"
+		e.mu.Unlock()
+		return false
+	}
+	if msg.Method != "" || msg.ID == nil {
+		return false
+	}
+
+	var id string
+	if err := json.Unmarshal(msg.ID, &id); err != nil {
+		return false
+	}
+	e.mu.Lock()
+	ch, ok := e.pending[id]
+	e.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	var response elicitationResponse
+	if msg.Error != nil {
+		response.err = fmt.Errorf("elicitation request failed: %s", msg.Error.Message)
+	} else {
+		var result protocol.ElicitationResult
+		if err := json.Unmarshal(msg.Result, &result); err != nil {
+			response.err = fmt.Errorf("failed to unmarshal elicitation result: %w", err)
+		} else {
+			response.result = &result
+		}
+	}
+	select {
+	case ch <- response:
+	default:
+	}
+	return true
+}
+
+// stdioReader wraps the stdio input stream so that elicitation responses are
+// consumed before mcp-go sees them.
+func (e *stdioElicitation) stdioReader(in io.Reader) io.Reader {
+	return filterLines(in, func(line []byte) []byte {
+		if e.intercept(line) {
+			return nil
+		}
+		return line
+	})
+}
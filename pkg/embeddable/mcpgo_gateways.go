@@ -0,0 +1,61 @@
+package embeddable
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/gateway"
+	tool_registry "github.com/go-go-golems/go-go-mcp/pkg/tools/providers/tool-registry"
+	"github.com/rs/zerolog/log"
+)
+
+// gatewaySync mirrors the tools of gateways into the tool registry, from
+// which toolSync picks them up. Their prompts and resources are served by the
+// prompt and resource providers.
+type gatewaySync struct {
+	reg      *tool_registry.Registry
+	gateways []*gateway.Gateway
+}
+
+func newGatewaySync(reg *tool_registry.Registry, gateways []*gateway.Gateway) *gatewaySync {
+	return &gatewaySync{reg: reg, gateways: gateways}
+}
+
+// sync registers the current tools of every gateway.
+func (g *gatewaySync) sync(ctx context.Context) error {
+	for _, gw := range g.gateways {
+		if err := g.reg.SyncFromProvider(ctx, gw); err != nil {
+			return fmt.Errorf("failed to sync upstream tools: %w", err)
+		}
+	}
+	return nil
+}
+
+// watch re-syncs the tools of a gateway whenever one of its upstreams
+// reports changes, until ctx is done or the gateway is closed.
+func (g *gatewaySync) watch(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, gw := range g.gateways {
+		ch, cleanup := gw.SubscribeToChanges()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cleanup()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case _, ok := <-ch:
+					if !ok {
+						return
+					}
+					if err := g.reg.SyncFromProvider(ctx, gw); err != nil {
+						log.Error().Err(err).Msg("Failed to sync upstream tools")
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
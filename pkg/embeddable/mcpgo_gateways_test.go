@@ -0,0 +1,40 @@
+package embeddable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/gateway"
+)
+
+func TestGatewaySyncStopsWithContext(t *testing.T) {
+	gw := gateway.NewGateway()
+	cfg := NewServerConfig()
+	if err := WithGateway(gw)(cfg); err != nil {
+		t.Fatalf("WithGateway: %v", err)
+	}
+	if len(cfg.gateways) != 1 {
+		t.Fatalf("expected the gateway to be kept for server start")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := newMCPServer(ctx, cfg)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.gateways.watch(ctx)
+		close(done)
+	}()
+	gw.NotifyChanged()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("gateway watch did not stop with its context")
+	}
+}
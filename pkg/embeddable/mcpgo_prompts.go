@@ -0,0 +1,226 @@
+package embeddable
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// maxProviderPages bounds pagination loops against providers that keep
+// returning cursors.
+const maxProviderPages = 1000
+
+// promptSync mirrors the prompts of the prompt providers into an mcp-go
+// server. Prompts that appear, change or disappear are added to or deleted
+// from the server, which in turn emits notifications/prompts/list_changed to
+// connected sessions. When several providers offer a prompt with the same
+// name, the first one wins.
+type promptSync struct {
+	server    *mcpserver.MCPServer
+	providers []pkg.PromptProvider
+
+	mu         sync.Mutex
+	registered map[string]protocol.Prompt
+}
+
+// promptChangeNotifier is implemented by prompt providers whose prompts
+// change while the server runs, such as providers watching prompt files.
+type promptChangeNotifier interface {
+	SubscribeToChanges() (chan struct{}, func())
+}
+
+func newPromptSync(s *mcpserver.MCPServer, providers []pkg.PromptProvider) *promptSync {
+	return &promptSync{
+		server:     s,
+		providers:  providers,
+		registered: map[string]protocol.Prompt{},
+	}
+}
+
+// sync diffs the prompts of the providers against the prompts currently
+// registered on the mcp-go server and applies the difference.
+func (p *promptSync) sync(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := map[string]protocol.Prompt{}
+	var toAdd []mcpserver.ServerPrompt
+	for _, provider := range p.providers {
+		prompts, err := listAllPrompts(ctx, provider)
+		if err != nil {
+			return fmt.Errorf("list prompts: %w", err)
+		}
+
+		for _, prompt := range prompts {
+			if _, ok := current[prompt.Name]; ok {
+				log.Warn().Str("prompt", prompt.Name).Msg("Prompt already registered by another provider, skipping")
+				continue
+			}
+			current[prompt.Name] = prompt
+			if prev, ok := p.registered[prompt.Name]; ok && reflect.DeepEqual(prev, prompt) {
+				continue
+			}
+
+			log.Debug().
+				Str("prompt", prompt.Name).
+				Str("description_preview", previewDescription(prompt.Description, toolDescriptionPreviewEdge)).
+				Msg("Adding prompt to mcp-go server")
+
+			toAdd = append(toAdd, mcpserver.ServerPrompt{
+				Prompt:  mapPromptToMCP(prompt),
+				Handler: newPromptHandler(provider, prompt),
+			})
+		}
+	}
+
+	var toDelete []string
+	for name := range p.registered {
+		if _, ok := current[name]; !ok {
+			log.Debug().Str("prompt", name).Msg("Removing prompt from mcp-go server")
+			toDelete = append(toDelete, name)
+		}
+	}
+
+	log.Debug().
+		Int("count", len(current)).
+		Int("added", len(toAdd)).
+		Int("removed", len(toDelete)).
+		Msg("Registering prompts")
+
+	if len(toAdd) > 0 {
+		p.server.AddPrompts(toAdd...)
+	}
+	if len(toDelete) > 0 {
+		p.server.DeletePrompts(toDelete...)
+	}
+	p.registered = current
+
+	return nil
+}
+
+// watch re-syncs the server whenever one of the providers reports changed
+// prompts, until ctx is done.
+func (p *promptSync) watch(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, provider := range p.providers {
+		notifier, ok := provider.(promptChangeNotifier)
+		if !ok {
+			continue
+		}
+
+		ch, cleanup := notifier.SubscribeToChanges()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cleanup()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ch:
+					if err := p.sync(ctx); err != nil {
+						log.Error().Err(err).Msg("Failed to sync prompts into mcp-go server")
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func listAllPrompts(ctx context.Context, provider pkg.PromptProvider) ([]protocol.Prompt, error) {
+	var ret []protocol.Prompt
+	cursor := ""
+	for i := 0; i < maxProviderPages; i++ {
+		prompts, next, err := provider.ListPrompts(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, prompts...)
+		if next == "" || next == cursor {
+			break
+		}
+		cursor = next
+	}
+	return ret, nil
+}
+
+func newPromptHandler(provider pkg.PromptProvider, prompt protocol.Prompt) mcpserver.PromptHandlerFunc {
+	name := prompt.Name
+	return func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := req.Params.Arguments
+		if args == nil {
+			args = map[string]string{}
+		}
+
+		log.Debug().Str("prompt", name).Interface("args", args).Msg("Handling prompt request")
+
+		result, err := provider.GetPrompt(ctx, name, args)
+		if err != nil {
+			log.Error().Str("prompt", name).Err(err).Msg("Prompt request errored")
+			return nil, err
+		}
+
+		res := &mcp.GetPromptResult{
+			Description: prompt.Description,
+			Messages:    []mcp.PromptMessage{},
+		}
+		if result == nil {
+			return res, nil
+		}
+		if result.Description != "" {
+			res.Description = result.Description
+		}
+		for _, msg := range result.Messages {
+			res.Messages = append(res.Messages, mapPromptMessageToMCP(msg))
+		}
+		return res, nil
+	}
+}
+
+func mapPromptToMCP(p protocol.Prompt) mcp.Prompt {
+	out := mcp.Prompt{
+		Name:        p.Name,
+		Description: p.Description,
+	}
+	for _, arg := range p.Arguments {
+		out.Arguments = append(out.Arguments, mcp.PromptArgument{
+			Name:        arg.Name,
+			Description: arg.Description,
+			Required:    arg.Required,
+		})
+	}
+	return out
+}
+
+func mapPromptMessageToMCP(m protocol.PromptMessage) mcp.PromptMessage {
+	role := mcp.RoleUser
+	if m.Role == string(mcp.RoleAssistant) {
+		role = mcp.RoleAssistant
+	}
+	return mcp.PromptMessage{
+		Role:    role,
+		Content: mapPromptContentToMCP(m.Content),
+	}
+}
+
+func mapPromptContentToMCP(c protocol.PromptContent) mcp.Content {
+	switch c.Type {
+	case "image":
+		return mcp.NewImageContent(c.Data, c.MimeType)
+	case "resource":
+		if c.Resource != nil {
+			return mcp.NewEmbeddedResource(mapResourceContentToMCP(*c.Resource))
+		}
+		return mcp.NewTextContent("")
+	default:
+		return mcp.NewTextContent(c.Text)
+	}
+}
@@ -0,0 +1,70 @@
+package embeddable
+
+import (
+	"testing"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMapPromptToMCP(t *testing.T) {
+	got := mapPromptToMCP(protocol.Prompt{
+		Name:        "summarize",
+		Description: "Summarize a document",
+		Arguments: []protocol.PromptArgument{
+			{Name: "text", Description: "The document", Required: true},
+			{Name: "style"},
+		},
+	})
+	if got.Name != "summarize" || got.Description != "Summarize a document" {
+		t.Fatalf("unexpected prompt %+v", got)
+	}
+	if len(got.Arguments) != 2 {
+		t.Fatalf("expected 2 arguments, got %d", len(got.Arguments))
+	}
+	if arg := got.Arguments[0]; arg.Name != "text" || arg.Description != "The document" || !arg.Required {
+		t.Fatalf("unexpected first argument %+v", arg)
+	}
+	if arg := got.Arguments[1]; arg.Name != "style" || arg.Required {
+		t.Fatalf("unexpected second argument %+v", arg)
+	}
+}
+
+func TestMapPromptMessageToMCP(t *testing.T) {
+	if got := mapPromptMessageToMCP(protocol.PromptMessage{Role: "assistant"}); got.Role != mcp.RoleAssistant {
+		t.Fatalf("expected the assistant role, got %q", got.Role)
+	}
+	// Unknown roles fall back to user
+	if got := mapPromptMessageToMCP(protocol.PromptMessage{Role: "system"}); got.Role != mcp.RoleUser {
+		t.Fatalf("expected the user role, got %q", got.Role)
+	}
+}
+
+func TestMapPromptContentToMCP(t *testing.T) {
+	text, ok := mapPromptContentToMCP(protocol.PromptContent{Type: "text", Text: "hello"}).(mcp.TextContent)
+	if !ok || text.Text != "hello" {
+		t.Fatalf("unexpected text content %+v", text)
+	}
+
+	image, ok := mapPromptContentToMCP(protocol.PromptContent{Type: "image", Data: "aGVsbG8=", MimeType: "image/png"}).(mcp.ImageContent)
+	if !ok || image.Data != "aGVsbG8=" || image.MIMEType != "image/png" {
+		t.Fatalf("unexpected image content %+v", image)
+	}
+
+	embedded, ok := mapPromptContentToMCP(protocol.PromptContent{
+		Type:     "resource",
+		Resource: &protocol.ResourceContent{URI: "file:///a.txt", MimeType: "text/plain", Text: "a"},
+	}).(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("expected embedded resource content")
+	}
+	resource, ok := embedded.Resource.(mcp.TextResourceContents)
+	if !ok || resource.URI != "file:///a.txt" || resource.Text != "a" {
+		t.Fatalf("unexpected embedded resource %+v", embedded.Resource)
+	}
+
+	// A resource content without a resource degrades to empty text
+	if empty, ok := mapPromptContentToMCP(protocol.PromptContent{Type: "resource"}).(mcp.TextContent); !ok || empty.Text != "" {
+		t.Fatalf("unexpected content for a missing resource %+v", empty)
+	}
+}
@@ -0,0 +1,251 @@
+package embeddable
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// resourceSync mirrors the resources and resource templates of the resource
+// providers into an mcp-go server. Resources that appear, change or
+// disappear are added to or deleted from the server, which in turn emits
+// notifications/resources/list_changed to connected sessions. When several
+// providers offer a resource with the same URI, or a template with the same
+// URI template, the first one wins.
+type resourceSync struct {
+	server    *mcpserver.MCPServer
+	providers []pkg.ResourceProvider
+
+	mu         sync.Mutex
+	registered map[string]registeredResource
+	templates  map[string]registeredTemplate
+}
+
+type registeredResource struct {
+	resource protocol.Resource
+	provider pkg.ResourceProvider
+}
+
+type registeredTemplate struct {
+	template protocol.ResourceTemplate
+	provider pkg.ResourceProvider
+}
+
+// resourceChangeNotifier is implemented by resource providers whose list of
+// resources changes while the server runs, such as resources.Registry.
+type resourceChangeNotifier interface {
+	SubscribeToChanges() (chan struct{}, func())
+}
+
+func newResourceSync(s *mcpserver.MCPServer, providers []pkg.ResourceProvider) *resourceSync {
+	return &resourceSync{
+		server:     s,
+		providers:  providers,
+		registered: map[string]registeredResource{},
+		templates:  map[string]registeredTemplate{},
+	}
+}
+
+// sync diffs the resources and templates of the providers against the ones
+// currently registered on the mcp-go server and applies the difference.
+// mcp-go cannot delete single templates, so they are all replaced when any
+// of them changed.
+func (r *resourceSync) sync(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := map[string]registeredResource{}
+	currentTemplates := map[string]registeredTemplate{}
+	var toAdd []mcpserver.ServerResource
+	var templates []mcpserver.ServerResourceTemplate
+	templatesChanged := false
+	for _, provider := range r.providers {
+		resources, err := listAllResources(ctx, provider)
+		if err != nil {
+			return fmt.Errorf("list resources: %w", err)
+		}
+
+		for _, res := range resources {
+			if _, ok := current[res.URI]; ok {
+				log.Warn().Str("uri", res.URI).Msg("Resource already registered by another provider, skipping")
+				continue
+			}
+			entry := registeredResource{resource: res, provider: provider}
+			current[res.URI] = entry
+			if prev, ok := r.registered[res.URI]; ok && prev.provider == provider && reflect.DeepEqual(prev.resource, res) {
+				continue
+			}
+
+			log.Debug().Str("uri", res.URI).Str("name", res.Name).Msg("Adding resource to mcp-go server")
+			toAdd = append(toAdd, mcpserver.ServerResource{
+				Resource: mapResourceToMCP(res),
+				Handler:  newResourceHandler(provider),
+			})
+		}
+
+		resourceTemplates, err := provider.ListResourceTemplates(ctx)
+		if err != nil {
+			return fmt.Errorf("list resource templates: %w", err)
+		}
+
+		for _, t := range resourceTemplates {
+			if _, ok := currentTemplates[t.URITemplate]; ok {
+				log.Warn().Str("uri_template", t.URITemplate).Msg("Resource template already registered by another provider, skipping")
+				continue
+			}
+			entry := registeredTemplate{template: t, provider: provider}
+			currentTemplates[t.URITemplate] = entry
+			if prev, ok := r.templates[t.URITemplate]; !ok || prev.provider != provider || !reflect.DeepEqual(prev.template, t) {
+				templatesChanged = true
+			}
+
+			mt, err := mapResourceTemplateToMCP(t)
+			if err != nil {
+				return fmt.Errorf("resource template %s: %w", t.URITemplate, err)
+			}
+			templates = append(templates, mcpserver.ServerResourceTemplate{
+				Template: mt,
+				Handler:  mcpserver.ResourceTemplateHandlerFunc(newResourceHandler(provider)),
+			})
+		}
+	}
+
+	var toDelete []string
+	for uri := range r.registered {
+		if _, ok := current[uri]; !ok {
+			log.Debug().Str("uri", uri).Msg("Removing resource from mcp-go server")
+			toDelete = append(toDelete, uri)
+		}
+	}
+	if len(currentTemplates) != len(r.templates) {
+		templatesChanged = true
+	}
+
+	log.Debug().
+		Int("count", len(current)).
+		Int("added", len(toAdd)).
+		Int("removed", len(toDelete)).
+		Int("templates", len(currentTemplates)).
+		Bool("templates_changed", templatesChanged).
+		Msg("Registering resources")
+
+	if len(toAdd) > 0 {
+		r.server.AddResources(toAdd...)
+	}
+	if len(toDelete) > 0 {
+		r.server.DeleteResources(toDelete...)
+	}
+	if templatesChanged {
+		r.server.SetResourceTemplates(templates...)
+	}
+	r.registered = current
+	r.templates = currentTemplates
+
+	return nil
+}
+
+// watch re-syncs the server whenever one of the providers reports changed
+// resources, until ctx is done.
+func (r *resourceSync) watch(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, provider := range r.providers {
+		notifier, ok := provider.(resourceChangeNotifier)
+		if !ok {
+			continue
+		}
+
+		ch, cleanup := notifier.SubscribeToChanges()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cleanup()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ch:
+					if err := r.sync(ctx); err != nil {
+						log.Error().Err(err).Msg("Failed to sync resources into mcp-go server")
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func listAllResources(ctx context.Context, provider pkg.ResourceProvider) ([]protocol.Resource, error) {
+	var ret []protocol.Resource
+	cursor := ""
+	for i := 0; i < maxProviderPages; i++ {
+		resources, next, err := provider.ListResources(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, resources...)
+		if next == "" || next == cursor {
+			break
+		}
+		cursor = next
+	}
+	return ret, nil
+}
+
+func newResourceHandler(provider pkg.ResourceProvider) mcpserver.ResourceHandlerFunc {
+	return func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		uri := req.Params.URI
+		log.Debug().Str("uri", uri).Msg("Handling resource read")
+
+		contents, err := provider.ReadResource(ctx, uri)
+		if err != nil {
+			log.Error().Str("uri", uri).Err(err).Msg("Resource read errored")
+			return nil, err
+		}
+
+		out := make([]mcp.ResourceContents, 0, len(contents))
+		for _, c := range contents {
+			if c.URI == "" {
+				c.URI = uri
+			}
+			out = append(out, mapResourceContentToMCP(c))
+		}
+		return out, nil
+	}
+}
+
+func mapResourceToMCP(r protocol.Resource) mcp.Resource {
+	return mcp.Resource{
+		URI:         r.URI,
+		Name:        r.Name,
+		Description: r.Description,
+		MIMEType:    r.MimeType,
+	}
+}
+
+func mapResourceTemplateToMCP(t protocol.ResourceTemplate) (mcp.ResourceTemplate, error) {
+	tmpl, err := uritemplate.New(t.URITemplate)
+	if err != nil {
+		return mcp.ResourceTemplate{}, err
+	}
+	return mcp.ResourceTemplate{
+		URITemplate: &mcp.URITemplate{Template: tmpl},
+		Name:        t.Name,
+		Description: t.Description,
+		MIMEType:    t.MimeType,
+	}, nil
+}
+
+func mapResourceContentToMCP(c protocol.ResourceContent) mcp.ResourceContents {
+	if c.Blob != "" {
+		return mcp.BlobResourceContents{URI: c.URI, MIMEType: c.MimeType, Blob: c.Blob}
+	}
+	return mcp.TextResourceContents{URI: c.URI, MIMEType: c.MimeType, Text: c.Text}
+}
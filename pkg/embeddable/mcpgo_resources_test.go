@@ -0,0 +1,136 @@
+package embeddable
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/go-go-golems/go-go-mcp/pkg/resources"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMapResourceToMCP(t *testing.T) {
+	got := mapResourceToMCP(protocol.Resource{
+		URI:         "file:///etc/app.json",
+		Name:        "config",
+		Description: "App configuration",
+		MimeType:    "application/json",
+	})
+	if got.URI != "file:///etc/app.json" || got.Name != "config" || got.Description != "App configuration" || got.MIMEType != "application/json" {
+		t.Fatalf("unexpected resource %+v", got)
+	}
+}
+
+func TestMapResourceTemplateToMCP(t *testing.T) {
+	got, err := mapResourceTemplateToMCP(protocol.ResourceTemplate{
+		URITemplate: "file:///logs/{name}",
+		Name:        "logs",
+		MimeType:    "text/plain",
+	})
+	if err != nil {
+		t.Fatalf("mapResourceTemplateToMCP: %v", err)
+	}
+	if got.URITemplate.Raw() != "file:///logs/{name}" || got.Name != "logs" || got.MIMEType != "text/plain" {
+		t.Fatalf("unexpected template %+v", got)
+	}
+
+	if _, err := mapResourceTemplateToMCP(protocol.ResourceTemplate{URITemplate: "file:///{unclosed"}); err == nil {
+		t.Fatal("expected an error for an invalid URI template")
+	}
+}
+
+func TestMapResourceContentToMCP(t *testing.T) {
+	text, ok := mapResourceContentToMCP(protocol.ResourceContent{URI: "file:///a.txt", MimeType: "text/plain", Text: "hello"}).(mcp.TextResourceContents)
+	if !ok || text.URI != "file:///a.txt" || text.MIMEType != "text/plain" || text.Text != "hello" {
+		t.Fatalf("unexpected text contents %+v", text)
+	}
+
+	blob, ok := mapResourceContentToMCP(protocol.ResourceContent{URI: "file:///a.png", MimeType: "image/png", Blob: "aGVsbG8="}).(mcp.BlobResourceContents)
+	if !ok || blob.URI != "file:///a.png" || blob.MIMEType != "image/png" || blob.Blob != "aGVsbG8=" {
+		t.Fatalf("unexpected blob contents %+v", blob)
+	}
+}
+
+func listResourceURIs(t *testing.T, s *mcpServer) []string {
+	t.Helper()
+	raw := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`))
+	data, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("marshal response: %v", err)
+	}
+	var response struct {
+		Result mcp.ListResourcesResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	var uris []string
+	for _, r := range response.Result.Resources {
+		uris = append(uris, r.URI)
+	}
+	return uris
+}
+
+func TestResourceSyncFollowsRegistry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := resources.NewRegistry()
+	reg.RegisterResource(protocol.Resource{URI: "file:///a.txt", Name: "a"})
+
+	cfg := NewServerConfig()
+	if err := WithResourceProvider(reg)(cfg); err != nil {
+		t.Fatalf("WithResourceProvider: %v", err)
+	}
+	s, err := newMCPServer(ctx, cfg)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+	if uris := listResourceURIs(t, s); len(uris) != 1 || uris[0] != "file:///a.txt" {
+		t.Fatalf("unexpected resources %v", uris)
+	}
+
+	session := &testClientSession{id: "session-1", notifications: make(chan mcp.JSONRPCNotification, 4)}
+	if err := s.RegisterSession(ctx, session); err != nil {
+		t.Fatalf("RegisterSession: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.resources.watch(ctx)
+		close(done)
+	}()
+	// Let the watcher subscribe before the registry changes
+	time.Sleep(50 * time.Millisecond)
+
+	reg.RegisterResourceWithHandler(protocol.Resource{URI: "file:///b.txt", Name: "b"}, func(r protocol.Resource) (*protocol.ResourceContent, error) {
+		return &protocol.ResourceContent{URI: r.URI, Text: "b"}, nil
+	})
+	expectListChanged(t, session)
+	if uris := listResourceURIs(t, s); len(uris) != 2 {
+		t.Fatalf("expected the new resource to be listed, got %v", uris)
+	}
+
+	reg.UnregisterResource("file:///a.txt")
+	expectListChanged(t, session)
+	if uris := listResourceURIs(t, s); len(uris) != 1 || uris[0] != "file:///b.txt" {
+		t.Fatalf("expected the removed resource to be gone, got %v", uris)
+	}
+
+	cancel()
+	<-done
+}
+
+func expectListChanged(t *testing.T, session *testClientSession) {
+	t.Helper()
+	select {
+	case n := <-session.notifications:
+		if n.Method != string(mcp.MethodNotificationResourcesListChanged) {
+			t.Fatalf("unexpected notification method %q", n.Method)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a resources/list_changed notification")
+	}
+}
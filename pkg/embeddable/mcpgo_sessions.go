@@ -0,0 +1,131 @@
+package embeddable
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/session"
+	"github.com/google/uuid"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// transportSessions binds mcp-go client sessions to sessions of the
+// configured session.SessionStore, so that handlers find their state with
+// session.GetSessionFromContext.
+//
+// The stdio connection and each SSE stream are one client session, which ends
+// when mcp-go unregisters it. Streamable HTTP sessions are identified by the
+// Mcp-Session-Id header and only end with a DELETE request; the GET streams
+// registered along the way come and go without ending the session.
+//
+// Stores implementing session.SessionBinder keep the IDs of the client
+// sessions; sessions of other stores get the ID the store chooses.
+type transportSessions struct {
+	store session.SessionStore
+	// endOnDelete is set when serving streamable HTTP
+	endOnDelete bool
+	// stdioID replaces the fixed mcp-go stdio session id, so that several
+	// stdio servers can share a persistent store
+	stdioID session.SessionID
+
+	mu sync.Mutex
+	// created maps client session IDs to the sessions created for them in
+	// stores that are not a session.SessionBinder
+	created map[session.SessionID]session.SessionID
+}
+
+func newTransportSessions(store session.SessionStore) *transportSessions {
+	return &transportSessions{
+		store:   store,
+		stdioID: session.SessionID(stdioSessionID + "-" + uuid.NewString()),
+		created: make(map[session.SessionID]session.SessionID),
+	}
+}
+
+// id returns the store session ID bound to the mcp-go session clientID. HTTP
+// sessions keep their ID, so that persistent stores find them again after a
+// restart.
+func (t *transportSessions) id(clientID string) session.SessionID {
+	if clientID == stdioSessionID {
+		return t.stdioID
+	}
+	return session.SessionID(clientID)
+}
+
+// get returns the store session bound to clientID, creating it on first use.
+func (t *transportSessions) get(clientID string) *session.Session {
+	id := t.id(clientID)
+	if binder, ok := t.store.(session.SessionBinder); ok {
+		return binder.GetOrCreate(id)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if created, ok := t.created[id]; ok {
+		if s, ok := t.store.Get(created); ok {
+			return s
+		}
+	}
+	s := t.store.Create()
+	t.created[id] = s.ID
+	return s
+}
+
+// end deletes the store session bound to clientID, if any.
+func (t *transportSessions) end(clientID string) {
+	id := t.id(clientID)
+	t.mu.Lock()
+	if created, ok := t.created[id]; ok {
+		delete(t.created, id)
+		id = created
+	}
+	t.mu.Unlock()
+
+	t.store.Delete(id)
+	log.Debug().Str("client_session", clientID).Msg("Ended session")
+}
+
+// withSession adds the store session of the mcp-go session in ctx to ctx.
+func (t *transportSessions) withSession(ctx context.Context) context.Context {
+	clientSession := mcpserver.ClientSessionFromContext(ctx)
+	if clientSession == nil || clientSession.SessionID() == "" {
+		return ctx
+	}
+	return session.WithSession(ctx, t.get(clientSession.SessionID()))
+}
+
+// httpContextFunc is withSession for the SSE and streamable HTTP context hooks.
+func (t *transportSessions) httpContextFunc(ctx context.Context, _ *http.Request) context.Context {
+	return t.withSession(ctx)
+}
+
+// unregister is the mcp-go hook ending stdio and SSE sessions.
+func (t *transportSessions) unregister(_ context.Context, clientSession mcpserver.ClientSession) {
+	if t.endOnDelete {
+		return
+	}
+	t.end(clientSession.SessionID())
+}
+
+// sessionIDManager ends streamable HTTP sessions when the client terminates them.
+func (t *transportSessions) sessionIDManager() mcpserver.SessionIdManager {
+	return &endingSessionIDManager{
+		SessionIdManager: &mcpserver.InsecureStatefulSessionIdManager{},
+		sessions:         t,
+	}
+}
+
+type endingSessionIDManager struct {
+	mcpserver.SessionIdManager
+	sessions *transportSessions
+}
+
+func (m *endingSessionIDManager) Terminate(sessionID string) (bool, error) {
+	notAllowed, err := m.SessionIdManager.Terminate(sessionID)
+	if err == nil && !notAllowed {
+		m.sessions.end(sessionID)
+	}
+	return notAllowed, err
+}
@@ -0,0 +1,86 @@
+package embeddable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/session"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+type fakeClientSession struct {
+	id string
+}
+
+func (s fakeClientSession) Initialize()       {}
+func (s fakeClientSession) Initialized() bool { return true }
+func (s fakeClientSession) SessionID() string { return s.id }
+func (s fakeClientSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification)
+}
+
+func TestTransportSessions(t *testing.T) {
+	store := session.NewInMemorySessionStore()
+	sessions := newTransportSessions(store)
+	server := mcpserver.NewMCPServer("test", "0.0.0")
+
+	sessionOf := func(clientID string) *session.Session {
+		ctx := sessions.withSession(server.WithContext(context.Background(), fakeClientSession{id: clientID}))
+		s, ok := session.GetSessionFromContext(ctx)
+		if !ok {
+			t.Fatalf("no session for %s", clientID)
+		}
+		return s
+	}
+
+	a := sessionOf("sse-a")
+	a.SetData("db", "open")
+	if again := sessionOf("sse-a"); again != a {
+		t.Fatalf("expected the same session for the same client")
+	}
+	if b := sessionOf("sse-b"); b == a {
+		t.Fatalf("expected different sessions for different clients")
+	}
+
+	sessions.unregister(context.Background(), fakeClientSession{id: "sse-a"})
+	if _, ok := store.Get(a.ID); ok {
+		t.Fatalf("session not deleted on disconnect")
+	}
+	if _, ok := sessionOf("sse-a").GetData("db"); ok {
+		t.Fatalf("state survived the end of the session")
+	}
+
+	// Streamable HTTP sessions survive their GET streams and end with DELETE
+	sessions.endOnDelete = true
+	c := sessionOf("mcp-session-c")
+	sessions.unregister(context.Background(), fakeClientSession{id: "mcp-session-c"})
+	if _, ok := store.Get(c.ID); !ok {
+		t.Fatalf("streamable session deleted when a stream closed")
+	}
+	if _, err := sessions.sessionIDManager().Terminate("mcp-session-c"); err != nil {
+		t.Fatalf("Terminate: %v", err)
+	}
+	if _, ok := store.Get(c.ID); ok {
+		t.Fatalf("streamable session not deleted on DELETE")
+	}
+}
+
+func TestTransportSessionsWithoutBinder(t *testing.T) {
+	store := session.NewInMemorySessionStore()
+	// Only the methods of session.SessionStore, so the store chooses the IDs
+	sessions := newTransportSessions(struct{ session.SessionStore }{store})
+
+	a := sessions.get("sse-a")
+	if a.ID == "sse-a" {
+		t.Fatalf("expected an ID chosen by the store")
+	}
+	if again := sessions.get("sse-a"); again != a {
+		t.Fatalf("expected the same session for the same client")
+	}
+
+	sessions.end("sse-a")
+	if _, ok := store.Get(a.ID); ok {
+		t.Fatalf("session not deleted when the client session ended")
+	}
+}
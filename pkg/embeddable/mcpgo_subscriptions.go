@@ -0,0 +1,379 @@
+package embeddable
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+
+	// stdioSessionID is the fixed session id mcp-go uses for its single stdio session.
+	stdioSessionID = "stdio"
+)
+
+// resourceSubscriptions tracks resources/subscribe requests per session and
+// forwards provider change signals as notifications/resources/updated.
+//
+// mcp-go does not route resources/subscribe and resources/unsubscribe to any
+// handler, so the transports pass incoming messages through intercept first.
+// It handles both methods and returns the JSON-RPC response, which the
+// transports send the way mcp-go sends its own responses. Over HTTP, only
+// the sessions mcp-go registered, and thus validated, can subscribe.
+type resourceSubscriptions struct {
+	server    *mcpserver.MCPServer
+	providers []pkg.ResourceProvider
+
+	mu   sync.Mutex
+	subs map[string]*resourceSubscription
+	// sessions holds the IDs of the sessions registered with mcp-go
+	sessions map[string]bool
+}
+
+// resourceSubscription is a single provider subscription for a URI, shared by
+// all sessions subscribed to it.
+type resourceSubscription struct {
+	sessions map[string]bool
+	cleanup  func()
+}
+
+func newResourceSubscriptions(s *mcpserver.MCPServer, providers []pkg.ResourceProvider) *resourceSubscriptions {
+	return &resourceSubscriptions{
+		server:    s,
+		providers: providers,
+		subs:      map[string]*resourceSubscription{},
+		sessions:  map[string]bool{},
+	}
+}
+
+// subscribe adds sessionID to the subscribers of uri, subscribing to the
+// first provider that knows the resource if this is the first subscriber.
+func (r *resourceSubscriptions) subscribe(ctx context.Context, sessionID string, uri string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sub, ok := r.subs[uri]; ok {
+		sub.sessions[sessionID] = true
+		return nil
+	}
+
+	var lastErr error = pkg.ErrResourceNotFound
+	for _, provider := range r.providers {
+		ch, cleanup, err := provider.SubscribeToResource(ctx, uri)
+		if err != nil {
+			// Providers that don't know the resource don't hide why another
+			// one couldn't subscribe to it
+			if !errors.Is(err, pkg.ErrResourceNotFound) {
+				lastErr = err
+			}
+			continue
+		}
+
+		r.subs[uri] = &resourceSubscription{
+			sessions: map[string]bool{sessionID: true},
+			cleanup:  cleanup,
+		}
+		go r.forward(uri, ch)
+		return nil
+	}
+
+	return lastErr
+}
+
+// unsubscribe removes sessionID from the subscribers of uri and releases the
+// provider subscription once nobody is left.
+func (r *resourceSubscriptions) unsubscribe(sessionID string, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(sessionID, uri)
+}
+
+// dropSession removes all subscriptions held by sessionID.
+func (r *resourceSubscriptions) dropSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for uri := range r.subs {
+		r.removeLocked(sessionID, uri)
+	}
+}
+
+func (r *resourceSubscriptions) removeLocked(sessionID string, uri string) {
+	sub, ok := r.subs[uri]
+	if !ok {
+		return
+	}
+	delete(sub.sessions, sessionID)
+	if len(sub.sessions) == 0 {
+		delete(r.subs, uri)
+		if sub.cleanup != nil {
+			sub.cleanup()
+		}
+	}
+}
+
+// forward sends notifications/resources/updated to every session subscribed to
+// uri each time the provider signals a change. It returns once the provider
+// closes the channel.
+func (r *resourceSubscriptions) forward(uri string, ch chan struct{}) {
+	for range ch {
+		r.mu.Lock()
+		var sessions []string
+		if sub, ok := r.subs[uri]; ok {
+			for sessionID := range sub.sessions {
+				sessions = append(sessions, sessionID)
+			}
+		}
+		r.mu.Unlock()
+
+		log.Debug().Str("uri", uri).Int("sessions", len(sessions)).Msg("Resource updated, notifying subscribers")
+		for _, sessionID := range sessions {
+			err := r.server.SendNotificationToSpecificClient(
+				sessionID,
+				string(mcp.MethodNotificationResourceUpdated),
+				map[string]any{"uri": uri},
+			)
+			if err != nil {
+				log.Debug().Str("uri", uri).Str("session", sessionID).Err(err).Msg("Could not deliver resource update")
+			}
+		}
+	}
+}
+
+// intercept handles resources/subscribe and resources/unsubscribe requests for
+// sessionID and returns the JSON-RPC response to send to the client. handled
+// is false for any other message, which is left to mcp-go.
+func (r *resourceSubscriptions) intercept(ctx context.Context, sessionID string, raw []byte) (response []byte, handled bool) {
+	msg, ok := parseSubscriptionRequest(raw)
+	if !ok {
+		return nil, false
+	}
+
+	switch msg.Method {
+	case methodResourcesSubscribe:
+		if err := r.subscribe(ctx, sessionID, msg.Params.URI); err != nil {
+			log.Warn().Str("uri", msg.Params.URI).Str("session", sessionID).Err(err).Msg("Resource subscription failed")
+			return subscriptionError(msg.ID, msg.Params.URI, err), true
+		}
+		log.Debug().Str("uri", msg.Params.URI).Str("session", sessionID).Msg("Subscribed to resource")
+	case methodResourcesUnsubscribe:
+		r.unsubscribe(sessionID, msg.Params.URI)
+		log.Debug().Str("uri", msg.Params.URI).Str("session", sessionID).Msg("Unsubscribed from resource")
+	}
+
+	response, _ = json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      msg.ID,
+		"result":  map[string]any{},
+	})
+	return response, true
+}
+
+type subscriptionRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+// parseSubscriptionRequest returns raw if it is a subscribe or unsubscribe
+// request for a URI
+func parseSubscriptionRequest(raw []byte) (subscriptionRequest, bool) {
+	var msg subscriptionRequest
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.ID == nil || msg.Params.URI == "" {
+		return msg, false
+	}
+	return msg, msg.Method == methodResourcesSubscribe || msg.Method == methodResourcesUnsubscribe
+}
+
+// subscriptionError builds the JSON-RPC error answering the subscribe request
+// with the given id: resource not found if no provider knows uri, an internal
+// error carrying the provider's error otherwise.
+func subscriptionError(id json.RawMessage, uri string, err error) []byte {
+	code := mcp.INTERNAL_ERROR
+	if errors.Is(err, pkg.ErrResourceNotFound) {
+		code = mcp.RESOURCE_NOT_FOUND
+	}
+	response, _ := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      id,
+		"error": map[string]any{
+			"code":    code,
+			"message": fmt.Sprintf("failed to subscribe to %s: %v", uri, err),
+		},
+	})
+	return response
+}
+
+// hooks returns mcp-go hooks that track the registered sessions and release
+// a session's subscriptions when the session goes away.
+func (r *resourceSubscriptions) hooks() *mcpserver.Hooks {
+	h := &mcpserver.Hooks{}
+	h.AddOnRegisterSession(func(_ context.Context, session mcpserver.ClientSession) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.sessions[session.SessionID()] = true
+	})
+	h.AddOnUnregisterSession(func(_ context.Context, session mcpserver.ClientSession) {
+		r.mu.Lock()
+		delete(r.sessions, session.SessionID())
+		r.mu.Unlock()
+		r.dropSession(session.SessionID())
+	})
+	return h
+}
+
+// registered returns whether mcp-go registered the session sessionID
+func (r *resourceSubscriptions) registered(sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sessions[sessionID]
+}
+
+// httpMiddleware intercepts subscription requests posted over HTTP. The
+// session ID is read by sessionOf, and must belong to a session registered
+// with mcp-go, that is one with an open stream to send notifications on.
+// Responses are sent with reply; requests of other sessions are rejected
+// with a JSON-RPC error in the body, as mcp-go does for unknown sessions.
+func (r *resourceSubscriptions) httpMiddleware(
+	sessionOf func(*http.Request) string,
+	reply func(w http.ResponseWriter, sessionID string, response []byte),
+	next http.Handler,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r == nil || req.Method != http.MethodPost || req.Body == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+
+		msg, ok := parseSubscriptionRequest(body)
+		if !ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+		sessionID := sessionOf(req)
+		if sessionID == "" || !r.registered(sessionID) {
+			log.Warn().Str("session", sessionID).Str("method", msg.Method).Msg("Rejecting subscription request of an unknown session")
+			writeJSON(w, http.StatusBadRequest, invalidSessionError(msg.ID))
+			return
+		}
+
+		response, _ := r.intercept(req.Context(), sessionID, body)
+		reply(w, sessionID, response)
+	})
+}
+
+// sseSessionID returns the session ID of an SSE message request
+func sseSessionID(req *http.Request) string {
+	return req.URL.Query().Get("sessionId")
+}
+
+// streamableSessionID returns the session ID of a streamable HTTP request
+func streamableSessionID(req *http.Request) string {
+	return req.Header.Get(mcpserver.HeaderKeySessionID)
+}
+
+// sseReply sends response on the event stream of the session and accepts the
+// request, like mcp-go's SSE server.
+func sseReply(sse *mcpserver.SSEServer) func(http.ResponseWriter, string, []byte) {
+	return func(w http.ResponseWriter, sessionID string, response []byte) {
+		if err := sse.SendEventToSession(sessionID, json.RawMessage(response)); err != nil {
+			log.Warn().Str("session", sessionID).Err(err).Msg("Could not send subscription response")
+			writeJSON(w, http.StatusBadRequest, response)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// jsonReply sends response in the response body, which streamable HTTP allows
+func jsonReply(w http.ResponseWriter, _ string, response []byte) {
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// invalidSessionError builds the JSON-RPC error answering a request of an
+// unknown session
+func invalidSessionError(id json.RawMessage) []byte {
+	response, _ := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      id,
+		"error": map[string]any{
+			"code":    mcp.INVALID_PARAMS,
+			"message": "Invalid session ID",
+		},
+	})
+	return response
+}
+
+// stdioReader wraps the stdio input stream so that subscription requests are
+// intercepted line by line before mcp-go sees them. Their responses are
+// written to out, which must be safe to share with mcp-go's own output.
+func (r *resourceSubscriptions) stdioReader(ctx context.Context, in io.Reader, out io.Writer) io.Reader {
+	if r == nil {
+		return in
+	}
+
+	return filterLines(in, func(line []byte) []byte {
+		response, handled := r.intercept(ctx, stdioSessionID, line)
+		if !handled {
+			return line
+		}
+		if _, err := out.Write(append(response, '\n')); err != nil {
+			log.Warn().Err(err).Msg("Could not write subscription response")
+		}
+		return nil
+	})
+}
+
+// filterLines passes each line of in through f, without its line ending.
+// Lines for which f returns nil are dropped.
+func filterLines(in io.Reader, f func(line []byte) []byte) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				out := f(bytes.TrimRight(line, "\r\n"))
+				if out != nil {
+					if _, werr := pw.Write(append(out, '\n')); werr != nil {
+						return
+					}
+				}
+			}
+			if err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
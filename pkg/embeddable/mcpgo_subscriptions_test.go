@@ -0,0 +1,190 @@
+package embeddable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/go-go-golems/go-go-mcp/pkg/resources"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+type testClientSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testClientSession) Initialize()       {}
+func (s *testClientSession) Initialized() bool { return true }
+func (s *testClientSession) SessionID() string { return s.id }
+func (s *testClientSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestResourceSubscriptionsForwardUpdates(t *testing.T) {
+	ctx := context.Background()
+	reg := resources.NewRegistry()
+	resource := protocol.Resource{URI: "file:///tmp/app.log", Name: "app.log"}
+	reg.RegisterResource(resource)
+
+	cfg := NewServerConfig()
+	if err := WithResourceProvider(reg)(cfg); err != nil {
+		t.Fatalf("WithResourceProvider: %v", err)
+	}
+	s, err := newMCPServer(ctx, cfg)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+
+	session := &testClientSession{id: "session-1", notifications: make(chan mcp.JSONRPCNotification, 4)}
+	if err := s.RegisterSession(ctx, session); err != nil {
+		t.Fatalf("RegisterSession: %v", err)
+	}
+
+	response, handled := s.subscriptions.intercept(ctx, session.id, []byte(`{"jsonrpc":"2.0","id":7,"method":"resources/subscribe","params":{"uri":"file:///tmp/app.log"}}`))
+	if !handled {
+		t.Fatalf("subscribe request not handled")
+	}
+	if string(response) != `{"id":7,"jsonrpc":"2.0","result":{}}` {
+		t.Fatalf("expected an empty result for id 7, got %s", response)
+	}
+
+	reg.NotifyResourceChanged(resource.URI)
+
+	select {
+	case n := <-session.notifications:
+		if n.Method != string(mcp.MethodNotificationResourceUpdated) {
+			t.Fatalf("unexpected notification method %q", n.Method)
+		}
+		if uri := n.Params.AdditionalFields["uri"]; uri != resource.URI {
+			t.Fatalf("unexpected notification uri %v", uri)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a resources/updated notification")
+	}
+
+	s.UnregisterSession(ctx, session.id)
+	if len(s.subscriptions.subs) != 0 {
+		t.Fatalf("expected subscriptions to be dropped with the session")
+	}
+}
+
+func TestResourceSubscriptionsFailureReturnsError(t *testing.T) {
+	subs := newResourceSubscriptions(nil, []pkg.ResourceProvider{resources.NewRegistry()})
+	in := []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"file:///missing"}}`)
+	response, handled := subs.intercept(context.Background(), "session-1", in)
+	if !handled {
+		t.Fatalf("expected failed subscription not to reach mcp-go")
+	}
+
+	var resp struct {
+		ID    int `json:"id"`
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(response, &resp); err != nil {
+		t.Fatalf("unmarshal response %s: %v", response, err)
+	}
+	if resp.ID != 1 || resp.Error.Code != mcp.RESOURCE_NOT_FOUND {
+		t.Fatalf("expected resource not found error for id 1, got %s", response)
+	}
+}
+
+type unsubscribableProvider struct {
+	*resources.Registry
+}
+
+func (unsubscribableProvider) SubscribeToResource(context.Context, string) (chan struct{}, func(), error) {
+	return nil, nil, pkg.ErrNotImplemented
+}
+
+func TestResourceSubscriptionsStdioError(t *testing.T) {
+	subs := newResourceSubscriptions(nil, []pkg.ResourceProvider{
+		resources.NewRegistry(),
+		unsubscribableProvider{resources.NewRegistry()},
+	})
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":"a","method":"resources/subscribe","params":{"uri":"file:///tmp/app.log"}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}` + "\n")
+	var out bytes.Buffer
+
+	forwarded, err := io.ReadAll(subs.stdioReader(context.Background(), in, &out))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(forwarded) != `{"jsonrpc":"2.0","id":2,"method":"ping"}`+"\n" {
+		t.Fatalf("expected only the ping to be forwarded, got %s", forwarded)
+	}
+
+	var resp struct {
+		ID    string `json:"id"`
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response %s: %v", out.Bytes(), err)
+	}
+	if resp.ID != "a" || resp.Error.Code != mcp.INTERNAL_ERROR || !strings.Contains(resp.Error.Message, "not implemented") {
+		t.Fatalf("expected an internal error carrying the provider error, got %s", out.Bytes())
+	}
+}
+
+func TestResourceSubscriptionsHTTPSessions(t *testing.T) {
+	ctx := context.Background()
+	reg := resources.NewRegistry()
+	reg.RegisterResource(protocol.Resource{URI: "file:///tmp/app.log", Name: "app.log"})
+
+	cfg := NewServerConfig()
+	if err := WithResourceProvider(reg)(cfg); err != nil {
+		t.Fatalf("WithResourceProvider: %v", err)
+	}
+	s, err := newMCPServer(ctx, cfg)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+	session := &testClientSession{id: "mcp-session-1", notifications: make(chan mcp.JSONRPCNotification, 4)}
+	if err := s.RegisterSession(ctx, session); err != nil {
+		t.Fatalf("RegisterSession: %v", err)
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("subscription request reached mcp-go")
+	})
+	handler := s.subscriptions.httpMiddleware(streamableSessionID, jsonReply, next)
+	post := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(
+			`{"jsonrpc":"2.0","id":3,"method":"resources/subscribe","params":{"uri":"file:///tmp/app.log"}}`))
+		req.Header.Set(mcpserver.HeaderKeySessionID, sessionID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Session IDs that mcp-go didn't register are rejected
+	if rec := post("mcp-session-forged"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected forged session to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+	if len(s.subscriptions.subs) != 0 {
+		t.Fatalf("forged session subscribed")
+	}
+
+	rec := post(session.id)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"id":3,"jsonrpc":"2.0","result":{}}` {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+	if !s.subscriptions.subs["file:///tmp/app.log"].sessions[session.id] {
+		t.Fatalf("session not subscribed")
+	}
+}
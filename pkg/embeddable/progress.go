@@ -0,0 +1,145 @@
+package embeddable
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/tools"
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+// requestIDMetaKey is the _meta field the call hook uses to pass the JSON-RPC
+// request id of a tool call to its handler, which mcp-go doesn't expose.
+const requestIDMetaKey = "go-go-mcp/requestId"
+
+// ReportProgress reports the progress of the tool call running in ctx to the
+// client, as a notifications/progress message. total is 0 when unknown. It is
+// a no-op if the client did not send a progress token with the call.
+func ReportProgress(ctx context.Context, progress, total float64, message string) error {
+	return tools.ReportProgress(ctx, progress, total, message)
+}
+
+// ReportLog sends a notifications/message log message to the client of the
+// tool call running in ctx. Clients only receive messages at or above the level
+// they selected with logging/setLevel.
+func ReportLog(ctx context.Context, level string, data interface{}) error {
+	return tools.ReportLog(ctx, level, data)
+}
+
+// newLogReporter sends log messages attributed to tool to the session of the
+// tool call running in ctx.
+func newLogReporter(ctx context.Context, tool string) tools.LogReporter {
+	server := mcpserver.ServerFromContext(ctx)
+	return func(level string, data interface{}) error {
+		if server == nil {
+			return nil
+		}
+		return server.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(mcp.LoggingLevel(level), tool, data))
+	}
+}
+
+// newProgressReporter sends progress notifications for token to the session
+// of the tool call running in ctx.
+func newProgressReporter(ctx context.Context, token mcp.ProgressToken) tools.ProgressReporter {
+	server := mcpserver.ServerFromContext(ctx)
+	return func(progress, total float64, message string) error {
+		if server == nil {
+			return nil
+		}
+		params := map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+		}
+		if total > 0 {
+			params["total"] = total
+		}
+		if message != "" {
+			params["message"] = message
+		}
+		return server.SendNotificationToClient(ctx, "notifications/progress", params)
+	}
+}
+
+// inflightCalls tracks the running tool calls so that notifications/cancelled
+// can cancel their context. Calls are keyed by session and request id.
+type inflightCalls struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newInflightCalls() *inflightCalls {
+	return &inflightCalls{cancels: map[string]context.CancelFunc{}}
+}
+
+func inflightKey(ctx context.Context, requestID string) string {
+	sessionID := ""
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	return sessionID + "/" + requestID
+}
+
+// start registers the tool call req and returns its cancellable context
+// along with the function to call once the call is done.
+func (c *inflightCalls) start(ctx context.Context, req mcp.CallToolRequest) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if req.Params.Meta == nil {
+		return ctx, cancel
+	}
+	requestID, ok := req.Params.Meta.AdditionalFields[requestIDMetaKey].(string)
+	if !ok {
+		return ctx, cancel
+	}
+
+	key := inflightKey(ctx, requestID)
+	c.mu.Lock()
+	c.cancels[key] = cancel
+	c.mu.Unlock()
+
+	return ctx, func() {
+		c.mu.Lock()
+		delete(c.cancels, key)
+		c.mu.Unlock()
+		cancel()
+	}
+}
+
+// beforeCallTool stores the request id in the call's _meta
+func (c *inflightCalls) beforeCallTool(_ context.Context, id any, req *mcp.CallToolRequest) {
+	requestID, err := json.Marshal(id)
+	if err != nil {
+		return
+	}
+	if req.Params.Meta == nil {
+		req.Params.Meta = &mcp.Meta{}
+	}
+	if req.Params.Meta.AdditionalFields == nil {
+		req.Params.Meta.AdditionalFields = map[string]any{}
+	}
+	req.Params.Meta.AdditionalFields[requestIDMetaKey] = string(requestID)
+}
+
+// handleCancelled cancels the tool call named by a notifications/cancelled
+func (c *inflightCalls) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	requestID, err := json.Marshal(notification.Params.AdditionalFields["requestId"])
+	if err != nil {
+		return
+	}
+
+	key := inflightKey(ctx, string(requestID))
+	c.mu.Lock()
+	cancel, ok := c.cancels[key]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	log.Info().
+		Str("request_id", string(requestID)).
+		Interface("reason", notification.Params.AdditionalFields["reason"]).
+		Msg("Cancelling tool call")
+	cancel()
+}
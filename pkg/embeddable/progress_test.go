@@ -0,0 +1,56 @@
+package embeddable
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mcp "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+func TestCancelledNotificationCancelsToolCall(t *testing.T) {
+	calls := newInflightCalls()
+	hooks := &mcpserver.Hooks{}
+	hooks.AddBeforeCallTool(calls.beforeCallTool)
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true), mcpserver.WithHooks(hooks))
+	s.AddNotificationHandler("notifications/cancelled", calls.handleCancelled)
+
+	started := make(chan struct{})
+	s.AddTool(mcp.NewTool("wait"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, done := calls.start(ctx, req)
+		defer done()
+		close(started)
+		select {
+		case <-ctx.Done():
+			return mcp.NewToolResultText("cancelled"), nil
+		case <-time.After(5 * time.Second):
+			return mcp.NewToolResultText("timeout"), nil
+		}
+	})
+
+	ctx := context.Background()
+	responses := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		responses <- s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"wait","arguments":{}}}`))
+	}()
+	<-started
+	s.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user abort"}}`))
+
+	select {
+	case response := <-responses:
+		data, _ := json.Marshal(response)
+		var decoded struct {
+			Result mcp.CallToolResult `json:"result"`
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if text := decoded.Result.Content[0].(mcp.TextContent).Text; text != "cancelled" {
+			t.Fatalf("expected call to be cancelled, got %q", text)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool call was not cancelled")
+	}
+}
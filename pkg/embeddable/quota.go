@@ -0,0 +1,329 @@
+package embeddable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// QuotaPerTool counts the calls to each tool separately
+	QuotaPerTool = "tool"
+	// QuotaPerSession counts the calls of each session separately
+	QuotaPerSession = "session"
+	// QuotaPerSubject counts the calls of each authenticated subject separately
+	QuotaPerSubject = "subject"
+
+	// quotaConcurrencyRetryAfter is the hint given when too many calls are running
+	quotaConcurrencyRetryAfter = time.Second
+	// quotaIdleBucketTTL is how long an unused, full bucket is kept
+	quotaIdleBucketTTL = 10 * time.Minute
+)
+
+// Quota limits the calls to the tools it matches.
+//
+// A quota matches the tools whose name matches one of the Tools glob patterns
+// (all tools if Tools is empty). All matching calls share the quota, unless
+// Per splits it by QuotaPerTool, QuotaPerSession and/or QuotaPerSubject.
+//
+// Rate and Burst configure a token bucket: up to Burst calls can be made at
+// once, and Rate calls per second are allowed after that. Burst defaults to
+// Rate rounded up. MaxConcurrent caps the number of calls running at the same
+// time. Zero values disable the respective limit. A call must pass every
+// quota matching it.
+type Quota struct {
+	Name          string   `yaml:"name,omitempty"`
+	Tools         []string `yaml:"tools,omitempty"`
+	Per           []string `yaml:"per,omitempty"`
+	Rate          float64  `yaml:"rate,omitempty"`
+	Burst         int      `yaml:"burst,omitempty"`
+	MaxConcurrent int      `yaml:"max_concurrent,omitempty"`
+}
+
+// QuotaExceededError describes a call rejected by a quota
+type QuotaExceededError struct {
+	Quota string
+	Tool  string
+	// Limit describes the limit that was hit
+	Limit string
+	// RetryAfter is how long the caller should wait before trying again
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota %s exceeded by %s (%s), retry after %s",
+		e.Quota, e.Tool, e.Limit, e.RetryAfter.Round(time.Millisecond))
+}
+
+// QuotaCounters are the counters of a quota, summed over its keys
+type QuotaCounters struct {
+	Quota              string `json:"quota"`
+	Allowed            uint64 `json:"allowed"`
+	RateLimited        uint64 `json:"rate_limited"`
+	ConcurrencyLimited uint64 `json:"concurrency_limited"`
+	Running            int    `json:"running"`
+	// Keys is the number of tools, sessions or subjects currently tracked
+	Keys int `json:"keys"`
+}
+
+// QuotaLimiter enforces a set of quotas on tool calls, see Middleware.
+type QuotaLimiter struct {
+	quotas []*quotaState
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+type quotaState struct {
+	Quota
+	counters QuotaCounters
+	buckets  map[string]*quotaBucket
+}
+
+type quotaBucket struct {
+	tokens  float64
+	updated time.Time
+	used    time.Time
+	running int
+}
+
+// quotaAppliedKey marks calls that already passed a limiter's middleware
+type quotaAppliedKey struct{}
+
+// NewQuotaLimiter validates quotas and creates a limiter enforcing them
+func NewQuotaLimiter(quotas ...Quota) (*QuotaLimiter, error) {
+	l := &QuotaLimiter{lastSweep: time.Now()}
+	for i, quota := range quotas {
+		for _, pattern := range quota.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+			}
+		}
+		for _, per := range quota.Per {
+			switch per {
+			case QuotaPerTool, QuotaPerSession, QuotaPerSubject:
+			default:
+				return nil, fmt.Errorf("invalid quota key %q, must be one of tool, session or subject", per)
+			}
+		}
+		if quota.Rate < 0 || quota.Burst < 0 || quota.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("quota limits must not be negative")
+		}
+		if quota.Rate > 0 && quota.Burst == 0 {
+			quota.Burst = int(math.Ceil(quota.Rate))
+		}
+		if quota.Name == "" {
+			quota.Name = fmt.Sprintf("quota-%d", i+1)
+		}
+		l.quotas = append(l.quotas, &quotaState{
+			Quota:    quota,
+			counters: QuotaCounters{Quota: quota.Name},
+			buckets:  map[string]*quotaBucket{},
+		})
+	}
+	return l, nil
+}
+
+// WithQuotas enforces quotas on all tool calls. Rejected calls return an
+// error result telling the model when to retry. On the HTTP transports, the
+// counters are served as JSON under /debug/quotas.
+func WithQuotas(quotas ...Quota) ServerOption {
+	return func(config *ServerConfig) error {
+		limiter, err := NewQuotaLimiter(quotas...)
+		if err != nil {
+			return err
+		}
+		config.quotaLimiter = limiter
+		config.middleware = append(config.middleware, limiter.Middleware())
+		return nil
+	}
+}
+
+// Middleware returns a ToolMiddleware enforcing the quotas. The tool name is
+// taken from ToolNameFromContext. Calls going through the middleware of the
+// same limiter twice are only counted once.
+func (l *QuotaLimiter) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, args map[string]interface{}) (*protocol.ToolResult, error) {
+			if ctx.Value(quotaAppliedKey{}) == l {
+				return next(ctx, args)
+			}
+
+			name, _ := ToolNameFromContext(ctx)
+			release, err := l.acquire(ctx, name, time.Now())
+			if err != nil {
+				log.Warn().Str("tool", name).Str("quota", err.Quota).Dur("retry_after", err.RetryAfter).Msg("Tool call rejected by quota")
+				return protocol.NewErrorToolResult(protocol.NewTextContent(err.Error())), nil
+			}
+			defer release()
+
+			return next(context.WithValue(ctx, quotaAppliedKey{}, l), args)
+		}
+	}
+}
+
+// acquire takes a token from, and reserves a running slot in, the buckets of
+// every quota matching the call, or none of them if one is exhausted.
+func (l *QuotaLimiter) acquire(ctx context.Context, name string, now time.Time) (func(), *QuotaExceededError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	var quotas []*quotaState
+	var buckets []*quotaBucket
+	for _, q := range l.quotas {
+		if !q.matches(name) {
+			continue
+		}
+		key := q.key(ctx, name)
+		b, ok := q.buckets[key]
+		if !ok {
+			b = &quotaBucket{tokens: float64(q.Burst), updated: now, used: now}
+			q.buckets[key] = b
+		}
+		q.refill(b, now)
+
+		if q.MaxConcurrent > 0 && b.running >= q.MaxConcurrent {
+			q.counters.ConcurrencyLimited++
+			return nil, &QuotaExceededError{
+				Quota:      q.Name,
+				Tool:       name,
+				Limit:      fmt.Sprintf("%d calls already running", b.running),
+				RetryAfter: quotaConcurrencyRetryAfter,
+			}
+		}
+		if q.Rate > 0 && b.tokens < 1 {
+			q.counters.RateLimited++
+			wait := time.Duration((1 - b.tokens) / q.Rate * float64(time.Second))
+			return nil, &QuotaExceededError{
+				Quota:      q.Name,
+				Tool:       name,
+				Limit:      fmt.Sprintf("%g calls per second", q.Rate),
+				RetryAfter: wait,
+			}
+		}
+		quotas = append(quotas, q)
+		buckets = append(buckets, b)
+	}
+
+	for i, q := range quotas {
+		if q.Rate > 0 {
+			buckets[i].tokens--
+		}
+		buckets[i].running++
+		buckets[i].used = now
+		q.counters.Allowed++
+	}
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, b := range buckets {
+			b.running--
+		}
+	}, nil
+}
+
+// sweep drops the buckets that have been idle and full for a while, so that
+// per-session and per-subject quotas don't grow forever.
+func (l *QuotaLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < quotaIdleBucketTTL {
+		return
+	}
+	l.lastSweep = now
+	for _, q := range l.quotas {
+		for key, b := range q.buckets {
+			q.refill(b, now)
+			if b.running == 0 && b.tokens >= float64(q.Burst) && now.Sub(b.used) >= quotaIdleBucketTTL {
+				delete(q.buckets, key)
+			}
+		}
+	}
+}
+
+// Counters returns the counters of every quota
+func (l *QuotaLimiter) Counters() []QuotaCounters {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ret := make([]QuotaCounters, 0, len(l.quotas))
+	for _, q := range l.quotas {
+		counters := q.counters
+		counters.Keys = len(q.buckets)
+		for _, b := range q.buckets {
+			counters.Running += b.running
+		}
+		ret = append(ret, counters)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Quota < ret[j].Quota })
+	return ret
+}
+
+// String returns the counters as JSON, so that the limiter can be published
+// with expvar.Publish.
+func (l *QuotaLimiter) String() string {
+	data, err := json.Marshal(l.Counters())
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// Handler serves the counters as JSON
+func (l *QuotaLimiter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(l.String()))
+	})
+}
+
+func (q *quotaState) matches(name string) bool {
+	if len(q.Tools) == 0 {
+		return true
+	}
+	for _, pattern := range q.Tools {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *quotaState) key(ctx context.Context, name string) string {
+	parts := make([]string, 0, len(q.Per))
+	for _, per := range q.Per {
+		switch per {
+		case QuotaPerTool:
+			parts = append(parts, "tool="+name)
+		case QuotaPerSession:
+			sessionID := ""
+			if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+				sessionID = session.SessionID()
+			}
+			parts = append(parts, "session="+sessionID)
+		case QuotaPerSubject:
+			principal, _ := GetAuthPrincipal(ctx)
+			parts = append(parts, "subject="+principal.Subject)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func (q *quotaState) refill(b *quotaBucket, now time.Time) {
+	if q.Rate > 0 && now.After(b.updated) {
+		b.tokens = math.Min(float64(q.Burst), b.tokens+now.Sub(b.updated).Seconds()*q.Rate)
+	}
+	b.updated = now
+}
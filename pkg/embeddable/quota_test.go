@@ -0,0 +1,93 @@
+package embeddable
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+)
+
+func TestQuotaLimiterRate(t *testing.T) {
+	limiter, err := NewQuotaLimiter(Quota{Name: "openalex", Tools: []string{"search_*"}, Per: []string{QuotaPerSubject}, Rate: 2})
+	if err != nil {
+		t.Fatalf("NewQuotaLimiter: %v", err)
+	}
+
+	alice := WithAuthPrincipal(context.Background(), AuthPrincipal{Subject: "alice"})
+	bob := WithAuthPrincipal(context.Background(), AuthPrincipal{Subject: "bob"})
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		release, err := limiter.acquire(alice, "search_works", now)
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		release()
+	}
+	_, exceeded := limiter.acquire(alice, "search_works", now)
+	if exceeded == nil || exceeded.Quota != "openalex" || exceeded.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected the rate limit to be hit with a 500ms hint, got %v", exceeded)
+	}
+
+	// Other subjects and tools are not affected, and the bucket refills
+	if _, err := limiter.acquire(bob, "search_works", now); err != nil {
+		t.Fatalf("bob: %v", err)
+	}
+	if _, err := limiter.acquire(alice, "resolve_doi", now); err != nil {
+		t.Fatalf("resolve_doi: %v", err)
+	}
+	if _, err := limiter.acquire(alice, "search_works", now.Add(500*time.Millisecond)); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+
+	counters := limiter.Counters()
+	if len(counters) != 1 || counters[0].Allowed != 4 || counters[0].RateLimited != 1 || counters[0].Keys != 2 {
+		t.Fatalf("unexpected counters: %+v", counters)
+	}
+}
+
+func TestQuotaLimiterMiddleware(t *testing.T) {
+	limiter, err := NewQuotaLimiter(Quota{MaxConcurrent: 1})
+	if err != nil {
+		t.Fatalf("NewQuotaLimiter: %v", err)
+	}
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	handler := func(ctx context.Context, args map[string]interface{}) (*protocol.ToolResult, error) {
+		if args["block"] == true {
+			close(started)
+			<-finish
+		}
+		return protocol.NewToolResult(protocol.WithText("ok")), nil
+	}
+	// Applied twice, as for tools registered with WithTool
+	wrapped := limiter.Middleware()(limiter.Middleware()(handler))
+	ctx := withToolName(context.Background(), "get_citations")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if res, _ := wrapped(ctx, map[string]interface{}{"block": true}); res.IsError {
+			t.Errorf("first call rejected: %+v", res)
+		}
+	}()
+	<-started
+
+	res, err := wrapped(ctx, nil)
+	if err != nil || !res.IsError || !strings.Contains(res.Content[0].Text, "retry after 1s") {
+		t.Fatalf("expected a concurrency error with a retry hint, got %+v, %v", res, err)
+	}
+
+	close(finish)
+	<-done
+	if res, _ := wrapped(ctx, nil); res.IsError {
+		t.Fatalf("call after the first finished rejected: %+v", res)
+	}
+
+	counters := limiter.Counters()
+	if counters[0].Allowed != 2 || counters[0].ConcurrencyLimited != 1 || counters[0].Running != 0 {
+		t.Fatalf("unexpected counters: %+v", counters)
+	}
+}
@@ -2,10 +2,14 @@ package embeddable
 
 import (
 	"context"
+	"encoding"
 	"encoding/json"
 	"fmt"
+	"path"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
 	"github.com/go-go-golems/go-go-mcp/pkg/tools"
@@ -87,7 +91,7 @@ func RegisterStructTool(config *ServerConfig, name string, obj interface{}, meth
 		if methodType.NumIn() == 2 {
 			// Convert arguments to struct
 			argsType := methodType.In(1)
-			argsValue, err := convertArgumentsToStruct(arguments, argsType)
+			argsValue, err := bindArguments(arguments, argsType)
 			if err != nil {
 				return protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("Invalid arguments: %v", err))), nil
 			}
@@ -191,7 +195,7 @@ func RegisterFunctionTool(config *ServerConfig, name string, fn interface{}) err
 		if fnType.NumIn() == 2 {
 			// Convert arguments to struct
 			argsType := fnType.In(1)
-			argsValue, err := convertArgumentsToStruct(arguments, argsType)
+			argsValue, err := bindArguments(arguments, argsType)
 			if err != nil {
 				return protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("Invalid arguments: %v", err))), nil
 			}
@@ -226,9 +230,53 @@ func RegisterFunctionTool(config *ServerConfig, name string, fn interface{}) err
 	return nil
 }
 
-// generateSchemaFromType generates a JSON schema from a Go type
+// SchemaFromStruct generates the JSON schema of the arguments struct v, or of
+// the struct v points to, following how encoding/json unmarshals it.
+//
+// Fields are named and made optional by their json tag: fields without
+// omitempty are required, unless they are pointers. Fields are further
+// described by a `description:"..."` tag or a jsonschema tag of comma
+// separated keywords, for example
+// `jsonschema:"description=Output format,enum=json,enum=yaml,default=json"`.
+// Commas in values are escaped as \,. The supported keywords are title,
+// description, enum (repeated), default, format, pattern, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength,
+// minItems, maxItems, uniqueItems and required. On array fields, enum,
+// format, pattern and the number and length constraints apply to the items.
+//
+// time.Time maps to a date-time string, types implementing
+// encoding.TextUnmarshaler to strings and named struct types to definitions
+// in $defs, which allows recursive types.
+func SchemaFromStruct(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, fmt.Errorf("expected struct, got nil")
+	}
+	return generateSchemaFromType(reflect.TypeOf(v))
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaGenerator maps Go types to JSON schemas the way encoding/json
+// (un)marshals them. Named struct types other than the root are emitted once
+// under $defs and referenced, so that recursive types terminate.
+type schemaGenerator struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+	// nullable allows null for pointers, slices and maps, which
+	// encoding/json marshals as null when they are nil
+	nullable bool
+}
+
+// generateSchemaFromType generates the JSON schema of the arguments struct
+// t, as described in SchemaFromStruct
 func generateSchemaFromType(t reflect.Type) (map[string]interface{}, error) {
-	if t.Kind() == reflect.Ptr {
+	for t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
@@ -236,123 +284,391 @@ func generateSchemaFromType(t reflect.Type) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("expected struct type, got %s", t.Kind())
 	}
 
-	properties := make(map[string]interface{})
-	var required []string
+	g := &schemaGenerator{
+		defs:  map[string]interface{}{},
+		names: map[reflect.Type]string{},
+	}
+	return g.rootSchema(g.structSchema(t))
+}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
+// generateOutputSchema generates the schema of the values of type t returned
+// as structured content. Structured content is an object, so only structs
+// and maps have a schema, otherwise it returns nil.
+func generateOutputSchema(t reflect.Type) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
 
-		// Skip unexported fields
-		if !field.IsExported() {
-			continue
+	g := &schemaGenerator{
+		defs:     map[string]interface{}{},
+		names:    map[reflect.Type]string{},
+		nullable: true,
+	}
+	switch {
+	case t == timeType || t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType):
+		return nil, nil
+	case t.Kind() == reflect.Struct:
+		return g.rootSchema(g.structSchema(t))
+	case t.Kind() == reflect.Map:
+		values, err := g.typeSchema(t.Elem())
+		return g.rootSchema(map[string]interface{}{"type": "object", "additionalProperties": values}, err)
+	default:
+		return nil, nil
+	}
+}
+
+func (g *schemaGenerator) rootSchema(schema map[string]interface{}, err error) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema, nil
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) (map[string]interface{}, error) {
+	schema, err := g.valueSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	return g.allowNull(t, schema), nil
+}
+
+// allowNull makes schema accept null if values of type t can be null
+func (g *schemaGenerator) allowNull(t reflect.Type, schema map[string]interface{}) map[string]interface{} {
+	if !g.nullable {
+		return schema
+	}
+
+	//nolint:exhaustive
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			schema["enum"] = append(enum, nil)
 		}
+		switch typ := schema["type"].(type) {
+		case string:
+			schema["type"] = []interface{}{typ, "null"}
+		case nil:
+			if _, ok := schema["$ref"]; ok {
+				return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+			}
+		}
+	}
+	return schema
+}
 
-		// Get JSON tag
-		jsonTag := field.Tag.Get("json")
-		if jsonTag == "-" {
-			continue
+// valueSchema returns the schema of the non-null values of type t
+func (g *schemaGenerator) valueSchema(t reflect.Type) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return map[string]interface{}{"type": "string"}, nil
+	case t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType):
+		// The accepted JSON is up to the type
+		return map[string]interface{}{}, nil
+	}
+
+	//nolint:exhaustive
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		// Interfaces accept any value
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema := map[string]interface{}{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			schema["minItems"] = t.Len()
+			schema["maxItems"] = t.Len()
 		}
+		return schema, nil
+	case reflect.Map:
+		values, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.refSchema(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
 
-		fieldName := field.Name
-		if jsonTag != "" {
-			parts := strings.Split(jsonTag, ",")
-			if parts[0] != "" {
-				fieldName = parts[0]
-			}
-			// Check for omitempty
-			isOptional := false
-			for _, part := range parts[1:] {
-				if part == "omitempty" {
-					isOptional = true
-					break
-				}
-			}
-			if !isOptional {
-				required = append(required, fieldName)
-			}
-		} else {
-			// Field without json tag is required by default
-			required = append(required, fieldName)
-		}
-
-		// Get description from tag
-		description := field.Tag.Get("description")
-
-		// Convert Go type to JSON schema type
-		fieldSchema := make(map[string]interface{})
-		switch field.Type.Kind() {
-		case reflect.String:
-			fieldSchema["type"] = "string"
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			fieldSchema["type"] = "integer"
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			fieldSchema["type"] = "integer"
-		case reflect.Float32, reflect.Float64:
-			fieldSchema["type"] = "number"
-		case reflect.Bool:
-			fieldSchema["type"] = "boolean"
-		case reflect.Slice, reflect.Array:
-			fieldSchema["type"] = "array"
-		case reflect.Map, reflect.Struct:
-			fieldSchema["type"] = "object"
-		case reflect.Invalid:
-			fieldSchema["type"] = "null"
-		case reflect.Uintptr:
-			fieldSchema["type"] = "string" // Represent as string
-		case reflect.Complex64, reflect.Complex128:
-			fieldSchema["type"] = "string" // Represent complex numbers as strings
-		case reflect.Chan:
-			fieldSchema["type"] = "string" // Channels not supported, use string representation
-		case reflect.Func:
-			fieldSchema["type"] = "string" // Functions not supported, use string representation
-		case reflect.Interface:
-			fieldSchema["type"] = "object" // Interfaces as objects
-		case reflect.Ptr:
-			fieldSchema["type"] = "object" // Pointers as objects
-		case reflect.UnsafePointer:
-			fieldSchema["type"] = "string" // Unsafe pointers as strings
-		default:
-			fieldSchema["type"] = "string" // Default fallback
+// refSchema returns a reference to the definition of the named struct t,
+// generating it on first use
+func (g *schemaGenerator) refSchema(t reflect.Type) (map[string]interface{}, error) {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.defName(t)
+		g.names[t] = name
+		// Register the name before generating, so that recursive
+		// references resolve to it
+		g.defs[name] = true
+		schema, err := g.structSchema(t)
+		if err != nil {
+			return nil, err
 		}
+		g.defs[name] = schema
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + name}, nil
+}
 
-		if description != "" {
-			fieldSchema["description"] = description
+// defName names the definition of t, qualifying it with its package if
+// another type already uses its name
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	candidates := []string{t.Name()}
+	if pkg := t.PkgPath(); pkg != "" {
+		candidates = append(candidates, path.Base(pkg)+"."+t.Name())
+	}
+	for _, name := range candidates {
+		if _, ok := g.defs[name]; !ok {
+			return name
+		}
+	}
+	for i := 2; ; i++ {
+		name := fmt.Sprintf("%s%d", candidates[len(candidates)-1], i)
+		if _, ok := g.defs[name]; !ok {
+			return name
 		}
+	}
+}
 
-		properties[fieldName] = fieldSchema
+func (g *schemaGenerator) structSchema(t reflect.Type) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+	if err := g.addFields(t, properties, &required); err != nil {
+		return nil, err
 	}
 
 	schema := map[string]interface{}{
 		"type":       "object",
 		"properties": properties,
 	}
-
 	if len(required) > 0 {
 		schema["required"] = required
 	}
-
 	return schema, nil
 }
 
-// convertArgumentsToStruct converts a map of arguments to a struct
-func convertArgumentsToStruct(arguments map[string]interface{}, structType reflect.Type) (reflect.Value, error) {
-	if structType.Kind() == reflect.Ptr {
-		structType = structType.Elem()
+// addFields adds the fields of the struct t to properties. The fields of
+// untagged embedded structs are promoted, as encoding/json does.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, opts := "", ""
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ = strings.Cut(tag, ",")
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if err := g.addFields(fieldType, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := g.valueSchema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if hasTagOption(opts, "string") {
+			// Numbers and booleans tagged ",string" are quoted
+			switch schema["type"] {
+			case "integer", "number", "boolean":
+				schema = map[string]interface{}{"type": "string"}
+			}
+		}
+
+		isRequired := !hasTagOption(opts, "omitempty") && field.Type.Kind() != reflect.Ptr
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		if tag, ok := field.Tag.Lookup("jsonschema"); ok {
+			r, err := applySchemaTag(schema, tag)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			isRequired = isRequired || r
+		}
+
+		properties[name] = g.allowNull(field.Type, schema)
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+func hasTagOption(opts string, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
 	}
+	return false
+}
 
-	// Create new struct instance (we'll use JSON marshaling instead)
+// itemKeywords are the jsonschema tag keywords that apply to the items of
+// array fields
+var itemKeywords = map[string]bool{
+	"enum": true, "format": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true, "multipleOf": true,
+	"minLength": true, "maxLength": true,
+}
 
-	// Convert arguments to JSON and back to struct for proper type conversion
-	jsonBytes, err := json.Marshal(arguments)
-	if err != nil {
-		return reflect.Value{}, fmt.Errorf("failed to marshal arguments: %w", err)
+// applySchemaTag adds the keywords of a jsonschema struct tag to schema and
+// reports whether the tag marks the field as required
+func applySchemaTag(schema map[string]interface{}, tag string) (bool, error) {
+	required := false
+	for _, part := range splitSchemaTag(tag) {
+		key, value, hasValue := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if !hasValue && key != "required" && key != "uniqueItems" {
+			return false, fmt.Errorf("jsonschema keyword %q needs a value", key)
+		}
+
+		target := schema
+		if items, ok := schema["items"].(map[string]interface{}); ok && schema["type"] == "array" && itemKeywords[key] {
+			target = items
+		}
+
+		switch key {
+		case "required":
+			required = true
+		case "uniqueItems":
+			target[key] = true
+		case "title", "description", "format", "pattern":
+			target[key] = value
+		case "enum":
+			v, err := parseSchemaValue(target, value)
+			if err != nil {
+				return false, fmt.Errorf("invalid enum value %q: %w", value, err)
+			}
+			enum, _ := target["enum"].([]interface{})
+			target["enum"] = append(enum, v)
+		case "default":
+			v, err := parseSchemaValue(target, value)
+			if err != nil {
+				return false, fmt.Errorf("invalid default %q: %w", value, err)
+			}
+			target[key] = v
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s %q: %w", key, value, err)
+			}
+			target[key] = n
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return false, fmt.Errorf("invalid %s %q", key, value)
+			}
+			target[key] = n
+		default:
+			return false, fmt.Errorf("unknown jsonschema keyword %q", key)
+		}
 	}
+	return required, nil
+}
 
-	structPtr := reflect.New(structType).Interface()
-	err = json.Unmarshal(jsonBytes, structPtr)
-	if err != nil {
-		return reflect.Value{}, fmt.Errorf("failed to unmarshal arguments: %w", err)
+// splitSchemaTag splits a jsonschema tag at the commas that aren't escaped
+func splitSchemaTag(tag string) []string {
+	var parts []string
+	var sb strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			sb.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(tag[i])
+		}
 	}
+	return append(parts, sb.String())
+}
 
-	return reflect.ValueOf(structPtr).Elem(), nil
+// parseSchemaValue parses an enum or default value written in a struct tag
+// as the type of schema
+func parseSchemaValue(schema map[string]interface{}, value string) (interface{}, error) {
+	switch schema["type"] {
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "string":
+		return value, nil
+	default:
+		// Objects, arrays and untyped values are written as JSON
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// bindArguments converts arguments to a value of argsType, which is a struct
+// or a pointer to one
+func bindArguments(arguments map[string]interface{}, argsType reflect.Type) (reflect.Value, error) {
+	isPtr := argsType.Kind() == reflect.Ptr
+	if isPtr {
+		argsType = argsType.Elem()
+	}
+
+	target := reflect.New(argsType)
+	if err := NewArguments(arguments).BindArguments(target.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	if isPtr {
+		return target, nil
+	}
+	return target.Elem(), nil
 }
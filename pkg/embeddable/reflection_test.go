@@ -0,0 +1,107 @@
+package embeddable
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type reflectionNode struct {
+	Name     string            `json:"name"`
+	Children []*reflectionNode `json:"children,omitempty"`
+}
+
+type reflectionPaging struct {
+	Page int `json:"page,omitempty" jsonschema:"minimum=1,default=1"`
+}
+
+type reflectionArgs struct {
+	reflectionPaging
+	Query  string          `json:"query" description:"Search query"`
+	Format string          `json:"format,omitempty" jsonschema:"description=Output format\\, for display,enum=json,enum=yaml,default=json"`
+	Tags   []string        `json:"tags,omitempty" jsonschema:"enum=a,enum=b,uniqueItems"`
+	Since  *time.Time      `json:"since"`
+	Limit  *int            `json:"limit" jsonschema:"maximum=100"`
+	Tree   reflectionNode  `json:"tree"`
+	Labels map[string]bool `json:"labels,omitempty"`
+	Extra  interface{}     `json:"extra,omitempty"`
+	Secret string          `json:"-"`
+	hidden string
+}
+
+func TestGenerateSchemaFromType(t *testing.T) {
+	schema, err := SchemaFromStruct(&reflectionArgs{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct: %v", err)
+	}
+	got, _ := json.Marshal(schema)
+
+	want := `{
+		"type": "object",
+		"properties": {
+			"page": {"type": "integer", "minimum": 1, "default": 1},
+			"query": {"type": "string", "description": "Search query"},
+			"format": {"type": "string", "description": "Output format, for display", "enum": ["json", "yaml"], "default": "json"},
+			"tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}, "uniqueItems": true},
+			"since": {"type": "string", "format": "date-time"},
+			"limit": {"type": "integer", "maximum": 100},
+			"tree": {"$ref": "#/$defs/reflectionNode"},
+			"labels": {"type": "object", "additionalProperties": {"type": "boolean"}},
+			"extra": {}
+		},
+		"required": ["query", "tree"],
+		"$defs": {
+			"reflectionNode": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/reflectionNode"}}
+				},
+				"required": ["name"]
+			}
+		}
+	}`
+	var gotValue, wantValue interface{}
+	_ = json.Unmarshal(got, &gotValue)
+	_ = json.Unmarshal([]byte(want), &wantValue)
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Fatalf("unexpected schema:\n%s", got)
+	}
+
+	type badArgs struct {
+		Mode string `jsonschema:"color=red"`
+	}
+	if _, err := SchemaFromStruct(badArgs{}); err == nil {
+		t.Fatalf("expected an error for an unknown jsonschema keyword")
+	}
+}
+
+func TestBindArguments(t *testing.T) {
+	arguments := map[string]interface{}{
+		"page":  2.0,
+		"query": "go",
+		"since": "2024-05-01T10:00:00Z",
+		"tree":  map[string]interface{}{"name": "root", "children": []interface{}{map[string]interface{}{"name": "leaf"}}},
+	}
+
+	v, err := bindArguments(arguments, reflect.TypeOf(&reflectionArgs{}))
+	if err != nil {
+		t.Fatalf("bindArguments: %v", err)
+	}
+	args := v.Interface().(*reflectionArgs)
+	if args.Page != 2 || args.Query != "go" || args.Tree.Children[0].Name != "leaf" || args.Limit != nil {
+		t.Fatalf("unexpected arguments: %+v", args)
+	}
+	if !args.Since.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected since: %v", args.Since)
+	}
+
+	v, err = bindArguments(arguments, reflect.TypeOf(reflectionArgs{}))
+	if err != nil {
+		t.Fatalf("bindArguments: %v", err)
+	}
+	if v.Interface().(reflectionArgs).Query != "go" {
+		t.Fatalf("unexpected arguments: %+v", v.Interface())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-go-golems/go-go-mcp/pkg"
+	"github.com/go-go-golems/go-go-mcp/pkg/gateway"
 	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
 	"github.com/go-go-golems/go-go-mcp/pkg/session"
 	"github.com/go-go-golems/go-go-mcp/pkg/tools"
@@ -26,6 +27,15 @@ type ServerConfig struct {
 	// Tool registration
 	toolRegistry *tool_registry.Registry
 
+	// Annotations of tools registered with WithEnhancedTool, by tool name
+	toolAnnotations map[string]ToolAnnotations
+
+	// Prompt and resource providers exposed alongside tools
+	promptProviders   []pkg.PromptProvider
+	resourceProviders []pkg.ResourceProvider
+	// gateways have their tools synced into toolRegistry while the server runs
+	gateways []*gateway.Gateway
+
 	// Transport options
 	defaultTransport string
 	defaultPort      int
@@ -44,13 +54,41 @@ type ServerConfig struct {
 	commandCustomizers []CommandCustomizer
 
 	// Auth options (HTTP transports only)
-	authEnabled bool
-	authOptions AuthOptions
+	authEnabled  bool
+	authOptions  AuthOptions
+	toolPolicies []ToolPolicy
+
+	// Audit options
+	auditSinks        []AuditSink
+	auditRedactedKeys []string
+
+	// Approval options
+	approval      *ApprovalSettings
+	approvalQueue *ApprovalQueue
+	// stdioApprovals is set by the stdio transport to serve the queue when
+	// a call first waits on it
+	stdioApprovals *onDemandApprovals
+
+	// Quotas, also part of middleware
+	quotaLimiter *QuotaLimiter
 }
 
 // ToolMiddleware is a function that wraps a ToolHandler
 type ToolMiddleware func(next ToolHandler) ToolHandler
 
+type toolNameContextKey struct{}
+
+func withToolName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, toolNameContextKey{}, name)
+}
+
+// ToolNameFromContext returns the name of the tool being called, for use in
+// middleware
+func ToolNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(toolNameContextKey{}).(string)
+	return name, ok
+}
+
 // Hooks allows customization of server behavior
 type Hooks struct {
 	OnServerStart  func(ctx context.Context) error
@@ -71,6 +109,7 @@ func NewServerConfig() *ServerConfig {
 		Version:          "1.0.0",
 		Description:      "MCP Server",
 		toolRegistry:     tool_registry.NewRegistry(),
+		toolAnnotations:  map[string]ToolAnnotations{},
 		defaultTransport: "stdio",
 		defaultPort:      3000,
 		enableConfig:     false,
@@ -139,6 +178,8 @@ func WithTool(name string, handler ToolHandler, opts ...ToolOption) ServerOption
 
 		// Register the tool with handler
 		config.toolRegistry.RegisterToolWithHandler(tool, func(ctx context.Context, tool tools.Tool, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+			ctx = withToolName(ctx, name)
+
 			// Apply middleware
 			finalHandler := handler
 			for i := len(config.middleware) - 1; i >= 0; i-- {
@@ -176,6 +217,45 @@ func WithToolRegistry(registry *tool_registry.Registry) ServerOption {
 	}
 }
 
+// WithPromptProvider exposes the prompts of the given provider through the server.
+// Multiple providers can be registered; on name collisions the first provider wins.
+func WithPromptProvider(provider pkg.PromptProvider) ServerOption {
+	return func(config *ServerConfig) error {
+		if provider != nil {
+			config.promptProviders = append(config.promptProviders, provider)
+		}
+		return nil
+	}
+}
+
+// WithResourceProvider exposes the resources and resource templates of the given
+// provider through the server. On URI collisions the first provider wins.
+func WithResourceProvider(provider pkg.ResourceProvider) ServerOption {
+	return func(config *ServerConfig) error {
+		if provider != nil {
+			config.resourceProviders = append(config.resourceProviders, provider)
+		}
+		return nil
+	}
+}
+
+// WithGateway re-exposes the tools, prompts and resources of the upstream
+// servers aggregated by gw. When the server starts, the upstream tools are
+// synced into the tool registry, and synced again whenever an upstream
+// reports that its tools changed, until the server stops. Closing the
+// upstream connections is left to the caller.
+func WithGateway(gw *gateway.Gateway) ServerOption {
+	return func(config *ServerConfig) error {
+		if gw == nil {
+			return nil
+		}
+		config.gateways = append(config.gateways, gw)
+		config.promptProviders = append(config.promptProviders, gw)
+		config.resourceProviders = append(config.resourceProviders, gw)
+		return nil
+	}
+}
+
 // Advanced options
 func WithSessionStore(store session.SessionStore) ServerOption {
 	return func(config *ServerConfig) error {
@@ -311,6 +391,16 @@ func (c *ServerConfig) GetToolProvider() pkg.ToolProvider {
 	return c.toolRegistry
 }
 
+// GetPromptProviders returns the prompt providers registered on the server config
+func (c *ServerConfig) GetPromptProviders() []pkg.PromptProvider {
+	return c.promptProviders
+}
+
+// GetResourceProviders returns the resource providers registered on the server config
+func (c *ServerConfig) GetResourceProviders() []pkg.ResourceProvider {
+	return c.resourceProviders
+}
+
 // createToolFromConfig creates a tool from a ToolConfig
 func createToolFromConfig(name string, config *ToolConfig) (tools.Tool, error) {
 	// Convert schema to JSON
@@ -329,5 +419,13 @@ func createToolFromConfig(name string, config *ToolConfig) (tools.Tool, error) {
 		}
 	}
 
-	return tools.NewToolImpl(name, config.Description, json.RawMessage(schemaBytes))
+	tool, err := tools.NewToolImpl(name, config.Description, json.RawMessage(schemaBytes))
+	if err != nil {
+		return nil, err
+	}
+	if err := tool.SetOutputSchema(config.OutputSchema); err != nil {
+		return nil, err
+	}
+	tool.SetAnnotations(config.Annotations)
+	return tool, nil
 }
@@ -11,6 +11,11 @@ type ToolOption func(*ToolConfig) error
 type ToolConfig struct {
 	Description string
 	Schema      interface{} // Can be a struct, JSON schema string, or json.RawMessage
+	// OutputSchema describes the structured content returned by the tool, in
+	// the same forms as Schema
+	OutputSchema interface{}
+	// Annotations are hints about the tool's behavior sent to clients
+	Annotations *ToolAnnotations
 	Examples    []ToolExample
 }
 
@@ -36,6 +41,39 @@ func WithSchema(schema interface{}) ToolOption {
 	}
 }
 
+// WithSchemaFromStruct sets the schema to the one generated from the
+// arguments struct v with SchemaFromStruct. The handler can then decode its
+// arguments with Arguments.BindArguments.
+func WithSchemaFromStruct(v interface{}) ToolOption {
+	return func(config *ToolConfig) error {
+		schema, err := SchemaFromStruct(v)
+		if err != nil {
+			return err
+		}
+		config.Schema = schema
+		return nil
+	}
+}
+
+// WithOutputSchema declares the schema of the tool's structured content. The
+// handler must then return results built with protocol.WithStructuredContent,
+// which are validated against the schema.
+func WithOutputSchema(schema interface{}) ToolOption {
+	return func(config *ToolConfig) error {
+		config.OutputSchema = schema
+		return nil
+	}
+}
+
+// WithToolAnnotations sets the hints about the tool's behavior, such as
+// whether it is read-only or destructive
+func WithToolAnnotations(annotations ToolAnnotations) ToolOption {
+	return func(config *ToolConfig) error {
+		config.Annotations = &annotations
+		return nil
+	}
+}
+
 func WithExample(name, description string, args map[string]interface{}) ToolOption {
 	return func(config *ToolConfig) error {
 		config.Examples = append(config.Examples, ToolExample{
@@ -0,0 +1,88 @@
+package embeddable
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+)
+
+// TypedToolHandler handles a tool call with arguments decoded into In and
+// returns a result encoded from Out
+type TypedToolHandler[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+var toolResultType = reflect.TypeOf((*protocol.ToolResult)(nil))
+
+// WithTypedTool registers a tool with a typed handler. The input schema is
+// generated from In, which must be a struct or a pointer to one (see
+// SchemaFromStruct), and the arguments are decoded into an In before calling
+// the handler.
+//
+// If Out is a struct or a map, the output schema is generated from it and the
+// returned value is sent as structured content. Other values are sent as
+// text: strings as they are, everything else as JSON. A handler returning a
+// *protocol.ToolResult builds the result itself.
+//
+// Options are applied after the generated schemas, so WithSchema and
+// WithOutputSchema override them. The handler is wrapped in the server's
+// middleware and hooks like the handlers of WithTool.
+func WithTypedTool[In, Out any](name string, handler TypedToolHandler[In, Out], opts ...ToolOption) ServerOption {
+	return func(config *ServerConfig) error {
+		inType := reflect.TypeOf((*In)(nil)).Elem()
+		outType := reflect.TypeOf((*Out)(nil)).Elem()
+
+		inputSchema, err := generateSchemaFromType(inType)
+		if err != nil {
+			return fmt.Errorf("failed to generate input schema for tool %s: %w", name, err)
+		}
+		var outputSchema map[string]interface{}
+		if outType != toolResultType {
+			outputSchema, err = generateOutputSchema(outType)
+			if err != nil {
+				return fmt.Errorf("failed to generate output schema for tool %s: %w", name, err)
+			}
+		}
+
+		toolOpts := []ToolOption{WithSchema(inputSchema)}
+		if outputSchema != nil {
+			toolOpts = append(toolOpts, WithOutputSchema(outputSchema))
+		}
+		toolOpts = append(toolOpts, opts...)
+
+		toolHandler := func(ctx context.Context, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+			var in In
+			if err := NewArguments(arguments).BindArguments(&in); err != nil {
+				return protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("Invalid arguments: %v", err))), nil
+			}
+
+			out, err := handler(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return typedToolResult(out, outputSchema != nil), nil
+		}
+
+		return WithTool(name, toolHandler, toolOpts...)(config)
+	}
+}
+
+// typedToolResult converts the value returned by a typed handler to a tool
+// result
+func typedToolResult(out interface{}, structured bool) *protocol.ToolResult {
+	switch v := out.(type) {
+	case *protocol.ToolResult:
+		return v
+	case string:
+		return protocol.NewToolResult(protocol.WithText(v))
+	}
+
+	if structured {
+		return protocol.NewToolResult(protocol.WithStructuredContent(out))
+	}
+	content, err := protocol.NewJSONContent(out)
+	if err != nil {
+		return protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("failed to convert result to JSON: %v", err)))
+	}
+	return protocol.NewToolResult(protocol.WithContent(content))
+}
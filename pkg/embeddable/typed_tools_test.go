@@ -0,0 +1,83 @@
+package embeddable
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type forecastArgs struct {
+	City string `json:"city" jsonschema:"minLength=1"`
+	Days int    `json:"days,omitempty" jsonschema:"minimum=1,maximum=7,default=3"`
+}
+
+type forecastDay struct {
+	Day         int     `json:"day"`
+	Temperature float64 `json:"temperature"`
+}
+
+type forecast struct {
+	City string        `json:"city"`
+	Days []forecastDay `json:"days"`
+	Note *string       `json:"note"`
+}
+
+func TestWithTypedTool(t *testing.T) {
+	cfg := NewServerConfig()
+	handler := func(ctx context.Context, args forecastArgs) (forecast, error) {
+		if args.City == "Atlantis" {
+			return forecast{}, errors.New("unknown city")
+		}
+		ret := forecast{City: args.City}
+		for i := 1; i <= args.Days; i++ {
+			ret.Days = append(ret.Days, forecastDay{Day: i, Temperature: 20})
+		}
+		return ret, nil
+	}
+	if err := WithTypedTool("forecast", handler, WithDescription("Weather forecast"))(cfg); err != nil {
+		t.Fatalf("WithTypedTool: %v", err)
+	}
+
+	tools, _, err := cfg.toolRegistry.ListTools(context.Background(), "")
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("ListTools: %v %v", tools, err)
+	}
+	if tools[0].Description != "Weather forecast" || !strings.Contains(string(tools[0].InputSchema), `"default":3`) {
+		t.Fatalf("unexpected tool: %+v", tools[0])
+	}
+	if !strings.Contains(string(tools[0].OutputSchema), `"note":{"type":["string","null"]}`) {
+		t.Fatalf("unexpected output schema: %s", tools[0].OutputSchema)
+	}
+
+	res, err := cfg.toolRegistry.CallTool(context.Background(), "forecast", map[string]interface{}{"city": "Paris", "days": "2"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	out, ok := res.StructuredContent.(forecast)
+	if !ok || out.City != "Paris" || len(out.Days) != 2 || res.IsError {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res, err = cfg.toolRegistry.CallTool(context.Background(), "forecast", map[string]interface{}{"city": "", "days": 9})
+	if err != nil || !res.IsError || !strings.Contains(res.Content[0].Text, "days: must be <= 7") {
+		t.Fatalf("expected a validation error, got %+v %v", res, err)
+	}
+
+	if _, err := cfg.toolRegistry.CallTool(context.Background(), "forecast", map[string]interface{}{"city": "Atlantis"}); err == nil {
+		t.Fatalf("expected the handler error")
+	}
+
+	err = WithTypedTool("echo", func(ctx context.Context, args struct {
+		Text string `json:"text"`
+	}) (string, error) {
+		return args.Text, nil
+	})(cfg)
+	if err != nil {
+		t.Fatalf("WithTypedTool: %v", err)
+	}
+	res, err = cfg.toolRegistry.CallTool(context.Background(), "echo", map[string]interface{}{"text": "hi"})
+	if err != nil || res.StructuredContent != nil || res.Content[0].Text != "hi" {
+		t.Fatalf("unexpected result: %+v %v", res, err)
+	}
+}
@@ -0,0 +1,331 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Gateway aggregates several upstream MCP servers into a single tool, prompt
+// and resource provider.
+//
+// Tools and prompts are exposed with the prefix of their upstream. When two
+// upstreams expose the same name (after prefixing), or the same resource URI,
+// the upstream declared first wins and the other entry is skipped with a
+// warning.
+//
+// Tool and prompt names are resolved against the last ListTools and
+// ListPrompts. Subscribers to SubscribeToChanges are told when an upstream
+// reports that its lists changed, so that they can list them again.
+type Gateway struct {
+	upstreams []*Upstream
+
+	mu        sync.RWMutex
+	tools     map[string]route
+	prompts   map[string]route
+	resources map[string]*Upstream
+
+	// ChangeNotifier signals subscribers whenever an upstream reports changed
+	// tools, prompts or resources
+	pkg.ChangeNotifier
+}
+
+// route maps an exposed name to the upstream that serves it and the name
+// used by that upstream.
+type route struct {
+	upstream *Upstream
+	name     string
+}
+
+var _ pkg.ToolProvider = &Gateway{}
+var _ pkg.PromptProvider = &Gateway{}
+var _ pkg.ResourceProvider = &Gateway{}
+
+// NewGateway creates a gateway over already connected upstreams
+func NewGateway(upstreams ...*Upstream) *Gateway {
+	g := &Gateway{
+		upstreams: upstreams,
+		tools:     map[string]route{},
+		prompts:   map[string]route{},
+		resources: map[string]*Upstream{},
+	}
+	for _, upstream := range upstreams {
+		upstream.setOnListChanged(g.NotifyChanged)
+	}
+	return g
+}
+
+// ConnectAll connects to every configured upstream and returns a gateway over
+// them. Upstreams that fail to connect are logged and skipped, so that one
+// broken server doesn't take the others down.
+func ConnectAll(ctx context.Context, upstreams []config.Upstream) (*Gateway, error) {
+	seen := map[string]bool{}
+	var connected []*Upstream
+	for _, cfg := range upstreams {
+		if seen[cfg.Name] {
+			return nil, errors.Errorf("duplicate upstream name %s", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		upstream, err := Connect(ctx, cfg)
+		if err != nil {
+			log.Error().Err(err).Str("upstream", cfg.Name).Msg("Skipping upstream")
+			continue
+		}
+		connected = append(connected, upstream)
+	}
+
+	return NewGateway(connected...), nil
+}
+
+// Upstreams returns the connected upstreams
+func (g *Gateway) Upstreams() []*Upstream {
+	return g.upstreams
+}
+
+// Close closes the connections to all upstreams and the channels returned by
+// SubscribeToChanges.
+func (g *Gateway) Close(ctx context.Context) error {
+	g.CloseSubscribers()
+
+	var firstErr error
+	for _, upstream := range g.upstreams {
+		if err := upstream.Client.Close(ctx); err != nil {
+			log.Warn().Err(err).Str("upstream", upstream.Name).Msg("Failed to close upstream")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// ListTools implements pkg.ToolProvider interface
+func (g *Gateway) ListTools(ctx context.Context, _ string) ([]protocol.Tool, string, error) {
+	var ret []protocol.Tool
+	routes := map[string]route{}
+
+	for _, upstream := range g.upstreams {
+		if upstream.Client.ServerCapabilities().Tools == nil {
+			continue
+		}
+		tools, err := collect(ctx, upstream.Client.ListTools)
+		if err != nil {
+			log.Warn().Err(err).Str("upstream", upstream.Name).Msg("Failed to list upstream tools")
+			continue
+		}
+
+		for _, tool := range tools {
+			name := upstream.Prefix + tool.Name
+			if existing, ok := routes[name]; ok {
+				log.Warn().
+					Str("tool", name).
+					Str("upstream", upstream.Name).
+					Str("winner", existing.upstream.Name).
+					Msg("Skipping tool with colliding name")
+				continue
+			}
+			routes[name] = route{upstream: upstream, name: tool.Name}
+			tool.Name = name
+			ret = append(ret, tool)
+		}
+	}
+
+	g.mu.Lock()
+	g.tools = routes
+	g.mu.Unlock()
+
+	return ret, "", nil
+}
+
+// CallTool implements pkg.ToolProvider interface
+func (g *Gateway) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+	r, ok := g.lookup(name, g.toolRoutes)
+	if !ok {
+		return nil, pkg.ErrToolNotFound
+	}
+
+	return r.upstream.Client.CallTool(ctx, r.name, arguments)
+}
+
+// ListPrompts implements pkg.PromptProvider interface
+func (g *Gateway) ListPrompts(ctx context.Context, _ string) ([]protocol.Prompt, string, error) {
+	var ret []protocol.Prompt
+	routes := map[string]route{}
+
+	for _, upstream := range g.upstreams {
+		if upstream.Client.ServerCapabilities().Prompts == nil {
+			continue
+		}
+		prompts, err := collect(ctx, upstream.Client.ListPrompts)
+		if err != nil {
+			log.Warn().Err(err).Str("upstream", upstream.Name).Msg("Failed to list upstream prompts")
+			continue
+		}
+
+		for _, prompt := range prompts {
+			name := upstream.Prefix + prompt.Name
+			if existing, ok := routes[name]; ok {
+				log.Warn().
+					Str("prompt", name).
+					Str("upstream", upstream.Name).
+					Str("winner", existing.upstream.Name).
+					Msg("Skipping prompt with colliding name")
+				continue
+			}
+			routes[name] = route{upstream: upstream, name: prompt.Name}
+			prompt.Name = name
+			ret = append(ret, prompt)
+		}
+	}
+
+	g.mu.Lock()
+	g.prompts = routes
+	g.mu.Unlock()
+
+	return ret, "", nil
+}
+
+// GetPrompt implements pkg.PromptProvider interface
+func (g *Gateway) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*protocol.GetPromptResult, error) {
+	r, ok := g.lookup(name, g.promptRoutes)
+	if !ok {
+		return nil, pkg.ErrPromptNotFound
+	}
+
+	return r.upstream.Client.GetPrompt(ctx, r.name, arguments)
+}
+
+// ListResources implements pkg.ResourceProvider interface
+func (g *Gateway) ListResources(ctx context.Context, _ string) ([]protocol.Resource, string, error) {
+	var ret []protocol.Resource
+	routes := map[string]*Upstream{}
+
+	for _, upstream := range g.upstreams {
+		if upstream.Client.ServerCapabilities().Resources == nil {
+			continue
+		}
+		resources, err := collect(ctx, upstream.Client.ListResources)
+		if err != nil {
+			log.Warn().Err(err).Str("upstream", upstream.Name).Msg("Failed to list upstream resources")
+			continue
+		}
+
+		for _, resource := range resources {
+			if existing, ok := routes[resource.URI]; ok {
+				log.Warn().
+					Str("uri", resource.URI).
+					Str("upstream", upstream.Name).
+					Str("winner", existing.Name).
+					Msg("Skipping resource with colliding URI")
+				continue
+			}
+			routes[resource.URI] = upstream
+			ret = append(ret, resource)
+		}
+	}
+
+	g.mu.Lock()
+	g.resources = routes
+	g.mu.Unlock()
+
+	return ret, "", nil
+}
+
+// ReadResource implements pkg.ResourceProvider interface. URIs that were not
+// listed, such as those built from resource templates, are tried against
+// each upstream in order.
+func (g *Gateway) ReadResource(ctx context.Context, uri string) ([]protocol.ResourceContent, error) {
+	g.mu.RLock()
+	upstream, ok := g.resources[uri]
+	g.mu.RUnlock()
+
+	if ok {
+		content, err := upstream.Client.ReadResource(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		return []protocol.ResourceContent{*content}, nil
+	}
+
+	for _, upstream := range g.upstreams {
+		if upstream.Client.ServerCapabilities().Resources == nil {
+			continue
+		}
+		content, err := upstream.Client.ReadResource(ctx, uri)
+		if err == nil {
+			return []protocol.ResourceContent{*content}, nil
+		}
+	}
+
+	return nil, pkg.ErrResourceNotFound
+}
+
+// ListResourceTemplates implements pkg.ResourceProvider interface
+func (g *Gateway) ListResourceTemplates(ctx context.Context) ([]protocol.ResourceTemplate, error) {
+	ret := []protocol.ResourceTemplate{}
+	seen := map[string]bool{}
+
+	for _, upstream := range g.upstreams {
+		if upstream.Client.ServerCapabilities().Resources == nil {
+			continue
+		}
+		templates, err := collect(ctx, upstream.Client.ListResourceTemplates)
+		if err != nil {
+			log.Warn().Err(err).Str("upstream", upstream.Name).Msg("Failed to list upstream resource templates")
+			continue
+		}
+		for _, template := range templates {
+			if seen[template.URITemplate] {
+				continue
+			}
+			seen[template.URITemplate] = true
+			ret = append(ret, template)
+		}
+	}
+
+	return ret, nil
+}
+
+// SubscribeToResource implements pkg.ResourceProvider interface
+func (g *Gateway) SubscribeToResource(_ context.Context, _ string) (chan struct{}, func(), error) {
+	return nil, nil, pkg.ErrNotImplemented
+}
+
+func (g *Gateway) toolRoutes() map[string]route {
+	return g.tools
+}
+
+func (g *Gateway) promptRoutes() map[string]route {
+	return g.prompts
+}
+
+// lookup resolves name in the routes returned by routes
+func (g *Gateway) lookup(name string, routes func() map[string]route) (route, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	r, ok := routes()[name]
+	return r, ok
+}
+
+// collect retrieves all pages of a paginated list call
+func collect[T any](ctx context.Context, list func(ctx context.Context, cursor string) ([]T, string, error)) ([]T, error) {
+	var ret []T
+	cursor := ""
+	for {
+		page, next, err := list(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, page...)
+		if next == "" || next == cursor {
+			return ret, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// newUpstreamServer starts a minimal streamable HTTP MCP server exposing the
+// given tools. Calling a tool returns "<server>:<tool>".
+func newUpstreamServer(t *testing.T, server string, toolNames ...string) *httptest.Server {
+	t.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request protocol.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if len(request.ID) == 0 || string(request.ID) == "null" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		var result interface{}
+		switch request.Method {
+		case "initialize":
+			result = protocol.InitializeResult{
+				ProtocolVersion: "2024-11-05",
+				Capabilities:    protocol.ServerCapabilities{Tools: &protocol.ToolsCapability{}},
+				ServerInfo:      protocol.ServerInfo{Name: server},
+			}
+		case "tools/list":
+			tools := []protocol.Tool{}
+			for _, name := range toolNames {
+				tools = append(tools, protocol.Tool{Name: name, InputSchema: json.RawMessage(`{"type":"object"}`)})
+			}
+			result = map[string]interface{}{"tools": tools}
+		case "tools/call":
+			var params struct {
+				Name string `json:"name"`
+			}
+			require.NoError(t, json.Unmarshal(request.Params, &params))
+			result = protocol.NewToolResult(protocol.WithText(server + ":" + params.Name))
+		}
+
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Mcp-Session-Id", server)
+		require.NoError(t, json.NewEncoder(w).Encode(protocol.Response{JSONRPC: "2.0", ID: request.ID, Result: data}))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestGatewayPrefixesAndResolvesCollisions(t *testing.T) {
+	ctx := context.Background()
+	a := newUpstreamServer(t, "a", "echo")
+	b := newUpstreamServer(t, "b", "a_echo", "other")
+
+	gw, err := ConnectAll(ctx, []config.Upstream{
+		{Name: "a", Transport: "streamable_http", URL: a.URL},
+		{Name: "b", Transport: "streamable_http", URL: b.URL, NoPrefix: true},
+	})
+	require.NoError(t, err)
+	defer func() { _ = gw.Close(ctx) }()
+
+	tools, _, err := gw.ListTools(ctx, "")
+	require.NoError(t, err)
+	names := []string{}
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	require.Equal(t, []string{"a_echo", "other"}, names)
+
+	result, err := gw.CallTool(ctx, "a_echo", map[string]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, "a:echo", result.Content[0].Text)
+
+	result, err = gw.CallTool(ctx, "other", map[string]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, "b:other", result.Content[0].Text)
+
+	_, err = gw.CallTool(ctx, "missing", map[string]interface{}{})
+	require.Error(t, err)
+}
+
+func TestGatewayNotifiesListChanges(t *testing.T) {
+	upstream := &Upstream{Name: "a"}
+	gw := NewGateway(upstream)
+	changes, cleanup := gw.SubscribeToChanges()
+	defer cleanup()
+
+	upstream.handleNotification(&protocol.Response{Method: "notifications/message"})
+	select {
+	case <-changes:
+		t.Fatal("unexpected change for a log message")
+	default:
+	}
+
+	upstream.handleNotification(&protocol.Response{Method: "notifications/tools/list_changed"})
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("no change signalled for notifications/tools/list_changed")
+	}
+}
@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"context"
+	"regexp"
+	"sync"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/client"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var upstreamNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Upstream is a connected MCP server whose tools, prompts and resources are
+// re-exposed by the gateway. Tool and prompt names are exposed as Prefix+name.
+type Upstream struct {
+	Name   string
+	Prefix string
+	Client *client.Client
+
+	mu sync.Mutex
+	// onListChanged is called when the upstream's tools, prompts or
+	// resources change
+	onListChanged func()
+}
+
+// Connect creates the transport described by cfg and initializes an MCP
+// session with the upstream server.
+func Connect(ctx context.Context, cfg config.Upstream) (*Upstream, error) {
+	if !upstreamNameRegexp.MatchString(cfg.Name) {
+		return nil, errors.Errorf("invalid upstream name %q", cfg.Name)
+	}
+
+	logger := log.Logger.With().Str("upstream", cfg.Name).Logger()
+
+	var transport client.Transport
+	switch cfg.Transport {
+	case "command", "stdio", "":
+		if cfg.Command == "" {
+			return nil, errors.Errorf("upstream %s: command is required for command transport", cfg.Name)
+		}
+		t, err := client.NewCommandStdioTransportWithEnv(logger, cfg.Env, cfg.Command, cfg.Args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "upstream %s: failed to start command", cfg.Name)
+		}
+		transport = t
+	case "sse":
+		if cfg.URL == "" {
+			return nil, errors.Errorf("upstream %s: url is required for sse transport", cfg.Name)
+		}
+		transport = client.NewSSETransport(cfg.URL, logger)
+	case "streamable_http":
+		if cfg.URL == "" {
+			return nil, errors.Errorf("upstream %s: url is required for streamable_http transport", cfg.Name)
+		}
+		transport = client.NewStreamableHTTPTransport(cfg.URL, logger)
+	default:
+		return nil, errors.Errorf("upstream %s: invalid transport type %s", cfg.Name, cfg.Transport)
+	}
+
+	upstream := &Upstream{Name: cfg.Name}
+	c := client.NewClient(logger, transport, client.WithNotificationHandler(upstream.handleNotification))
+	if err := c.Initialize(ctx, protocol.ClientCapabilities{}); err != nil {
+		_ = c.Close(context.Background())
+		return nil, errors.Wrapf(err, "upstream %s: failed to initialize", cfg.Name)
+	}
+
+	prefix := cfg.Prefix
+	if prefix == "" && !cfg.NoPrefix {
+		prefix = cfg.Name + "_"
+	}
+	if cfg.NoPrefix {
+		prefix = ""
+	}
+
+	logger.Info().Str("transport", cfg.Transport).Str("prefix", prefix).Msg("Connected to upstream")
+
+	upstream.Prefix = prefix
+	upstream.Client = c
+	return upstream, nil
+}
+
+func (u *Upstream) handleNotification(notification *protocol.Response) {
+	switch notification.Method {
+	case "notifications/tools/list_changed",
+		"notifications/prompts/list_changed",
+		"notifications/resources/list_changed":
+		log.Debug().Str("upstream", u.Name).Str("method", notification.Method).Msg("Upstream list changed")
+		u.mu.Lock()
+		onListChanged := u.onListChanged
+		u.mu.Unlock()
+		if onListChanged != nil {
+			onListChanged()
+		}
+	}
+}
+
+func (u *Upstream) setOnListChanged(fn func()) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.onListChanged = fn
+}
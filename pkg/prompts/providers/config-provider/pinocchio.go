@@ -0,0 +1,434 @@
+package config_provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/alias"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/loaders"
+	"github.com/go-go-golems/glazed/pkg/helpers/templating"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// PinocchioMessage is a message of the conversation a pinocchio prompt
+// starts with, typically used for few-shot examples.
+type PinocchioMessage struct {
+	Role string `yaml:"role"`
+	Text string `yaml:"text"`
+}
+
+// PinocchioPromptDescription represents the YAML structure of pinocchio
+// prompt files. Keys pinocchio uses to configure its AI engine, such as
+// ai-chat or layers, are ignored.
+type PinocchioPromptDescription struct {
+	Name         string               `yaml:"name"`
+	Short        string               `yaml:"short"`
+	Long         string               `yaml:"long,omitempty"`
+	Flags        []*fields.Definition `yaml:"flags,omitempty"`
+	Arguments    []*fields.Definition `yaml:"arguments,omitempty"`
+	SystemPrompt string               `yaml:"system-prompt,omitempty"`
+	Messages     []PinocchioMessage   `yaml:"messages,omitempty"`
+	Prompt       string               `yaml:"prompt,omitempty"`
+}
+
+// PinocchioPrompt is the runtime representation of a pinocchio prompt. Its
+// system prompt, messages and prompt are go templates rendered with the
+// prompt's flags and arguments.
+type PinocchioPrompt struct {
+	*cmds.CommandDescription
+	SystemPrompt string
+	Messages     []PinocchioMessage
+	Prompt       string
+}
+
+var _ cmds.Command = &PinocchioPrompt{}
+
+// LoadPinocchioPromptFromYAML creates a new PinocchioPrompt from YAML data
+func LoadPinocchioPromptFromYAML(data []byte) (*PinocchioPrompt, error) {
+	var desc PinocchioPromptDescription
+	if err := yaml.Unmarshal(data, &desc); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal YAML")
+	}
+
+	if desc.Name == "" {
+		return nil, errors.New("pinocchio prompt has no name")
+	}
+	if desc.SystemPrompt == "" && desc.Prompt == "" && len(desc.Messages) == 0 {
+		return nil, errors.Errorf("pinocchio prompt %s has no system-prompt, prompt or messages", desc.Name)
+	}
+	for _, message := range desc.Messages {
+		switch message.Role {
+		case "system", "user", "assistant":
+		default:
+			return nil, errors.Errorf("pinocchio prompt %s has a message with unknown role %q", desc.Name, message.Role)
+		}
+	}
+
+	cmdDesc := cmds.NewCommandDescription(
+		desc.Name,
+		cmds.WithShort(desc.Short),
+		cmds.WithLong(desc.Long),
+		cmds.WithFlags(desc.Flags...),
+		cmds.WithArguments(desc.Arguments...),
+	)
+
+	return &PinocchioPrompt{
+		CommandDescription: cmdDesc,
+		SystemPrompt:       desc.SystemPrompt,
+		Messages:           desc.Messages,
+		Prompt:             desc.Prompt,
+	}, nil
+}
+
+// LoadPinocchioPrompt loads a pinocchio prompt from the file at path
+func LoadPinocchioPrompt(path string) (*PinocchioPrompt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read file %s", path)
+	}
+
+	return LoadPinocchioPromptFromYAML(data)
+}
+
+// parameters returns the flags and arguments of the prompt
+func (p *PinocchioPrompt) parameters() []*fields.Definition {
+	ret := p.Description().GetDefaultFlags().ToList()
+	return append(ret, p.Description().GetDefaultArguments().ToList()...)
+}
+
+// ToPrompt describes the prompt and its parameters as MCP prompt arguments.
+// Parameters listed in hidden are left out; they can only be set through
+// the defaults and overrides of the prompt's source.
+func (p *PinocchioPrompt) ToPrompt(hidden map[string]bool) protocol.Prompt {
+	desc := p.Description()
+	description := desc.Short
+	if desc.Long != "" {
+		description += "\n\n" + desc.Long
+	}
+
+	prompt := protocol.Prompt{
+		Name:        desc.Name,
+		Description: description,
+		Arguments:   []protocol.PromptArgument{},
+	}
+	for _, param := range p.parameters() {
+		if hidden[param.Name] {
+			continue
+		}
+		prompt.Arguments = append(prompt.Arguments, protocol.PromptArgument{
+			Name:        param.Name,
+			Description: argumentDescription(param),
+			Required:    param.Required,
+		})
+	}
+
+	return prompt
+}
+
+// argumentDescription is the help of param, with a hint on how to pass
+// values that are not plain strings.
+func argumentDescription(param *fields.Definition) string {
+	hint := ""
+	switch param.Type {
+	case fields.TypeStringList, fields.TypeChoiceList, fields.TypeIntegerList, fields.TypeFloatList,
+		fields.TypeStringListFromFile, fields.TypeStringListFromFiles, fields.TypeFileList:
+		hint = "comma-separated list or JSON array"
+	case fields.TypeObjectFromFile, fields.TypeObjectListFromFile:
+		hint = "JSON"
+	case fields.TypeKeyValue:
+		hint = "comma-separated key:value pairs or JSON object"
+	case fields.TypeChoice:
+		hint = "one of " + strings.Join(param.Choices, ", ")
+	}
+	if hint == "" {
+		return param.Help
+	}
+	if param.Help == "" {
+		return hint
+	}
+	return param.Help + " (" + hint + ")"
+}
+
+// Render parses arguments according to the prompt's parameters and renders
+// the system prompt, messages and prompt into a conversation. MCP prompt
+// messages are either from the user or the assistant, so the system prompt
+// and system messages are sent as user messages. defaults are applied before
+// and overrides after the arguments.
+func (p *PinocchioPrompt) Render(
+	arguments map[string]string,
+	defaults map[string]interface{},
+	overrides map[string]interface{},
+) ([]protocol.PromptMessage, error) {
+	data := map[string]interface{}{}
+	for _, param := range p.parameters() {
+		var value interface{}
+		if param.Default != nil {
+			value = *param.Default
+		}
+		if v, ok := defaults[param.Name]; ok {
+			value = v
+		}
+		if s, ok := arguments[param.Name]; ok {
+			v, err := parseArgument(param, s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid argument %s", param.Name)
+			}
+			value = v
+		}
+		if v, ok := overrides[param.Name]; ok {
+			value = v
+		}
+		if value == nil {
+			if param.Required {
+				return nil, errors.Errorf("missing required argument: %s", param.Name)
+			}
+			value = zeroValue(param.Type)
+		}
+		data[param.Name] = value
+	}
+
+	var messages []protocol.PromptMessage
+	add := func(role string, name string, text string) error {
+		rendered, err := renderTemplate(name, text, data)
+		if err != nil {
+			return err
+		}
+		if role == "system" {
+			role = "user"
+		}
+		messages = append(messages, protocol.NewPromptMessage(role, protocol.NewTextPromptContent(rendered)))
+		return nil
+	}
+
+	if p.SystemPrompt != "" {
+		if err := add("system", "system-prompt", p.SystemPrompt); err != nil {
+			return nil, err
+		}
+	}
+	for i, message := range p.Messages {
+		if err := add(message.Role, "message-"+strconv.Itoa(i), message.Text); err != nil {
+			return nil, err
+		}
+	}
+	if p.Prompt != "" {
+		if err := add("user", "prompt", p.Prompt); err != nil {
+			return nil, err
+		}
+	}
+
+	return messages, nil
+}
+
+// parseArgument converts the string value of a prompt argument to the type
+// of param. Lists are comma-separated or JSON arrays. Files are passed by
+// content and show up in templates with a Content field.
+func parseArgument(param *fields.Definition, s string) (interface{}, error) {
+	switch param.Type {
+	case fields.TypeInteger:
+		return strconv.Atoi(strings.TrimSpace(s))
+	case fields.TypeFloat:
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case fields.TypeBool:
+		return strconv.ParseBool(strings.TrimSpace(s))
+	case fields.TypeChoice:
+		if err := checkChoice(param, s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case fields.TypeStringList, fields.TypeStringListFromFile, fields.TypeStringListFromFiles:
+		return splitList(s), nil
+	case fields.TypeChoiceList:
+		list := splitList(s)
+		for _, v := range list {
+			if err := checkChoice(param, v); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case fields.TypeIntegerList:
+		list := splitList(s)
+		ret := make([]int, 0, len(list))
+		for _, v := range list {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, i)
+		}
+		return ret, nil
+	case fields.TypeFloatList:
+		list := splitList(s)
+		ret := make([]float64, 0, len(list))
+		for _, v := range list {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, f)
+		}
+		return ret, nil
+	case fields.TypeFile:
+		return fileContent(s), nil
+	case fields.TypeFileList:
+		// File contents are likely to contain commas, so only JSON arrays
+		// are split into several files.
+		var contents []string
+		if err := json.Unmarshal([]byte(s), &contents); err != nil {
+			contents = []string{s}
+		}
+		ret := make([]map[string]interface{}, 0, len(contents))
+		for _, content := range contents {
+			ret = append(ret, fileContent(content))
+		}
+		return ret, nil
+	case fields.TypeObjectFromFile:
+		var ret map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &ret); err != nil {
+			return nil, errors.Wrap(err, "expected a JSON object")
+		}
+		return ret, nil
+	case fields.TypeObjectListFromFile:
+		var ret []map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &ret); err != nil {
+			return nil, errors.Wrap(err, "expected a JSON array of objects")
+		}
+		return ret, nil
+	case fields.TypeKeyValue:
+		ret := map[string]string{}
+		if err := json.Unmarshal([]byte(s), &ret); err == nil {
+			return ret, nil
+		}
+		for _, pair := range splitList(s) {
+			key, value, ok := strings.Cut(pair, ":")
+			if !ok {
+				return nil, errors.Errorf("expected key:value, got %q", pair)
+			}
+			ret[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		return ret, nil
+	default:
+		return s, nil
+	}
+}
+
+func checkChoice(param *fields.Definition, s string) error {
+	for _, choice := range param.Choices {
+		if choice == s {
+			return nil
+		}
+	}
+	return errors.Errorf("%q is not one of %s", s, strings.Join(param.Choices, ", "))
+}
+
+func splitList(s string) []string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		var ret []string
+		if err := json.Unmarshal([]byte(s), &ret); err == nil {
+			return ret
+		}
+	}
+	if s == "" {
+		return []string{}
+	}
+	ret := strings.Split(s, ",")
+	for i, v := range ret {
+		ret[i] = strings.TrimSpace(v)
+	}
+	return ret
+}
+
+// fileContent mirrors the fields of glazed's file data that prompt
+// templates use. Files passed as prompt arguments have no path.
+func fileContent(content string) map[string]interface{} {
+	return map[string]interface{}{
+		"Path":    "",
+		"Content": content,
+	}
+}
+
+// zeroValue is the template value of parameters that were neither passed
+// nor have a default, so that templates can join or range over them.
+func zeroValue(type_ fields.Type) interface{} {
+	switch type_ {
+	case fields.TypeStringList, fields.TypeChoiceList, fields.TypeIntegerList, fields.TypeFloatList,
+		fields.TypeStringListFromFile, fields.TypeStringListFromFiles, fields.TypeFileList,
+		fields.TypeObjectListFromFile:
+		return []interface{}{}
+	case fields.TypeInteger:
+		return 0
+	case fields.TypeFloat:
+		return 0.0
+	case fields.TypeBool:
+		return false
+	case fields.TypeFile, fields.TypeObjectFromFile:
+		return nil
+	case fields.TypeKeyValue:
+		return map[string]string{}
+	default:
+		return ""
+	}
+}
+
+func renderTemplate(name string, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := templating.CreateTemplate(name).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s template", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "failed to execute %s template", name)
+	}
+
+	return buf.String(), nil
+}
+
+// PinocchioPromptLoader loads pinocchio prompts from the YAML files of a
+// prompt repository.
+type PinocchioPromptLoader struct{}
+
+var _ loaders.CommandLoader = &PinocchioPromptLoader{}
+
+func (l *PinocchioPromptLoader) LoadCommands(
+	fs_ fs.FS,
+	filePath string,
+	options []cmds.CommandDescriptionOption,
+	aliasOptions []alias.Option,
+) ([]cmds.Command, error) {
+	data, err := fs.ReadFile(fs_, filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read file %s", filePath)
+	}
+
+	prompt, err := LoadPinocchioPromptFromYAML(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load pinocchio prompt from file %s", filePath)
+	}
+
+	for _, opt := range options {
+		opt(prompt.CommandDescription)
+	}
+
+	return []cmds.Command{prompt}, nil
+}
+
+func (l *PinocchioPromptLoader) GetFileExtensions() []string {
+	return []string{".yaml", ".yml"}
+}
+
+func (l *PinocchioPromptLoader) GetName() string {
+	return "pinocchio"
+}
+
+func (l *PinocchioPromptLoader) IsFileSupported(f fs.FS, fileName string) bool {
+	return strings.HasSuffix(fileName, ".yaml") || strings.HasSuffix(fileName, ".yml")
+}
@@ -0,0 +1,165 @@
+package config_provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+const reviewPrompt = `name: review-diff
+short: Review a diff
+flags:
+  - name: diff
+    type: string
+    help: The diff to review
+    required: true
+  - name: focus
+    type: stringList
+    default: [correctness]
+  - name: strict
+    type: bool
+ai-chat:
+  ai-engine: gpt-4o
+system-prompt: |
+  Focus on {{ .focus | join ", " }}.{{ if .strict }} Be strict.{{ end }}
+messages:
+  - role: user
+    text: Review a + b
+  - role: assistant
+    text: Looks fine.
+prompt: |
+  Review {{ .diff }}
+`
+
+func TestPinocchioPromptRender(t *testing.T) {
+	prompt, err := LoadPinocchioPromptFromYAML([]byte(reviewPrompt))
+	require.NoError(t, err)
+
+	p := prompt.ToPrompt(nil)
+	require.Equal(t, "review-diff", p.Name)
+	require.Len(t, p.Arguments, 3)
+	require.Equal(t, "diff", p.Arguments[0].Name)
+	require.True(t, p.Arguments[0].Required)
+
+	messages, err := prompt.Render(map[string]string{
+		"diff":   "x - y",
+		"focus":  "style, naming",
+		"strict": "true",
+	}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	require.Equal(t, "user", messages[0].Role)
+	require.Equal(t, "Focus on style, naming. Be strict.\n", messages[0].Content.Text)
+	require.Equal(t, "assistant", messages[2].Role)
+	require.Equal(t, "Looks fine.", messages[2].Content.Text)
+	require.Equal(t, "Review x - y\n", messages[3].Content.Text)
+
+	// Defaults of the file and of the source apply to missing arguments
+	messages, err = prompt.Render(map[string]string{"diff": "x"}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Focus on correctness.\n", messages[0].Content.Text)
+
+	messages, err = prompt.Render(
+		map[string]string{"diff": "x", "focus": "style"},
+		map[string]interface{}{"strict": true},
+		map[string]interface{}{"focus": []interface{}{"security"}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "Focus on security. Be strict.\n", messages[0].Content.Text)
+
+	_, err = prompt.Render(map[string]string{}, nil, nil)
+	require.ErrorContains(t, err, "missing required argument: diff")
+
+	_, err = prompt.Render(map[string]string{"diff": "x", "strict": "maybe"}, nil, nil)
+	require.ErrorContains(t, err, "invalid argument strict")
+}
+
+func TestLoadPinocchioPromptErrors(t *testing.T) {
+	_, err := LoadPinocchioPromptFromYAML([]byte("name: empty\nshort: Nothing to render\n"))
+	require.Error(t, err)
+
+	_, err = LoadPinocchioPromptFromYAML([]byte("name: bad\nmessages:\n  - role: robot\n    text: hi\n"))
+	require.ErrorContains(t, err, "unknown role")
+}
+
+func TestConfigPromptProviderDirectories(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review-diff.yaml"), []byte(reviewPrompt), 0o644))
+
+	provider, err := NewConfigPromptProvider(&config.Config{
+		Profiles: map[string]*config.Profile{
+			"default": {
+				Prompts: &config.PromptSources{
+					Directories: []config.SourceConfig{{
+						Path: dir,
+						Overrides: config.LayerParameters{
+							schema.DefaultSlug: {"strict": true},
+						},
+						Blacklist: config.ParameterFilter{
+							schema.DefaultSlug: {"strict"},
+						},
+					}},
+				},
+			},
+		},
+	}, "default")
+	require.NoError(t, err)
+
+	prompts, _, err := provider.ListPrompts(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, prompts, 1)
+	require.Equal(t, "review-diff", prompts[0].Name)
+	require.Len(t, prompts[0].Arguments, 2)
+
+	result, err := provider.GetPrompt(context.Background(), "review-diff", map[string]string{
+		"diff":   "x",
+		"strict": "false",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Review a diff", result.Description)
+	require.Len(t, result.Messages, 4)
+	require.Equal(t, "Focus on correctness. Be strict.\n", result.Messages[0].Content.Text)
+}
+
+func TestConfigPromptProviderNestedDirectories(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "code", "review")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "review-diff.yaml"), []byte(reviewPrompt), 0o644))
+
+	provider, err := NewConfigPromptProvider(&config.Config{
+		Profiles: map[string]*config.Profile{
+			"default": {
+				Prompts: &config.PromptSources{
+					Directories: []config.SourceConfig{{Path: dir}},
+				},
+			},
+		},
+	}, "default")
+	require.NoError(t, err)
+
+	prompts, _, err := provider.ListPrompts(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, prompts, 1)
+	require.Equal(t, "review-diff", prompts[0].Name)
+
+	result, err := provider.GetPrompt(context.Background(), prompts[0].Name, map[string]string{"diff": "x"})
+	require.NoError(t, err)
+	require.Len(t, result.Messages, 4)
+	require.Equal(t, "Review x\n", result.Messages[3].Content.Text)
+}
+
+func TestGoGoMCPPinocchioRepository(t *testing.T) {
+	prompt, err := LoadPinocchioPrompt(filepath.Join("..", "..", "..", "..", "pinocchio", "go-go-mcp", "create-command.yaml"))
+	require.NoError(t, err)
+
+	messages, err := prompt.Render(map[string]string{"description": "List files"}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Contains(t, messages[1].Content.Text, "List files")
+}
@@ -4,76 +4,154 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/go-go-golems/clay/pkg/repositories"
 	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
 	"github.com/go-go-golems/glazed/pkg/help"
 	"github.com/go-go-golems/go-go-mcp/pkg"
 	"github.com/go-go-golems/go-go-mcp/pkg/config"
 	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
 )
 
-// ConfigPromptProvider implements pkg.PromptProvider interface
+// ConfigPromptProvider implements pkg.PromptProvider interface. Prompts are
+// pinocchio prompt files, loaded from the directories and files of a profile
+// and from its pinocchio repository.
 type ConfigPromptProvider struct {
-	repository     *repositories.Repository
-	pinocchioFiles map[string]*protocol.Prompt
+	repositories   []*promptRepository
+	pinocchioFiles map[string]*PinocchioPrompt
 	promptConfigs  map[string]*config.SourceConfig
+	watching       bool
+
+	// ChangeNotifier signals subscribers whenever the watcher adds or removes
+	// prompts
+	pkg.ChangeNotifier
 }
 
-func NewConfigPromptProvider(config_ *config.Config, profile string) (*ConfigPromptProvider, error) {
-	if _, ok := config_.Profiles[profile]; !ok {
-		return nil, errors.Errorf("profile %s not found", profile)
+// promptRepository is a directory of pinocchio prompts along with the
+// configuration that applies to all of them.
+type promptRepository struct {
+	*repositories.Repository
+	sourceConfig *config.SourceConfig
+}
+
+// prompts returns the prompts of the repository, including those in
+// subdirectories. They are advertised by their name, not by their path in the
+// repository.
+func (r *promptRepository) prompts() []*PinocchioPrompt {
+	var ret []*PinocchioPrompt
+	for _, cmd := range r.CollectCommands([]string{}, true) {
+		if prompt, ok := cmd.(*PinocchioPrompt); ok {
+			ret = append(ret, prompt)
+		}
 	}
+	return ret
+}
 
-	directories := []repositories.Directory{}
+type ConfigPromptProviderOption func(*ConfigPromptProvider) error
 
-	profileConfig := config_.Profiles[profile]
+var _ pkg.PromptProvider = &ConfigPromptProvider{}
 
-	// Load directories using Clay's repository system
-	for _, dir := range profileConfig.Prompts.Directories {
-		absPath, err := filepath.Abs(dir.Path)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get absolute path for %s", dir.Path)
-		}
+// WithWatch reloads prompts when the files in the prompt directories change
+func WithWatch(watch bool) ConfigPromptProviderOption {
+	return func(p *ConfigPromptProvider) error {
+		p.watching = watch
+		return nil
+	}
+}
 
-		directories = append(directories, repositories.Directory{
-			FS:            os.DirFS(absPath),
-			RootDirectory: ".",
-			Name:          dir.Path,
-		})
+func NewConfigPromptProvider(config_ *config.Config, profile string, options ...ConfigPromptProviderOption) (*ConfigPromptProvider, error) {
+	if _, ok := config_.Profiles[profile]; !ok {
+		return nil, errors.Errorf("profile %s not found", profile)
 	}
 
 	provider := &ConfigPromptProvider{
-		repository:     repositories.NewRepository(repositories.WithDirectories(directories...)),
-		pinocchioFiles: make(map[string]*protocol.Prompt),
+		pinocchioFiles: make(map[string]*PinocchioPrompt),
 		promptConfigs:  make(map[string]*config.SourceConfig),
 	}
 
+	for _, option := range options {
+		if err := option(provider); err != nil {
+			return nil, err
+		}
+	}
+
+	profileConfig := config_.Profiles[profile]
 	if profileConfig.Prompts == nil {
 		return provider, nil
 	}
 
+	// Prompt directories and the pinocchio repository are both loaded as
+	// pinocchio prompt repositories
+	sources := []*config.SourceConfig{}
+	for i := range profileConfig.Prompts.Directories {
+		sources = append(sources, &profileConfig.Prompts.Directories[i])
+	}
+	if pinocchio := profileConfig.Prompts.Pinocchio; pinocchio != nil {
+		if pinocchio.Path == "" {
+			return nil, errors.New("pinocchio prompts need a path to the prompt repository")
+		}
+		sources = append(sources, &pinocchio.SourceConfig)
+	}
+
 	helpSystem := help.NewHelpSystem()
-	// Load repository commands
-	if err := provider.repository.LoadCommands(helpSystem); err != nil {
-		return nil, errors.Wrap(err, "failed to load repository prompts")
+	for _, source := range sources {
+		absPath, err := filepath.Abs(source.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get absolute path for %s", source.Path)
+		}
+
+		repository := repositories.NewRepository(
+			repositories.WithDirectories(repositories.Directory{
+				FS:               os.DirFS(absPath),
+				RootDirectory:    ".",
+				RootDocDirectory: "doc",
+				WatchDirectory:   absPath,
+				Name:             source.Path,
+				SourcePrefix:     "file",
+			}),
+			repositories.WithCommandLoader(&PinocchioPromptLoader{}),
+			repositories.WithUpdateCallback(func(cmd cmds.Command) error {
+				log.Debug().Str("prompt", cmd.Description().Name).Msg("Prompt updated")
+				provider.NotifyChanged()
+				return nil
+			}),
+			repositories.WithRemoveCallback(func(cmd cmds.Command) error {
+				log.Debug().Str("prompt", cmd.Description().Name).Msg("Prompt removed")
+				provider.NotifyChanged()
+				return nil
+			}),
+		)
+		if err := repository.LoadCommands(helpSystem); err != nil {
+			return nil, errors.Wrapf(err, "failed to load prompts from %s", source.Path)
+		}
+
+		provider.repositories = append(provider.repositories, &promptRepository{
+			Repository:   repository,
+			sourceConfig: source,
+		})
 	}
 
 	// Load individual Pinocchio files
-	for _, file := range profileConfig.Prompts.Files {
+	for i := range profileConfig.Prompts.Files {
+		file := &profileConfig.Prompts.Files[i]
 		absPath, err := filepath.Abs(file.Path)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get absolute path for %s", file.Path)
 		}
 
-		prompt, err := loadPinocchioFile(absPath)
+		prompt, err := LoadPinocchioPrompt(absPath)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to load pinocchio file from %s", file.Path)
 		}
 
-		provider.pinocchioFiles[prompt.Name] = prompt
-		provider.promptConfigs[prompt.Name] = &file
+		name := prompt.Description().Name
+		provider.pinocchioFiles[name] = prompt
+		provider.promptConfigs[name] = file
 	}
 
 	return provider, nil
@@ -84,15 +162,21 @@ func (p *ConfigPromptProvider) ListPrompts(_ context.Context, cursor string) ([]
 	var prompts []protocol.Prompt
 
 	// Get prompts from repositories
-	repoCommands := p.repository.CollectCommands([]string{}, true)
-	for _, cmd := range repoCommands {
-		prompt := convertCommandToPrompt(cmd)
-		prompts = append(prompts, prompt)
+	for _, repository := range p.repositories {
+		for _, prompt := range repository.prompts() {
+			prompts = append(prompts, prompt.ToPrompt(hiddenParameters(prompt, repository.sourceConfig)))
+		}
 	}
 
 	// Add Pinocchio files
-	for _, prompt := range p.pinocchioFiles {
-		prompts = append(prompts, *prompt)
+	names := make([]string, 0, len(p.pinocchioFiles))
+	for name := range p.pinocchioFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prompt := p.pinocchioFiles[name]
+		prompts = append(prompts, prompt.ToPrompt(hiddenParameters(prompt, p.promptConfigs[name])))
 	}
 
 	// Handle cursor-based pagination if needed
@@ -108,126 +192,95 @@ func (p *ConfigPromptProvider) ListPrompts(_ context.Context, cursor string) ([]
 	return prompts, "", nil
 }
 
-// GetPrompt implements pkg.PromptProvider interface
-func (p *ConfigPromptProvider) GetPrompt(_ context.Context, name string, arguments map[string]string) (*protocol.PromptMessage, error) {
-	// Try repositories first
-	if cmd, ok := p.repository.GetCommand(name); ok {
-		return p.executeRepositoryPrompt(cmd, arguments)
+// GetPrompt implements pkg.PromptProvider interface. It renders the prompt
+// with the given arguments into the conversation it describes.
+func (p *ConfigPromptProvider) GetPrompt(_ context.Context, name string, arguments map[string]string) (*protocol.GetPromptResult, error) {
+	prompt, sourceConfig, ok := p.lookupPrompt(name)
+	if !ok {
+		return nil, pkg.ErrPromptNotFound
 	}
 
-	// Try Pinocchio files
-	if prompt, ok := p.pinocchioFiles[name]; ok {
-		return p.executePinocchioPrompt(prompt, arguments)
-	}
-
-	return nil, pkg.ErrPromptNotFound
-}
-
-func (p *ConfigPromptProvider) executeRepositoryPrompt(cmd cmds.Command, arguments map[string]string) (*protocol.PromptMessage, error) {
-	// Convert arguments and apply parameter configuration
-	if config, ok := p.promptConfigs[cmd.Description().Name]; ok {
-		args := make(map[string]interface{})
-		for k, v := range arguments {
+	// Arguments for hidden parameters are dropped, they are only set from
+	// the source configuration
+	hidden := hiddenParameters(prompt, sourceConfig)
+	args := make(map[string]string, len(arguments))
+	for k, v := range arguments {
+		if !hidden[k] {
 			args[k] = v
 		}
-		_ = config
-		for k, v := range args {
-			if str, ok := v.(string); ok {
-				arguments[k] = str
-			}
-		}
 	}
 
-	// Build message content from arguments
-	content := "Please help me with the following task:\n\n"
-	for key, value := range arguments {
-		content += key + ": " + value + "\n"
+	var defaults, overrides map[string]interface{}
+	if sourceConfig != nil {
+		defaults = sourceConfig.Defaults[schema.DefaultSlug]
+		overrides = sourceConfig.Overrides[schema.DefaultSlug]
+	}
+
+	messages, err := prompt.Render(args, defaults, overrides)
+	if err != nil {
+		return nil, err
 	}
 
-	return &protocol.PromptMessage{
-		Role: "user",
-		Content: protocol.PromptContent{
-			Type: "text",
-			Text: content,
-		},
-	}, nil
+	return protocol.NewGetPromptResult(prompt.Description().Short, messages...), nil
 }
 
-func (p *ConfigPromptProvider) executePinocchioPrompt(prompt *protocol.Prompt, arguments map[string]string) (*protocol.PromptMessage, error) {
-	// Validate required arguments
-	for _, arg := range prompt.Arguments {
-		if arg.Required {
-			if _, ok := arguments[arg.Name]; !ok {
-				return nil, errors.Errorf("missing required argument: %s", arg.Name)
-			}
-		}
+// lookupPrompt finds the prompt called name in the prompt files and
+// repositories, along with the configuration of its source.
+func (p *ConfigPromptProvider) lookupPrompt(name string) (*PinocchioPrompt, *config.SourceConfig, bool) {
+	if prompt, ok := p.pinocchioFiles[name]; ok {
+		return prompt, p.promptConfigs[name], true
 	}
 
-	// Apply parameter configuration
-	if config, ok := p.promptConfigs[prompt.Name]; ok {
-		args := make(map[string]interface{})
-		for k, v := range arguments {
-			args[k] = v
-		}
-		_ = config
-		for k, v := range args {
-			if str, ok := v.(string); ok {
-				arguments[k] = str
+	for _, repository := range p.repositories {
+		for _, prompt := range repository.prompts() {
+			if prompt.Description().Name == name {
+				return prompt, repository.sourceConfig, true
 			}
 		}
 	}
 
-	// Build message content from arguments
-	// XXX this doesn't really make sense, but whatever, placeholder
-	content := prompt.Description + "\n\n"
-	for key, value := range arguments {
-		content += key + ": " + value + "\n"
-	}
-
-	return &protocol.PromptMessage{
-		Role: "user",
-		Content: protocol.PromptContent{
-			Type: "text",
-			Text: content,
-		},
-	}, nil
+	return nil, nil, false
 }
 
-func loadPinocchioFile(path string) (*protocol.Prompt, error) {
-	// TODO: Implement Pinocchio file loading
-	// For now, return a simple prompt
-	return &protocol.Prompt{
-		Name:        filepath.Base(path),
-		Description: "Pinocchio prompt from " + path,
-		Arguments: []protocol.PromptArgument{
-			{
-				Name:        "context",
-				Description: "Additional context",
-				Required:    false,
-			},
-		},
-	}, nil
-}
+// hiddenParameters returns the parameters of the prompt that the source
+// configuration blacklists, or leaves out of its whitelist.
+func hiddenParameters(prompt *PinocchioPrompt, sourceConfig *config.SourceConfig) map[string]bool {
+	ret := map[string]bool{}
+	if sourceConfig == nil {
+		return ret
+	}
 
-func convertCommandToPrompt(cmd cmds.Command) protocol.Prompt {
-	prompt := protocol.Prompt{
-		Name:        cmd.Description().Name,
-		Description: cmd.Description().Short + "\n" + cmd.Description().Long,
-		Arguments:   make([]protocol.PromptArgument, 0),
+	for _, name := range sourceConfig.Blacklist[schema.DefaultSlug] {
+		ret[name] = true
+	}
+	if whitelist, ok := sourceConfig.Whitelist[schema.DefaultSlug]; ok {
+		allowed := map[string]bool{}
+		for _, name := range whitelist {
+			allowed[name] = true
+		}
+		for _, param := range prompt.parameters() {
+			if !allowed[param.Name] {
+				ret[param.Name] = true
+			}
+		}
 	}
 
-	flagsAndArguments := cmd.Description().GetDefaultFlags().ToList()
-	flagsAndArguments = append(flagsAndArguments, cmd.Description().GetDefaultArguments().ToList()...)
+	return ret
+}
 
-	// Convert parameters to arguments
-	for _, param := range flagsAndArguments {
-		arg := protocol.PromptArgument{
-			Name:        param.Name,
-			Description: param.Help,
-			Required:    param.Required,
-		}
-		prompt.Arguments = append(prompt.Arguments, arg)
+// Watch reloads the prompt repositories when their files change, until ctx
+// is done.
+func (p *ConfigPromptProvider) Watch(ctx context.Context) error {
+	if !p.watching || len(p.repositories) == 0 {
+		return nil
 	}
 
-	return prompt
+	g, gctx := errgroup.WithContext(ctx)
+	for _, repository := range p.repositories {
+		repository := repository
+		g.Go(func() error {
+			return repository.Watch(gctx)
+		})
+	}
+	return g.Wait()
 }
@@ -15,7 +15,7 @@ type Registry struct {
 	mu      sync.RWMutex
 	prompts map[string]protocol.Prompt
 	// handlers map prompt names to custom handlers for generating messages
-	handlers map[string]Handler
+	handlers map[string]ResultHandler
 }
 
 var _ pkg.PromptProvider = &Registry{}
@@ -23,11 +23,16 @@ var _ pkg.PromptProvider = &Registry{}
 // Handler is a function that generates a prompt message based on arguments
 type Handler func(prompt protocol.Prompt, arguments map[string]string) (*protocol.PromptMessage, error)
 
+// ResultHandler is a function that generates the messages of a prompt based
+// on arguments, for prompts made of several messages or with image and
+// resource content.
+type ResultHandler func(prompt protocol.Prompt, arguments map[string]string) (*protocol.GetPromptResult, error)
+
 // NewRegistry creates a new prompt registry
 func NewRegistry() *Registry {
 	return &Registry{
 		prompts:  make(map[string]protocol.Prompt),
-		handlers: make(map[string]Handler),
+		handlers: make(map[string]ResultHandler),
 	}
 }
 
@@ -38,8 +43,25 @@ func (r *Registry) RegisterPrompt(prompt protocol.Prompt) {
 	r.prompts[prompt.Name] = prompt
 }
 
-// RegisterPromptWithHandler adds a prompt with a custom handler to the registry
+// RegisterPromptWithHandler adds a prompt with a custom handler to the
+// registry. The message returned by the handler is the only message of the
+// prompt, described by the prompt's description.
 func (r *Registry) RegisterPromptWithHandler(prompt protocol.Prompt, handler Handler) {
+	r.RegisterPromptWithResultHandler(prompt, func(prompt protocol.Prompt, arguments map[string]string) (*protocol.GetPromptResult, error) {
+		msg, err := handler(prompt, arguments)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			return protocol.NewGetPromptResult(prompt.Description), nil
+		}
+		return protocol.NewGetPromptResult(prompt.Description, *msg), nil
+	})
+}
+
+// RegisterPromptWithResultHandler adds a prompt with a custom handler that
+// returns all messages of the prompt to the registry
+func (r *Registry) RegisterPromptWithResultHandler(prompt protocol.Prompt, handler ResultHandler) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.prompts[prompt.Name] = prompt
@@ -94,7 +116,7 @@ func (r *Registry) ListPrompts(_ context.Context, cursor string) ([]protocol.Pro
 }
 
 // GetPrompt implements PromptProvider interface
-func (r *Registry) GetPrompt(_ context.Context, name string, arguments map[string]string) (*protocol.PromptMessage, error) {
+func (r *Registry) GetPrompt(_ context.Context, name string, arguments map[string]string) (*protocol.GetPromptResult, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -123,11 +145,8 @@ func (r *Registry) GetPrompt(_ context.Context, name string, arguments map[strin
 		content += fmt.Sprintf("%s: %s\n", key, value)
 	}
 
-	return &protocol.PromptMessage{
-		Role: "user",
-		Content: protocol.PromptContent{
-			Type: "text",
-			Text: content,
-		},
-	}, nil
+	return protocol.NewGetPromptResult(
+		prompt.Description,
+		protocol.NewPromptMessage("user", protocol.NewTextPromptContent(content)),
+	), nil
 }
@@ -0,0 +1,47 @@
+package prompts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+)
+
+func TestRegistryHandlers(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterPromptWithHandler(protocol.Prompt{
+		Name:        "single",
+		Description: "A single message",
+	}, func(p protocol.Prompt, args map[string]string) (*protocol.PromptMessage, error) {
+		msg := protocol.NewPromptMessage("user", protocol.NewTextPromptContent("hello "+args["name"]))
+		return &msg, nil
+	})
+	r.RegisterPromptWithResultHandler(protocol.Prompt{
+		Name: "few-shot",
+	}, func(p protocol.Prompt, args map[string]string) (*protocol.GetPromptResult, error) {
+		return protocol.NewGetPromptResult("Few-shot",
+			protocol.NewPromptMessage("user", protocol.NewTextPromptContent("1+1")),
+			protocol.NewPromptMessage("assistant", protocol.NewTextPromptContent("2")),
+			protocol.NewPromptMessage("user", protocol.NewImagePromptContent("aGk=", "image/png")),
+		), nil
+	})
+
+	res, err := r.GetPrompt(context.Background(), "single", map[string]string{"name": "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Description != "A single message" || len(res.Messages) != 1 || res.Messages[0].Content.Text != "hello bob" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	res, err = r.GetPrompt(context.Background(), "few-shot", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(res.Messages))
+	}
+	if res.Messages[1].Role != "assistant" || res.Messages[2].Content.Type != "image" {
+		t.Errorf("unexpected messages: %+v", res.Messages)
+	}
+}
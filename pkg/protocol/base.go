@@ -19,6 +19,9 @@ type Response struct {
 	ID      json.RawMessage `json:"id"` // Can be a string or an int
 	Result  json.RawMessage `json:"result,omitempty"`
 	Error   *Error          `json:"error,omitempty"`
+	// Method and Params are only set on notifications received by a client
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
 }
 
 // BatchRequest represents an array of JSON-RPC 2.0 requests for batch processing
@@ -63,6 +66,15 @@ func (br BatchResponse) Validate() error {
 	return nil
 }
 
+// Standard JSON-RPC 2.0 error codes
+const (
+	ErrCodeParseError     = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternalError  = -32603
+)
+
 // Error represents a JSON-RPC 2.0 error.
 type Error struct {
 	Code    int             `json:"code"`
@@ -0,0 +1,22 @@
+package protocol
+
+import "encoding/json"
+
+// Elicitation actions a user can take in response to an elicitation request
+const (
+	ElicitationActionAccept  = "accept"
+	ElicitationActionDecline = "decline"
+	ElicitationActionCancel  = "cancel"
+)
+
+// ElicitationRequest represents a server request asking the user for structured input
+type ElicitationRequest struct {
+	Message         string          `json:"message"`
+	RequestedSchema json.RawMessage `json:"requestedSchema"`
+}
+
+// ElicitationResult represents the user's answer to an elicitation request
+type ElicitationResult struct {
+	Action  string         `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
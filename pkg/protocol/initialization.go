@@ -30,6 +30,7 @@ type ServerInfo struct {
 type ClientCapabilities struct {
 	Roots        *RootsCapability       `json:"roots,omitempty"`
 	Sampling     *SamplingCapability    `json:"sampling,omitempty"`
+	Elicitation  *ElicitationCapability `json:"elicitation,omitempty"`
 	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
@@ -50,6 +51,9 @@ type RootsCapability struct {
 // SamplingCapability describes LLM sampling capabilities
 type SamplingCapability struct{}
 
+// ElicitationCapability describes the ability to ask the user for input
+type ElicitationCapability struct{}
+
 // PromptsCapability describes prompt template capabilities
 type PromptsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
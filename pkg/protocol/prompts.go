@@ -44,11 +44,58 @@ type ListToolsResult struct {
 	NextCursor string `json:"nextCursor"`
 }
 
-type PromptResult struct {
-	Description string          `json:"description"`
+// GetPromptResult is the result of prompts/get: a conversation of user and
+// assistant messages, for example a system prompt followed by few-shot
+// examples and the actual request.
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
 	Messages    []PromptMessage `json:"messages"`
 }
 
 type ResourceResult struct {
 	Contents []ResourceContent `json:"contents"`
 }
+
+// NewGetPromptResult creates a new GetPromptResult with the given messages
+func NewGetPromptResult(description string, messages ...PromptMessage) *GetPromptResult {
+	if messages == nil {
+		messages = []PromptMessage{}
+	}
+	return &GetPromptResult{
+		Description: description,
+		Messages:    messages,
+	}
+}
+
+// NewPromptMessage creates a new PromptMessage with the given role and content
+func NewPromptMessage(role string, content PromptContent) PromptMessage {
+	return PromptMessage{
+		Role:    role,
+		Content: content,
+	}
+}
+
+// NewTextPromptContent creates a new PromptContent with text type
+func NewTextPromptContent(text string) PromptContent {
+	return PromptContent{
+		Type: "text",
+		Text: text,
+	}
+}
+
+// NewImagePromptContent creates a new PromptContent with base64-encoded image data
+func NewImagePromptContent(base64Data, mimeType string) PromptContent {
+	return PromptContent{
+		Type:     "image",
+		Data:     base64Data,
+		MimeType: mimeType,
+	}
+}
+
+// NewResourcePromptContent creates a new PromptContent embedding a resource
+func NewResourcePromptContent(resource *ResourceContent) PromptContent {
+	return PromptContent{
+		Type:     "resource",
+		Resource: resource,
+	}
+}
@@ -33,7 +33,11 @@ type CreateMessageRequest struct {
 	Messages         []Message        `json:"messages"`
 	ModelPreferences ModelPreferences `json:"modelPreferences,omitempty"`
 	SystemPrompt     string           `json:"systemPrompt,omitempty"`
+	IncludeContext   string           `json:"includeContext,omitempty"` // "none", "thisServer" or "allServers"
+	Temperature      float64          `json:"temperature,omitempty"`
 	MaxTokens        int              `json:"maxTokens,omitempty"`
+	StopSequences    []string         `json:"stopSequences,omitempty"`
+	Metadata         map[string]any   `json:"metadata,omitempty"`
 }
 
 // CreateMessageResponse represents the response to a create message request
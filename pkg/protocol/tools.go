@@ -3,6 +3,8 @@ package protocol
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+
 	"github.com/rs/zerolog/log"
 )
 
@@ -10,12 +12,56 @@ type Tool struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
 	InputSchema json.RawMessage `json:"inputSchema"`
+	// OutputSchema is the JSON schema of the tool's structured content
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+	// Annotations are hints about the tool's behavior, for clients deciding
+	// which calls to confirm
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations describes the behavior of a tool. Unset hints take the
+// defaults of the MCP specification: not read-only, destructive, not
+// idempotent and open-world.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty" yaml:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty" yaml:"read-only-hint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty" yaml:"destructive-hint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty" yaml:"idempotent-hint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty" yaml:"open-world-hint,omitempty"`
+}
+
+// String lists the annotations that are set, e.g.
+// `title="Delete file", readOnlyHint=false, destructiveHint=true`
+func (a *ToolAnnotations) String() string {
+	if a == nil {
+		return ""
+	}
+	var parts []string
+	if a.Title != "" {
+		parts = append(parts, fmt.Sprintf("title=%q", a.Title))
+	}
+	for _, hint := range []struct {
+		name  string
+		value *bool
+	}{
+		{"readOnlyHint", a.ReadOnlyHint},
+		{"destructiveHint", a.DestructiveHint},
+		{"idempotentHint", a.IdempotentHint},
+		{"openWorldHint", a.OpenWorldHint},
+	} {
+		if hint.value != nil {
+			parts = append(parts, fmt.Sprintf("%s=%t", hint.name, *hint.value))
+		}
+	}
+	return strings.Join(parts, ", ")
 }
 
 // ToolResult represents the result of a tool invocation
 type ToolResult struct {
 	Content []ToolContent `json:"content"`
-	IsError bool          `json:"isError"`
+	// StructuredContent is the typed result of tools with an output schema
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError"`
 }
 
 // ToolContent represents different types of content in a tool result
@@ -67,6 +113,22 @@ func WithJSON(data interface{}) ToolResultOption {
 	}
 }
 
+// WithStructuredContent sets the structured content of the ToolResult and,
+// for clients that don't support it, adds its JSON serialization as text
+// content. If marshaling fails, it adds an error message instead.
+func WithStructuredContent(data interface{}) ToolResultOption {
+	return func(tr *ToolResult) {
+		content, err := NewJSONContent(data)
+		if err != nil {
+			tr.Content = append(tr.Content, NewTextContent(fmt.Sprintf("Error marshaling JSON: %v", err)))
+			tr.IsError = true
+			return
+		}
+		tr.StructuredContent = data
+		tr.Content = append(tr.Content, content)
+	}
+}
+
 // WithImage adds an image content to the ToolResult
 func WithImage(base64Data, mimeType string) ToolResultOption {
 	return func(tr *ToolResult) {
@@ -26,6 +26,7 @@ type ProgressParams struct {
 	ProgressToken string  `json:"progressToken"`
 	Progress      float64 `json:"progress"`
 	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
 }
 
 // CompletionReference represents what is being completed
@@ -60,3 +61,8 @@ type Root struct {
 	URI  string `json:"uri"` // Must be a file:// URI
 	Name string `json:"name,omitempty"`
 }
+
+// ListRootsResult represents the response to a roots/list request
+type ListRootsResult struct {
+	Roots []Root `json:"roots"`
+}
@@ -11,8 +11,9 @@ type PromptProvider interface {
 	// ListPrompts returns a list of available prompts with optional pagination
 	ListPrompts(ctx context.Context, cursor string) ([]protocol.Prompt, string, error)
 
-	// GetPrompt retrieves a specific prompt with the given arguments
-	GetPrompt(ctx context.Context, name string, arguments map[string]string) (*protocol.PromptMessage, error)
+	// GetPrompt renders a specific prompt with the given arguments into its
+	// description and messages
+	GetPrompt(ctx context.Context, name string, arguments map[string]string) (*protocol.GetPromptResult, error)
 }
 
 // ResourceProvider defines the interface for serving resources
@@ -0,0 +1,331 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/base64"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-go-golems/go-go-mcp/pkg"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultMaxFileSize is the size cap applied to directories that don't set one.
+const DefaultMaxFileSize int64 = 1024 * 1024
+
+// FileTemplate is the resource template advertised for reading files by path.
+// It uses reserved expansion, as simple expansion doesn't match paths with
+// slashes.
+const FileTemplate = "file:///{+path}"
+
+// FileSystemResourceProvider implements pkg.ResourceProvider by exposing the
+// files of a set of directories as file:// resources
+type FileSystemResourceProvider struct {
+	directories []directory
+}
+
+type directory struct {
+	root    string
+	include []string
+	exclude []string
+	maxSize int64
+}
+
+type FileSystemResourceProviderOption func(*FileSystemResourceProvider) error
+
+var _ pkg.ResourceProvider = &FileSystemResourceProvider{}
+
+// WithDirectory exposes the files below dir.Path
+func WithDirectory(dir config.ResourceDirectory) FileSystemResourceProviderOption {
+	return func(p *FileSystemResourceProvider) error {
+		absPath, err := filepath.Abs(dir.Path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get absolute path for %s", dir.Path)
+		}
+		// Resolve symlinks so that reads can be checked against the real root
+		if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+			absPath = resolved
+		}
+		for _, pattern := range append(append([]string{}, dir.Include...), dir.Exclude...) {
+			if !doublestar.ValidatePattern(pattern) {
+				return errors.Errorf("invalid glob pattern %q for %s", pattern, dir.Path)
+			}
+		}
+
+		maxSize := dir.MaxSize
+		if maxSize <= 0 {
+			maxSize = DefaultMaxFileSize
+		}
+
+		p.directories = append(p.directories, directory{
+			root:    absPath,
+			include: dir.Include,
+			exclude: dir.Exclude,
+			maxSize: maxSize,
+		})
+		return nil
+	}
+}
+
+// WithConfig exposes the resource directories of the given profile
+func WithConfig(config_ *config.Config, profile string) FileSystemResourceProviderOption {
+	return func(p *FileSystemResourceProvider) error {
+		profileConfig, ok := config_.Profiles[profile]
+		if !ok {
+			return errors.Errorf("profile %s not found", profile)
+		}
+		if profileConfig.Resources == nil {
+			return nil
+		}
+
+		for _, dir := range profileConfig.Resources.Directories {
+			if err := WithDirectory(dir)(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// NewFileSystemResourceProvider creates a new FileSystemResourceProvider with the given options
+func NewFileSystemResourceProvider(options ...FileSystemResourceProviderOption) (*FileSystemResourceProvider, error) {
+	provider := &FileSystemResourceProvider{}
+
+	for _, option := range options {
+		if err := option(provider); err != nil {
+			return nil, err
+		}
+	}
+
+	return provider, nil
+}
+
+// ListResources implements pkg.ResourceProvider interface
+func (p *FileSystemResourceProvider) ListResources(_ context.Context, cursor string) ([]protocol.Resource, string, error) {
+	var resources []protocol.Resource
+	seen := map[string]bool{}
+
+	for _, dir := range p.directories {
+		err := filepath.WalkDir(dir.root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				log.Warn().Str("path", path).Err(err).Msg("Skipping unreadable resource path")
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir.root, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if d.IsDir() {
+				if rel != "." && dir.excluded(rel) {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || !dir.matches(rel) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil || info.Size() > dir.maxSize {
+				return nil
+			}
+
+			uri := pathToURI(path)
+			if seen[uri] {
+				return nil
+			}
+			seen[uri] = true
+
+			resources = append(resources, protocol.Resource{
+				URI:      uri,
+				Name:     rel,
+				MimeType: mime.TypeByExtension(filepath.Ext(path)),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to list resources in %s", dir.root)
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].URI < resources[j].URI
+	})
+
+	if cursor == "" {
+		return resources, "", nil
+	}
+
+	for i, r := range resources {
+		if r.URI == cursor {
+			return resources[i+1:], "", nil
+		}
+	}
+
+	return resources, "", nil
+}
+
+// ReadResource implements pkg.ResourceProvider interface
+func (p *FileSystemResourceProvider) ReadResource(_ context.Context, uri string) ([]protocol.ResourceContent, error) {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil, pkg.ErrResourceNotFound
+	}
+
+	// Resolve symlinks before checking the path, so that links cannot point
+	// outside of the configured directories
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, pkg.ErrResourceNotFound
+	}
+
+	dir, ok := p.directoryFor(path)
+	if !ok {
+		return nil, pkg.ErrResourceNotFound
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, pkg.ErrResourceNotFound
+	}
+	if info.Size() > dir.maxSize {
+		return nil, errors.Errorf("resource %s is larger than the %d bytes limit", uri, dir.maxSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read resource %s", uri)
+	}
+
+	content := protocol.ResourceContent{
+		URI:      uri,
+		MimeType: detectMimeType(path, data),
+	}
+	if isText(data) {
+		content.Text = string(data)
+	} else {
+		content.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return []protocol.ResourceContent{content}, nil
+}
+
+// ListResourceTemplates implements pkg.ResourceProvider interface
+func (p *FileSystemResourceProvider) ListResourceTemplates(_ context.Context) ([]protocol.ResourceTemplate, error) {
+	if len(p.directories) == 0 {
+		return []protocol.ResourceTemplate{}, nil
+	}
+
+	return []protocol.ResourceTemplate{{
+		URITemplate: FileTemplate,
+		Name:        "file",
+		Description: "Read a file from one of the configured resource directories by absolute path",
+	}}, nil
+}
+
+// SubscribeToResource implements pkg.ResourceProvider interface
+func (p *FileSystemResourceProvider) SubscribeToResource(_ context.Context, _ string) (chan struct{}, func(), error) {
+	return nil, nil, pkg.ErrNotImplemented
+}
+
+// directoryFor returns the configured directory that exposes path, if any.
+func (p *FileSystemResourceProvider) directoryFor(path string) (directory, bool) {
+	for _, dir := range p.directories {
+		rel, err := filepath.Rel(dir.root, path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if dir.matches(rel) && !dir.excludedParent(rel) {
+			return dir, true
+		}
+	}
+	return directory{}, false
+}
+
+// matches reports whether the file at rel is included and not excluded.
+func (d directory) matches(rel string) bool {
+	if d.excluded(rel) {
+		return false
+	}
+	if len(d.include) == 0 {
+		return true
+	}
+	for _, pattern := range d.include {
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (d directory) excluded(rel string) bool {
+	for _, pattern := range d.exclude {
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedParent reports whether one of the parent directories of rel is
+// excluded, mirroring the directories skipped while listing.
+func (d directory) excludedParent(rel string) bool {
+	parent := rel
+	for {
+		idx := strings.LastIndex(parent, "/")
+		if idx < 0 {
+			return false
+		}
+		parent = parent[:idx]
+		if d.excluded(parent) {
+			return true
+		}
+	}
+}
+
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" || (u.Host != "" && u.Host != "localhost") {
+		return "", errors.Errorf("unsupported resource URI %s", uri)
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path)), nil
+}
+
+func detectMimeType(path string, data []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+// isText reports whether data can be returned as text content rather than
+// a base64 blob.
+func isText(data []byte) bool {
+	return utf8.Valid(data) && !strings.ContainsRune(string(data), 0)
+}
@@ -0,0 +1,110 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-go-golems/go-go-mcp/pkg"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/stretchr/testify/require"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func TestFileSystemResourceProvider(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "README.md"), []byte("# Project"))
+	writeFile(t, filepath.Join(root, "docs", "guide.md"), []byte("guide"))
+	writeFile(t, filepath.Join(root, "docs", "logo.png"), []byte{0x89, 'P', 'N', 'G', 0x00, 0x01})
+	writeFile(t, filepath.Join(root, "node_modules", "dep", "README.md"), []byte("dep"))
+	writeFile(t, filepath.Join(root, "docs", "big.md"), make([]byte, 64))
+	outside := filepath.Join(t.TempDir(), "secret.md")
+	writeFile(t, outside, []byte("secret"))
+
+	provider, err := NewFileSystemResourceProvider(WithDirectory(config.ResourceDirectory{
+		Path:    root,
+		Include: []string{"**/*.md", "**/*.png"},
+		Exclude: []string{"node_modules"},
+		MaxSize: 32,
+	}))
+	require.NoError(t, err)
+
+	resources, _, err := provider.ListResources(ctx, "")
+	require.NoError(t, err)
+	names := []string{}
+	for _, r := range resources {
+		names = append(names, r.Name)
+	}
+	require.ElementsMatch(t, []string{"README.md", "docs/guide.md", "docs/logo.png"}, names)
+
+	var readmeURI, logoURI string
+	for _, r := range resources {
+		switch r.Name {
+		case "README.md":
+			readmeURI = r.URI
+		case "docs/logo.png":
+			logoURI = r.URI
+		}
+	}
+
+	contents, err := provider.ReadResource(ctx, readmeURI)
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	require.Equal(t, "# Project", contents[0].Text)
+	require.Empty(t, contents[0].Blob)
+
+	contents, err = provider.ReadResource(ctx, logoURI)
+	require.NoError(t, err)
+	require.Equal(t, "image/png", contents[0].MimeType)
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G', 0x00, 0x01}), contents[0].Blob)
+
+	_, err = provider.ReadResource(ctx, pathToURI(outside))
+	require.ErrorIs(t, err, pkg.ErrResourceNotFound)
+
+	_, err = provider.ReadResource(ctx, pathToURI(filepath.Join(root, "node_modules", "dep", "README.md")))
+	require.ErrorIs(t, err, pkg.ErrResourceNotFound)
+
+	_, err = provider.ReadResource(ctx, pathToURI(filepath.Join(root, "docs", "big.md")))
+	require.Error(t, err)
+
+	templates, err := provider.ListResourceTemplates(ctx)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	require.Equal(t, FileTemplate, templates[0].URITemplate)
+}
+
+func TestFileTemplateReadsUnlistedFiles(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	provider, err := NewFileSystemResourceProvider(WithDirectory(config.ResourceDirectory{Path: root}))
+	require.NoError(t, err)
+
+	resources, _, err := provider.ListResources(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, resources)
+
+	// Files added after the resources were listed are read through the template
+	path := filepath.Join(root, "docs", "notes", "a.txt")
+	writeFile(t, path, []byte("added later"))
+	resolved, err := filepath.EvalSymlinks(path)
+	require.NoError(t, err)
+	uri := pathToURI(resolved)
+
+	values := uritemplate.MustNew(FileTemplate).Match(uri)
+	require.NotNil(t, values, "%s does not match %s", uri, FileTemplate)
+	require.Equal(t, filepath.ToSlash(resolved)[1:], values.Get("path").String())
+
+	contents, err := provider.ReadResource(ctx, uri)
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	require.Equal(t, "added later", contents[0].Text)
+}
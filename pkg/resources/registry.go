@@ -16,6 +16,9 @@ type Registry struct {
 	handlers  map[string]Handler
 	// subscribers maps resource URIs to channels that receive update notifications
 	subscribers map[string][]chan struct{}
+	// ChangeNotifier signals subscribers whenever resources are registered or
+	// unregistered
+	pkg.ChangeNotifier
 }
 
 var _ pkg.ResourceProvider = &Registry{}
@@ -38,6 +41,7 @@ func (r *Registry) RegisterResource(resource protocol.Resource) {
 	defer r.mu.Unlock()
 	r.resources[resource.URI] = resource
 	r.notifySubscribers(resource.URI)
+	r.NotifyChanged()
 }
 
 // RegisterResourceWithHandler adds a resource with a custom handler
@@ -47,6 +51,7 @@ func (r *Registry) RegisterResourceWithHandler(resource protocol.Resource, handl
 	r.resources[resource.URI] = resource
 	r.handlers[resource.URI] = handler
 	r.notifySubscribers(resource.URI)
+	r.NotifyChanged()
 }
 
 // UnregisterResource removes a resource from the registry
@@ -56,6 +61,15 @@ func (r *Registry) UnregisterResource(uri string) {
 	delete(r.resources, uri)
 	delete(r.handlers, uri)
 	r.notifySubscribers(uri)
+	r.NotifyChanged()
+}
+
+// NotifyResourceChanged signals subscribers of uri that its content changed,
+// for resources whose handler output changes without re-registering them.
+func (r *Registry) NotifyResourceChanged(uri string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.notifySubscribers(uri)
 }
 
 // ListResources implements ResourceProvider interface
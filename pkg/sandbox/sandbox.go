@@ -0,0 +1,104 @@
+// Package sandbox runs commands with restricted access to the host: a
+// filesystem allow-list, no network, resource limits, a filtered environment
+// and a syscall blocklist. It is implemented with Linux user and network
+// namespaces, landlock and seccomp; on other systems and on kernels without
+// landlock, sandboxed commands fail with an error instead of running
+// unrestricted.
+package sandbox
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupported is returned when the sandbox can't be set up on this system
+var ErrUnsupported = errors.New("sandboxing is not supported on this system")
+
+// DefaultReadOnlyPaths are readable (and executable) in every sandbox so that
+// shells and the usual command line tools work. Missing paths are skipped.
+var DefaultReadOnlyPaths = []string{
+	"/bin",
+	"/sbin",
+	"/usr",
+	"/lib",
+	"/lib32",
+	"/lib64",
+	"/etc",
+	"/dev/random",
+	"/dev/urandom",
+}
+
+// DefaultWritablePaths are writable in every sandbox, along with a private
+// temporary directory passed in TMPDIR
+var DefaultWritablePaths = []string{
+	"/dev/null",
+	"/dev/zero",
+}
+
+// DefaultEnv is the environment allow-list used when Config.Env is empty
+var DefaultEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ"}
+
+// Config is the `sandbox:` block of a shell command
+type Config struct {
+	// AllowedDirs are readable and writable. The working directory of the
+	// command must be inside one of them, and defaults to the first one.
+	AllowedDirs []string `yaml:"allowed-dirs,omitempty"`
+	// ReadOnlyPaths are readable and executable, in addition to DefaultReadOnlyPaths
+	ReadOnlyPaths []string `yaml:"read-only-paths,omitempty"`
+	// Network keeps access to the host network. It is off by default.
+	Network bool `yaml:"network,omitempty"`
+	// CPUSeconds limits the CPU time of each process (RLIMIT_CPU)
+	CPUSeconds uint64 `yaml:"cpu-seconds,omitempty"`
+	// MemoryMB limits the address space of each process (RLIMIT_AS)
+	MemoryMB uint64 `yaml:"memory-mb,omitempty"`
+	// Timeout kills the command after the given wall clock time
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Env lists the variables of the server environment passed to the
+	// command. Variables declared by the command itself are always passed.
+	Env []string `yaml:"env,omitempty"`
+}
+
+// Validate checks that all paths are absolute and the timeout isn't negative
+func (c *Config) Validate() error {
+	for _, paths := range [][]string{c.AllowedDirs, c.ReadOnlyPaths} {
+		for _, p := range paths {
+			if !filepath.IsAbs(p) {
+				return errors.Errorf("sandbox path %q must be absolute", p)
+			}
+		}
+	}
+	if c.Timeout < 0 {
+		return errors.New("sandbox timeout must not be negative")
+	}
+	return nil
+}
+
+// FilterEnv returns the variables of env whose name is allow-listed by the
+// config or listed in keep.
+func (c *Config) FilterEnv(env []string, keep ...string) []string {
+	allowed := c.Env
+	if len(allowed) == 0 {
+		allowed = DefaultEnv
+	}
+
+	var ret []string
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if contains(allowed, name) || contains(keep, name) {
+			ret = append(ret, kv)
+		}
+	}
+	return ret
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
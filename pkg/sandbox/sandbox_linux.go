@@ -0,0 +1,218 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// helperEnv carries the helperConfig of a sandboxed command. A process
+	// started with it set is the sandbox helper.
+	helperEnv = "GO_GO_MCP_SANDBOX"
+	// helperExitCode is the exit code of a helper that failed to set up the
+	// sandbox, before the command ran.
+	helperExitCode = 125
+)
+
+// helperConfig is passed from Apply to the helper process
+type helperConfig struct {
+	ReadOnly   []string `json:"readOnly"`
+	Writable   []string `json:"writable"`
+	CPUSeconds uint64   `json:"cpuSeconds,omitempty"`
+	MemoryMB   uint64   `json:"memoryMB,omitempty"`
+}
+
+// helperEnabled is set by MaybeRunHelper. Without it, the re-executed binary
+// would run its own main instead of the command.
+var helperEnabled bool
+
+// MaybeRunHelper runs the sandbox helper if the current process was started
+// as one, and never returns in that case. Sandboxed commands are started by
+// re-executing the current binary, which restricts itself and then executes
+// the command, so binaries that run sandboxed commands must call it first
+// thing in main, before starting any other goroutine.
+func MaybeRunHelper() {
+	helperEnabled = true
+	data, ok := os.LookupEnv(helperEnv)
+	if !ok {
+		return
+	}
+	// landlock and seccomp apply to the calling thread, which then executes
+	// the command
+	runtime.LockOSThread()
+	err := runHelper(data, os.Args[1:])
+	_, _ = fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(helperExitCode)
+}
+
+// Check returns an error if sandboxed commands can't run on this system
+func Check() error {
+	if !helperEnabled {
+		return errors.New("sandbox helper is not enabled: sandbox.MaybeRunHelper must be called at the start of main")
+	}
+	_, err := landlockABI()
+	return err
+}
+
+// Apply configures cmd to run inside the sandbox. It must be called once cmd
+// is otherwise fully set up, as it replaces its path and arguments with a call
+// to the sandbox helper. extraReadOnly are made readable in addition to the
+// configured paths, e.g. the script run by the command.
+//
+// The command gets a private temporary directory in TMPDIR, which the
+// returned cleanup function removes once the command has exited.
+func (c *Config) Apply(cmd *exec.Cmd, extraReadOnly ...string) (func(), error) {
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if err := Check(); err != nil {
+		return nil, err
+	}
+
+	dir, err := c.workDir(cmd.Dir)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Dir = dir
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the sandbox helper executable")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "go-go-mcp-sandbox-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the sandbox temporary directory")
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(tmpDir)
+	}
+
+	data, err := json.Marshal(helperConfig{
+		ReadOnly:   existingPaths(DefaultReadOnlyPaths, c.ReadOnlyPaths, extraReadOnly, []string{cmd.Path}),
+		Writable:   existingPaths(DefaultWritablePaths, c.AllowedDirs, []string{tmpDir}),
+		CPUSeconds: c.CPUSeconds,
+		MemoryMB:   c.MemoryMB,
+	})
+	if err != nil {
+		cleanup()
+		return nil, errors.Wrap(err, "failed to marshal sandbox config")
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, "TMPDIR="+tmpDir, helperEnv+"="+string(data))
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+
+	if !c.Network {
+		// A new network namespace only has a loopback device, which is down.
+		// The user namespace allows creating it without privileges and maps
+		// the server's user to itself.
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+		cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	}
+
+	return cleanup, nil
+}
+
+// runHelper restricts the current process according to data and executes
+// args[0] with argv args[1:]. It only returns on error.
+func runHelper(data string, args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing command")
+	}
+	var cfg helperConfig
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		return errors.Wrap(err, "invalid sandbox config")
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, helperEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+
+	if cfg.CPUSeconds > 0 {
+		limit := &unix.Rlimit{Cur: cfg.CPUSeconds, Max: cfg.CPUSeconds}
+		if err := unix.Setrlimit(unix.RLIMIT_CPU, limit); err != nil {
+			return errors.Wrap(err, "failed to limit CPU time")
+		}
+	}
+	if cfg.MemoryMB > 0 {
+		limit := &unix.Rlimit{Cur: cfg.MemoryMB << 20, Max: cfg.MemoryMB << 20}
+		if err := unix.Setrlimit(unix.RLIMIT_AS, limit); err != nil {
+			return errors.Wrap(err, "failed to limit memory")
+		}
+	}
+
+	// Required by landlock and seccomp, and keeps setuid binaries from
+	// gaining privileges.
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return errors.Wrap(err, "failed to set no_new_privs")
+	}
+	if err := restrictFilesystem(cfg.ReadOnly, cfg.Writable); err != nil {
+		return err
+	}
+	if err := installSeccompFilter(); err != nil {
+		return err
+	}
+
+	return errors.Wrapf(unix.Exec(args[0], args[1:], env), "failed to execute %s", args[0])
+}
+
+// workDir returns the working directory for a command configured with dir,
+// checking that it is inside the allowed directories.
+func (c *Config) workDir(dir string) (string, error) {
+	if dir == "" {
+		if len(c.AllowedDirs) == 0 {
+			return "", nil
+		}
+		return c.AllowedDirs[0], nil
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve working directory %s", dir)
+	}
+	for _, allowed := range c.AllowedDirs {
+		if rel, err := filepath.Rel(allowed, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return abs, nil
+		}
+	}
+	return "", errors.Errorf("working directory %s is not inside the sandbox's allowed directories", dir)
+}
+
+// existingPaths returns the paths that exist
+func existingPaths(paths ...[]string) []string {
+	var ret []string
+	for _, ps := range paths {
+		for _, p := range ps {
+			if _, err := os.Stat(p); err == nil {
+				ret = append(ret, p)
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,104 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSandboxedProcess is run inside the sandbox by TestApply
+func TestSandboxedProcess(t *testing.T) {
+	if os.Getenv("SANDBOX_TEST_PROCESS") != "1" {
+		t.Skip("only runs inside the sandbox")
+	}
+
+	allowed, denied := os.Getenv("SANDBOX_ALLOWED"), os.Getenv("SANDBOX_DENIED")
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "out"), []byte("ok"), 0o600))
+	require.Error(t, os.WriteFile(filepath.Join(denied, "out"), []byte("ok"), 0o600))
+	_, err := os.ReadFile(filepath.Join(denied, "secret"))
+	require.Error(t, err)
+
+	_, err = net.Dial("tcp", os.Getenv("SANDBOX_LISTENER"))
+	require.Error(t, err)
+
+	require.Empty(t, os.Getenv("SANDBOX_SECRET"))
+
+	tmp, err := os.CreateTemp("", "sandbox-")
+	require.NoError(t, err)
+	require.NoError(t, tmp.Close())
+}
+
+func TestApply(t *testing.T) {
+	if err := Check(); err != nil {
+		t.Skip(err)
+	}
+
+	allowed, denied := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(denied, "secret"), []byte("secret"), 0o600))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	self, err := os.Executable()
+	require.NoError(t, err)
+	cmd := exec.Command(self, "-test.run=^TestSandboxedProcess$", "-test.v")
+	cfg := &Config{AllowedDirs: []string{allowed}}
+	keep := []string{"SANDBOX_TEST_PROCESS", "SANDBOX_ALLOWED", "SANDBOX_DENIED", "SANDBOX_LISTENER"}
+	cmd.Env = cfg.FilterEnv(append(os.Environ(),
+		"SANDBOX_TEST_PROCESS=1",
+		"SANDBOX_ALLOWED="+allowed,
+		"SANDBOX_DENIED="+denied,
+		"SANDBOX_LISTENER="+listener.Addr().String(),
+		"SANDBOX_SECRET=secret",
+	), keep...)
+	cleanup, err := cfg.Apply(cmd)
+	require.NoError(t, err)
+	tmpDir := ""
+	for _, kv := range cmd.Env {
+		if dir, ok := strings.CutPrefix(kv, "TMPDIR="); ok {
+			tmpDir = dir
+		}
+	}
+	require.DirExists(t, tmpDir)
+	defer func() {
+		cleanup()
+		require.NoDirExists(t, tmpDir)
+	}()
+
+	out, err := cmd.CombinedOutput()
+	if err != nil && strings.Contains(err.Error(), "operation not permitted") {
+		t.Skip("user namespaces are not available:", err)
+	}
+	require.NoError(t, err, string(out))
+	require.Contains(t, string(out), "--- PASS: TestSandboxedProcess")
+
+	data, err := os.ReadFile(filepath.Join(allowed, "out"))
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(data))
+}
+
+func TestWorkDir(t *testing.T) {
+	cfg := &Config{AllowedDirs: []string{"/srv/work"}}
+
+	dir, err := cfg.workDir("")
+	require.NoError(t, err)
+	require.Equal(t, "/srv/work", dir)
+
+	dir, err = cfg.workDir("/srv/work/repo")
+	require.NoError(t, err)
+	require.Equal(t, "/srv/work/repo", dir)
+
+	_, err = cfg.workDir("/srv/work/../other")
+	require.Error(t, err)
+	_, err = cfg.workDir("/srv/workspace")
+	require.Error(t, err)
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// MaybeRunHelper does nothing, as sandboxing is only implemented on Linux
+func MaybeRunHelper() {}
+
+// Check returns an error if sandboxed commands can't run on this system
+func Check() error {
+	return errors.Wrapf(ErrUnsupported, "sandboxed commands require Linux, not %s", runtime.GOOS)
+}
+
+// Apply returns an error, as sandboxing is only implemented on Linux
+func (c *Config) Apply(cmd *exec.Cmd, extraReadOnly ...string) (func(), error) {
+	return nil, Check()
+}
@@ -0,0 +1,50 @@
+package sandbox
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestMain lets TestApply re-execute the test binary as the sandbox helper
+func TestMain(m *testing.M) {
+	MaybeRunHelper()
+	os.Exit(m.Run())
+}
+
+func TestConfigFromYAML(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+allowed-dirs: [/srv/work]
+read-only-paths: [/opt/tools]
+cpu-seconds: 10
+memory-mb: 256
+timeout: 30s
+env: [PATH]
+`), &cfg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/srv/work"}, cfg.AllowedDirs)
+	require.Equal(t, []string{"/opt/tools"}, cfg.ReadOnlyPaths)
+	require.False(t, cfg.Network)
+	require.Equal(t, uint64(10), cfg.CPUSeconds)
+	require.Equal(t, uint64(256), cfg.MemoryMB)
+	require.Equal(t, 30*time.Second, cfg.Timeout)
+	require.NoError(t, cfg.Validate())
+
+	cfg.ReadOnlyPaths = []string{"relative/path"}
+	require.Error(t, cfg.Validate())
+}
+
+func TestFilterEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "AWS_SECRET_ACCESS_KEY=x", "HOME=/root", "TOOL_OPT=1"}
+
+	cfg := &Config{}
+	require.Equal(t, []string{"PATH=/usr/bin", "HOME=/root"}, cfg.FilterEnv(env))
+	require.Equal(t, []string{"PATH=/usr/bin", "HOME=/root", "TOOL_OPT=1"}, cfg.FilterEnv(env, "TOOL_OPT"))
+
+	cfg = &Config{Env: []string{"TOOL_OPT"}}
+	require.Equal(t, []string{"TOOL_OPT=1"}, cfg.FilterEnv(env))
+}
@@ -0,0 +1,15 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const (
+	seccompAuditArch = unix.AUDIT_ARCH_X86_64
+	// seccompMaxSyscall denies x32 syscalls, which share the x86_64 audit
+	// arch but have the 0x40000000 bit set.
+	seccompMaxSyscall = 0x40000000
+)
+
+var archDeniedSyscalls = []uintptr{
+	unix.SYS_IOPL,
+	unix.SYS_IOPERM,
+}
@@ -0,0 +1,10 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const (
+	seccompAuditArch  = unix.AUDIT_ARCH_AARCH64
+	seccompMaxSyscall = 0
+)
+
+var archDeniedSyscalls []uintptr
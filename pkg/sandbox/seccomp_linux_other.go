@@ -0,0 +1,11 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// Sandboxed commands fail on architectures without a seccomp filter
+const (
+	seccompAuditArch  = 0
+	seccompMaxSyscall = 0
+)
+
+var archDeniedSyscalls []uintptr
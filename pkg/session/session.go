@@ -1,8 +1,12 @@
 package session
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -17,8 +21,21 @@ type SessionState map[string]interface{}
 type Session struct {
 	ID    SessionID
 	State SessionState
-	mu    sync.RWMutex
-	// TODO: Add metadata like CreationTime, LastAccessTime
+	// CreatedAt is when the session was created
+	CreatedAt time.Time
+
+	mu           sync.RWMutex
+	lastAccessed time.Time
+	// transient holds the keys of State that are never persisted
+	transient map[string]bool
+	closers   map[string]func(*Session)
+	closed    bool
+	// onChange is set by stores that persist the state, and called with the
+	// key that changed
+	onChange func(s *Session, key string)
+	// persisted holds the JSON of the values as last read from or written to
+	// the store, so that reloading keeps the values that didn't change
+	persisted map[string]json.RawMessage
 }
 
 // contextKey is an unexported type for context keys defined in this package.
@@ -40,22 +57,65 @@ func GetSessionFromContext(ctx context.Context) (*Session, bool) {
 }
 
 func NewSession() *Session {
+	return newSessionWithID(SessionID(uuid.New().String()), time.Now())
+}
+
+func newSessionWithID(id SessionID, now time.Time) *Session {
 	return &Session{
-		ID:    SessionID(uuid.New().String()),
-		State: make(SessionState),
+		ID:           id,
+		State:        make(SessionState),
+		CreatedAt:    now,
+		lastAccessed: now,
 	}
 }
 
+// LastAccessedAt returns when the session was last retrieved from its store.
+func (s *Session) LastAccessedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastAccessed
+}
+
+func (s *Session) touch(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastAccessed = now
+}
+
 // SetData stores a key-value pair in the session's state.
 // It is thread-safe.
 func (s *Session) SetData(key string, value interface{}) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	if s.State == nil {
 		s.State = make(SessionState)
 	}
 	s.State[key] = value
+	delete(s.transient, key)
+	onChange := s.onChange
+	s.mu.Unlock()
 	log.Debug().Str("sessionID", string(s.ID)).Str("key", key).Msg("Set data in session")
+
+	if onChange != nil {
+		onChange(s, key)
+	}
+}
+
+// SetTransientData stores a key-value pair that is kept in memory only, even
+// if the store persists sessions. Use it for values such as open connections
+// that cannot outlive the process.
+// It is thread-safe.
+func (s *Session) SetTransientData(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.State == nil {
+		s.State = make(SessionState)
+	}
+	if s.transient == nil {
+		s.transient = make(map[string]bool)
+	}
+	s.State[key] = value
+	s.transient[key] = true
+	log.Debug().Str("sessionID", string(s.ID)).Str("key", key).Msg("Set transient data in session")
 }
 
 // GetData retrieves a value from the session's state by key.
@@ -80,10 +140,153 @@ func (s *Session) GetData(key string) (interface{}, bool) {
 // It is thread-safe.
 func (s *Session) DeleteData(key string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	if s.State == nil {
+		s.mu.Unlock()
 		return
 	}
 	delete(s.State, key)
+	transient := s.transient[key]
+	delete(s.transient, key)
+	onChange := s.onChange
+	s.mu.Unlock()
 	log.Debug().Str("sessionID", string(s.ID)).Str("key", key).Msg("Deleted data from session")
+
+	if onChange != nil && !transient {
+		onChange(s, key)
+	}
+}
+
+// OnClose registers fn to be called when the session is deleted from or
+// evicted by its store. Registering another function under the same key
+// replaces the previous one.
+// It is thread-safe.
+func (s *Session) OnClose(key string, fn func(*Session)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closers == nil {
+		s.closers = make(map[string]func(*Session))
+	}
+	s.closers[key] = fn
+}
+
+// close runs the close callbacks once, in key order.
+func (s *Session) close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	closers := s.closers
+	s.closers = nil
+	s.mu.Unlock()
+
+	keys := make([]string, 0, len(closers))
+	for key := range closers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		closers[key](s)
+	}
+	if len(keys) > 0 {
+		log.Debug().Str("sessionID", string(s.ID)).Int("callbacks", len(keys)).Msg("Closed session")
+	}
+}
+
+// reload replaces the persisted values of the state with the stored ones,
+// keeping the transient values and the values this process never stored.
+// Values whose stored JSON didn't change since they were last read or
+// written keep their type; the other ones are decoded as encoding/json does.
+func (s *Session) reload(stored map[string]json.RawMessage, createdAt, lastAccessed time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := make(SessionState, len(stored))
+	for key, data := range stored {
+		if value, ok := s.State[key]; ok && bytes.Equal(data, s.persisted[key]) {
+			state[key] = value
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			log.Warn().Str("sessionID", string(s.ID)).Str("key", key).Err(err).Msg("Skipping invalid session value")
+			continue
+		}
+		state[key] = value
+	}
+	for key, value := range s.State {
+		_, wasPersisted := s.persisted[key]
+		if s.transient[key] || !wasPersisted {
+			state[key] = value
+		}
+	}
+	s.State = state
+	s.persisted = stored
+	if !s.CreatedAt.Equal(createdAt) {
+		s.CreatedAt = createdAt
+	}
+	s.lastAccessed = lastAccessed
+}
+
+func (s *Session) isClosed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.closed
+}
+
+func (s *Session) setOnChange(fn func(*Session, string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// persistentValues returns the JSON encoding of the values of keys, or of
+// the whole state if keys is empty, leaving out transient values and values
+// that cannot be encoded. Keys missing from the result have been deleted.
+func (s *Session) persistentValues(keys ...string) map[string]json.RawMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(keys) == 0 {
+		for key := range s.State {
+			keys = append(keys, key)
+		}
+	}
+	values := make(map[string]json.RawMessage, len(keys))
+	for _, key := range keys {
+		value, ok := s.State[key]
+		if !ok || s.transient[key] {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			log.Warn().Str("sessionID", string(s.ID)).Str("key", key).Err(err).Msg("Session value cannot be encoded, it is only kept in this process")
+			continue
+		}
+		values[key] = data
+	}
+	return values
+}
+
+// setPersisted records the JSON of the values of keys, or of all values if
+// keys is empty, as written to the store.
+func (s *Session) setPersisted(values map[string]json.RawMessage, keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(keys) == 0 {
+		s.persisted = values
+		return
+	}
+	if s.persisted == nil {
+		s.persisted = make(map[string]json.RawMessage)
+	}
+	for _, key := range keys {
+		if data, ok := values[key]; ok {
+			s.persisted[key] = data
+		} else {
+			delete(s.persisted, key)
+		}
+	}
 }
@@ -0,0 +1,366 @@
+package session
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// SQLiteSessionStore keeps sessions in a SQLite database, so that they
+// survive server restarts and can be shared by several servers.
+//
+// State values are stored as JSON whenever they change. A session's state is
+// read from the database on every Get: values changed by another server come
+// back as the types encoding/json decodes into (float64, string,
+// []interface{}, map[string]interface{}, ...), the other ones keep their type.
+// SetData and DeleteData only write their key, so servers changing different
+// keys of a session don't overwrite each other; for the same key, the last
+// write wins. Values set with SetTransientData, values that cannot be
+// encoded, and close callbacks only live in the process that created them.
+// The last access time is written at most every minute, or every tenth of the
+// idle timeout if that is shorter.
+type SQLiteSessionStore struct {
+	db   *sql.DB
+	opts storeOptions
+
+	mu sync.Mutex
+	// sessions holds the sessions used by this process
+	sessions map[SessionID]*Session
+	// saveMu orders state writes, so that the last write has the latest state
+	saveMu sync.Mutex
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSQLiteSessionStore opens the database at path and creates the session
+// table if needed. When a TTL or idle timeout is set, expired sessions are
+// evicted in the background until Close is called.
+//
+// The database is opened in WAL mode, and waits for the locks of other
+// servers sharing it instead of failing right away.
+func NewSQLiteSessionStore(path string, opts ...StoreOption) (*SQLiteSessionStore, error) {
+	db, err := sql.Open("sqlite3", sqliteDSN(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open session database")
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS mcp_sessions (
+        id TEXT PRIMARY KEY,
+        state_json TEXT NOT NULL,
+        created_at INTEGER NOT NULL,
+        last_accessed_at INTEGER NOT NULL
+    );`); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "failed to create session table")
+	}
+
+	s := &SQLiteSessionStore{
+		db:       db,
+		opts:     newStoreOptions(opts),
+		sessions: make(map[SessionID]*Session),
+		stop:     make(chan struct{}),
+	}
+	if interval := s.opts.janitorInterval(); interval > 0 {
+		go runJanitor(interval, s.stop, s.EvictExpired)
+	}
+	return s, nil
+}
+
+// sqliteDSN adds the connection parameters of the store to path
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	// Transactions take the write lock right away, so that merging a value
+	// into the stored state waits for other writers instead of failing
+	return path + separator + "_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+}
+
+// Get retrieves a session by its ID. Its state is read from the database on
+// every call, so that it includes the changes made by other servers.
+func (s *SQLiteSessionStore) Get(sessionID SessionID) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getLocked(sessionID, time.Now())
+}
+
+func (s *SQLiteSessionStore) getLocked(sessionID SessionID, now time.Time) (*Session, bool) {
+	cached, isCached := s.sessions[sessionID]
+	session, err := s.load(sessionID, cached)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Str("sessionID", string(sessionID)).Msg("Failed to load session")
+			return nil, false
+		}
+		if isCached {
+			// Deleted or evicted by another server
+			delete(s.sessions, sessionID)
+			go cached.close()
+		}
+		return nil, false
+	}
+
+	lastAccessed := session.LastAccessedAt()
+	if s.opts.expired(session.CreatedAt, lastAccessed, now) {
+		delete(s.sessions, sessionID)
+		s.deleteRow(sessionID)
+		go session.close()
+		return nil, false
+	}
+
+	session.touch(now)
+	s.sessions[sessionID] = session
+	if now.Sub(lastAccessed) >= s.opts.accessWriteInterval() {
+		if _, err := s.db.Exec(`UPDATE mcp_sessions SET last_accessed_at = ? WHERE id = ?`, now.UnixMilli(), string(sessionID)); err != nil {
+			log.Error().Err(err).Str("sessionID", string(sessionID)).Msg("Failed to record session access")
+		}
+	}
+	return session, true
+}
+
+// load reads a session from the database. If this process already uses the
+// session, cached is refreshed and returned, keeping its transient values and
+// close callbacks.
+func (s *SQLiteSessionStore) load(sessionID SessionID, cached *Session) (*Session, error) {
+	var (
+		stateJSON               string
+		createdAt, lastAccessed int64
+	)
+	err := s.db.QueryRow(`SELECT state_json, created_at, last_accessed_at FROM mcp_sessions WHERE id = ?`, string(sessionID)).
+		Scan(&stateJSON, &createdAt, &lastAccessed)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(stateJSON), &state); err != nil {
+		return nil, errors.Wrap(err, "failed to decode session state")
+	}
+	session := cached
+	if session == nil {
+		session = newSessionWithID(sessionID, time.UnixMilli(createdAt))
+		session.setOnChange(s.saveKey)
+	}
+	session.reload(state, time.UnixMilli(createdAt), time.UnixMilli(lastAccessed))
+	return session, nil
+}
+
+// Create generates a new session with a unique ID and adds it to the store.
+func (s *SQLiteSessionStore) Create() *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createLocked(SessionID(uuid.NewString()), time.Now())
+}
+
+// GetOrCreate retrieves the session with the given ID, creating it if needed.
+func (s *SQLiteSessionStore) GetOrCreate(sessionID SessionID) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if session, ok := s.getLocked(sessionID, now); ok {
+		return session
+	}
+	return s.createLocked(sessionID, now)
+}
+
+func (s *SQLiteSessionStore) createLocked(sessionID SessionID, now time.Time) *Session {
+	// Keep the precision of the database, so that reloading doesn't change it
+	now = time.UnixMilli(now.UnixMilli())
+	session := newSessionWithID(sessionID, now)
+	session.setOnChange(s.saveKey)
+	s.sessions[sessionID] = session
+
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO mcp_sessions (id, state_json, created_at, last_accessed_at) VALUES (?, '{}', ?, ?)`,
+		string(sessionID), now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		log.Error().Err(err).Str("sessionID", string(sessionID)).Msg("Failed to store session")
+	}
+	return session
+}
+
+// Update replaces the stored state with the session's state. SetData and
+// DeleteData write the values they change automatically.
+func (s *SQLiteSessionStore) Update(session *Session) {
+	s.save(session)
+}
+
+func (s *SQLiteSessionStore) saveKey(session *Session, key string) {
+	s.save(session, key)
+}
+
+// save writes the values of keys, or the whole state if keys is empty, into
+// the stored state of session. The row is created again if another server
+// removed it in the meantime; sessions closed by this process are not
+// written back.
+func (s *SQLiteSessionStore) save(session *Session, keys ...string) {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	if session.isClosed() {
+		return
+	}
+	values := session.persistentValues(keys...)
+	if err := s.merge(session, values, keys); err != nil {
+		log.Error().Err(err).Str("sessionID", string(session.ID)).Msg("Failed to save session state")
+		return
+	}
+	session.setPersisted(values, keys...)
+}
+
+// merge writes values into the stored state of session, in a transaction so
+// that the changes other servers make to other keys are kept.
+func (s *SQLiteSessionStore) merge(session *Session, values map[string]json.RawMessage, keys []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	state := values
+	if len(keys) > 0 {
+		var stateJSON string
+		err := tx.QueryRow(`SELECT state_json FROM mcp_sessions WHERE id = ?`, string(session.ID)).Scan(&stateJSON)
+		state = make(map[string]json.RawMessage)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		default:
+			if err := json.Unmarshal([]byte(stateJSON), &state); err != nil {
+				return errors.Wrap(err, "failed to decode session state")
+			}
+		}
+		for _, key := range keys {
+			if data, ok := values[key]; ok {
+				state[key] = data
+			} else {
+				delete(state, key)
+			}
+		}
+	}
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode session state")
+	}
+	_, err = tx.Exec(`INSERT INTO mcp_sessions (id, state_json, created_at, last_accessed_at) VALUES (?, ?, ?, ?)
+        ON CONFLICT(id) DO UPDATE SET state_json = excluded.state_json`,
+		string(session.ID), string(stateJSON), session.CreatedAt.UnixMilli(), session.LastAccessedAt().UnixMilli())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes a session from the store and runs its close callbacks.
+func (s *SQLiteSessionStore) Delete(sessionID SessionID) {
+	s.mu.Lock()
+	session, ok := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.deleteRow(sessionID)
+	s.mu.Unlock()
+
+	if ok {
+		session.close()
+	}
+}
+
+func (s *SQLiteSessionStore) deleteRow(sessionID SessionID) {
+	if _, err := s.db.Exec(`DELETE FROM mcp_sessions WHERE id = ?`, string(sessionID)); err != nil {
+		log.Error().Err(err).Str("sessionID", string(sessionID)).Msg("Failed to delete session")
+	}
+}
+
+// EvictExpired removes the sessions that expired at now, runs the close
+// callbacks of those used by this process, and returns how many were evicted.
+func (s *SQLiteSessionStore) EvictExpired(now time.Time) int {
+	var (
+		conditions []string
+		params     []interface{}
+	)
+	if s.opts.ttl > 0 {
+		conditions = append(conditions, "created_at <= ?")
+		params = append(params, now.Add(-s.opts.ttl).UnixMilli())
+	}
+	if s.opts.idleTimeout > 0 {
+		conditions = append(conditions, "last_accessed_at <= ?")
+		params = append(params, now.Add(-s.opts.idleTimeout).UnixMilli())
+	}
+	if len(conditions) == 0 {
+		return 0
+	}
+	where := strings.Join(conditions, " OR ")
+
+	s.mu.Lock()
+	rows, err := s.db.Query(`SELECT id FROM mcp_sessions WHERE `+where, params...)
+	if err != nil {
+		s.mu.Unlock()
+		log.Error().Err(err).Msg("Failed to list expired sessions")
+		return 0
+	}
+	var ids []SessionID
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			log.Error().Err(err).Msg("Failed to read expired session")
+			continue
+		}
+		ids = append(ids, SessionID(id))
+	}
+	_ = rows.Close()
+
+	var expired []*Session
+	for _, id := range ids {
+		if session, ok := s.sessions[id]; ok {
+			expired = append(expired, session)
+			delete(s.sessions, id)
+		}
+		s.deleteRow(id)
+	}
+	s.mu.Unlock()
+
+	for _, session := range expired {
+		session.close()
+	}
+	return len(ids)
+}
+
+// Close stops the background eviction, runs the close callbacks of the
+// sessions used by this process and closes the database. The sessions stay
+// in the database.
+func (s *SQLiteSessionStore) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+
+		s.mu.Lock()
+		sessions := s.sessions
+		s.sessions = make(map[SessionID]*Session)
+		s.mu.Unlock()
+
+		for _, session := range sessions {
+			// Keep the stored state as it is, whatever the callbacks delete
+			session.setOnChange(nil)
+			session.close()
+		}
+		err = s.db.Close()
+	})
+	return err
+}
+
+// Ensure SQLiteSessionStore implements SessionStore and SessionBinder
+var (
+	_ SessionStore  = (*SQLiteSessionStore)(nil)
+	_ SessionBinder = (*SQLiteSessionStore)(nil)
+)
@@ -2,8 +2,10 @@ package session
 
 import (
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 // SessionStore defines the interface for managing sessions.
@@ -12,43 +14,172 @@ type SessionStore interface {
 	Create() *Session
 	Update(session *Session) // Note: For in-memory, Get returns a pointer, so Update might be implicit.
 	Delete(sessionID SessionID)
-	// TODO: Add cleanup mechanism for stale sessions
+}
+
+// SessionBinder is implemented by stores that can create sessions with a
+// given ID. Transports use it to bind sessions to the IDs their clients
+// present, so that persistent stores find them again after a restart.
+type SessionBinder interface {
+	// GetOrCreate returns the session with the given ID, creating it if it
+	// does not exist or has expired.
+	GetOrCreate(sessionID SessionID) *Session
+}
+
+// StoreOption configures the expiry of sessions in a store.
+type StoreOption func(*storeOptions)
+
+type storeOptions struct {
+	ttl         time.Duration
+	idleTimeout time.Duration
+}
+
+// WithTTL expires sessions ttl after they were created.
+func WithTTL(ttl time.Duration) StoreOption {
+	return func(o *storeOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithIdleTimeout expires sessions that have not been retrieved for timeout.
+func WithIdleTimeout(timeout time.Duration) StoreOption {
+	return func(o *storeOptions) {
+		o.idleTimeout = timeout
+	}
+}
+
+func newStoreOptions(opts []StoreOption) storeOptions {
+	var o storeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o storeOptions) expired(createdAt, lastAccessed, now time.Time) bool {
+	if o.ttl > 0 && now.Sub(createdAt) >= o.ttl {
+		return true
+	}
+	return o.idleTimeout > 0 && now.Sub(lastAccessed) >= o.idleTimeout
+}
+
+// janitorInterval is how often expired sessions are evicted, or 0 if sessions
+// never expire.
+func (o storeOptions) janitorInterval() time.Duration {
+	interval := o.ttl
+	if interval == 0 || (o.idleTimeout > 0 && o.idleTimeout < interval) {
+		interval = o.idleTimeout
+	}
+	interval /= 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval > 0 && interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// accessWriteInterval is how old the stored last access time of a session
+// may get before a persistent store writes it again, so that every call does
+// not write to the database.
+func (o storeOptions) accessWriteInterval() time.Duration {
+	interval := time.Minute
+	if o.idleTimeout > 0 && o.idleTimeout/10 < interval {
+		interval = o.idleTimeout / 10
+	}
+	return interval
+}
+
+// runJanitor calls evict every interval until stop is closed.
+func runJanitor(interval time.Duration, stop <-chan struct{}, evict func(now time.Time) int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if n := evict(now); n > 0 {
+				log.Debug().Int("count", n).Msg("Evicted expired sessions")
+			}
+		}
+	}
 }
 
 // InMemorySessionStore provides a thread-safe in-memory implementation of SessionStore.
 type InMemorySessionStore struct {
+	opts storeOptions
+
 	mu       sync.RWMutex
 	sessions map[SessionID]*Session
+
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
-// NewInMemorySessionStore creates a new in-memory session store.
-func NewInMemorySessionStore() *InMemorySessionStore {
-	return &InMemorySessionStore{
+// NewInMemorySessionStore creates a new in-memory session store. When a TTL
+// or idle timeout is set, expired sessions are evicted in the background
+// until Close is called.
+func NewInMemorySessionStore(opts ...StoreOption) *InMemorySessionStore {
+	s := &InMemorySessionStore{
+		opts:     newStoreOptions(opts),
 		sessions: make(map[SessionID]*Session),
+		stop:     make(chan struct{}),
+	}
+	if interval := s.opts.janitorInterval(); interval > 0 {
+		go runJanitor(interval, s.stop, s.EvictExpired)
 	}
+	return s
 }
 
 // Get retrieves a session by its ID.
 func (s *InMemorySessionStore) Get(sessionID SessionID) (*Session, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	session, ok := s.sessions[sessionID]
+	s.mu.Lock()
+	session, ok := s.getLocked(sessionID, time.Now())
+	s.mu.Unlock()
 	return session, ok
 }
 
+// getLocked returns the session if it exists and has not expired, and
+// records the access. Expired sessions are removed and returned as not found.
+func (s *InMemorySessionStore) getLocked(sessionID SessionID, now time.Time) (*Session, bool) {
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, false
+	}
+	if s.opts.expired(session.CreatedAt, session.LastAccessedAt(), now) {
+		delete(s.sessions, sessionID)
+		go session.close()
+		return nil, false
+	}
+	session.touch(now)
+	return session, true
+}
+
 // Create generates a new session with a unique ID and adds it to the store.
 func (s *InMemorySessionStore) Create() *Session {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	newSession := &Session{
-		ID:    SessionID(uuid.NewString()),
-		State: make(SessionState),
-	}
+	newSession := newSessionWithID(SessionID(uuid.NewString()), time.Now())
 	s.sessions[newSession.ID] = newSession
 	return newSession
 }
 
+// GetOrCreate retrieves the session with the given ID, creating it if needed.
+func (s *InMemorySessionStore) GetOrCreate(sessionID SessionID) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if session, ok := s.getLocked(sessionID, now); ok {
+		return session
+	}
+	newSession := newSessionWithID(sessionID, now)
+	s.sessions[sessionID] = newSession
+	return newSession
+}
+
 // Update stores the potentially modified session state.
 // For this in-memory store using pointers, changes to the retrieved session
 // are inherently reflected. This method ensures the session exists.
@@ -62,12 +193,56 @@ func (s *InMemorySessionStore) Update(session *Session) {
 	// Optionally, add logic here if sessions need explicit saving or validation
 }
 
-// Delete removes a session from the store.
+// Delete removes a session from the store and runs its close callbacks.
 func (s *InMemorySessionStore) Delete(sessionID SessionID) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
 	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+
+	if ok {
+		session.close()
+	}
 }
 
-// Ensure InMemorySessionStore implements SessionStore
-var _ SessionStore = (*InMemorySessionStore)(nil)
+// EvictExpired removes the sessions that expired at now, runs their close
+// callbacks, and returns how many were evicted.
+func (s *InMemorySessionStore) EvictExpired(now time.Time) int {
+	s.mu.Lock()
+	var expired []*Session
+	for id, session := range s.sessions {
+		if s.opts.expired(session.CreatedAt, session.LastAccessedAt(), now) {
+			delete(s.sessions, id)
+			expired = append(expired, session)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, session := range expired {
+		session.close()
+	}
+	return len(expired)
+}
+
+// Close stops the background eviction and closes all sessions.
+func (s *InMemorySessionStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+
+		s.mu.Lock()
+		sessions := s.sessions
+		s.sessions = make(map[SessionID]*Session)
+		s.mu.Unlock()
+
+		for _, session := range sessions {
+			session.close()
+		}
+	})
+	return nil
+}
+
+// Ensure InMemorySessionStore implements SessionStore and SessionBinder
+var (
+	_ SessionStore  = (*InMemorySessionStore)(nil)
+	_ SessionBinder = (*InMemorySessionStore)(nil)
+)
@@ -0,0 +1,184 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemorySessionStoreExpiry(t *testing.T) {
+	store := NewInMemorySessionStore(WithIdleTimeout(time.Hour))
+	defer func() { _ = store.Close() }()
+
+	s := store.GetOrCreate("client-1")
+	closed := make(chan SessionID, 1)
+	s.OnClose("db", func(s *Session) { closed <- s.ID })
+
+	require.Same(t, s, store.GetOrCreate("client-1"))
+	require.Equal(t, 0, store.EvictExpired(time.Now()))
+
+	require.Equal(t, 1, store.EvictExpired(time.Now().Add(2*time.Hour)))
+	require.Equal(t, SessionID("client-1"), <-closed)
+	_, ok := store.Get("client-1")
+	require.False(t, ok)
+}
+
+func TestSQLiteSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+
+	store, err := NewSQLiteSessionStore(path, WithTTL(time.Hour))
+	require.NoError(t, err)
+
+	s := store.GetOrCreate("mcp-session-1")
+	s.SetData("db_path", "/tmp/data.db")
+	s.SetData("limit", 10)
+	s.SetTransientData("connection", struct{}{})
+	closed := false
+	s.OnClose("db", func(*Session) { closed = true })
+	require.NoError(t, store.Close())
+	require.True(t, closed)
+
+	// A restarted server finds the persisted state
+	store, err = NewSQLiteSessionStore(path, WithTTL(time.Hour))
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	s, ok := store.Get("mcp-session-1")
+	require.True(t, ok)
+	require.Equal(t, "/tmp/data.db", s.State["db_path"])
+	require.Equal(t, float64(10), s.State["limit"])
+	_, ok = s.GetData("connection")
+	require.False(t, ok)
+
+	s.DeleteData("limit")
+	require.Equal(t, 0, store.EvictExpired(time.Now()))
+	require.Equal(t, 1, store.EvictExpired(time.Now().Add(2*time.Hour)))
+	_, ok = store.Get("mcp-session-1")
+	require.False(t, ok)
+}
+
+func TestSQLiteSessionStoreSharedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	a, err := NewSQLiteSessionStore(path, WithIdleTimeout(time.Hour))
+	require.NoError(t, err)
+	defer func() { _ = a.Close() }()
+	b, err := NewSQLiteSessionStore(path, WithIdleTimeout(time.Hour))
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+
+	sa := a.GetOrCreate("shared")
+	sa.SetData("step", "one")
+	sa.SetTransientData("connection", struct{}{})
+	sb, ok := b.Get("shared")
+	require.True(t, ok)
+	require.Equal(t, "one", sb.State["step"])
+
+	// Each server sees the writes of the other, and keeps its transient values
+	sb.SetData("step", "two")
+	sa, ok = a.Get("shared")
+	require.True(t, ok)
+	require.Equal(t, "two", sa.State["step"])
+	_, ok = sa.GetData("connection")
+	require.True(t, ok)
+
+	// A write after another server removed the row stores the state again
+	b.Delete("shared")
+	sa.SetData("step", "three")
+	sb, ok = b.Get("shared")
+	require.True(t, ok)
+	require.Equal(t, "three", sb.State["step"])
+
+	// A session deleted by another server is closed
+	closed := make(chan SessionID, 1)
+	sa.OnClose("db", func(s *Session) { closed <- s.ID })
+	b.Delete("shared")
+	_, ok = a.Get("shared")
+	require.False(t, ok)
+	require.Equal(t, SessionID("shared"), <-closed)
+}
+
+func TestSQLiteSessionStoreMergesKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	a, err := NewSQLiteSessionStore(path)
+	require.NoError(t, err)
+	defer func() { _ = a.Close() }()
+	b, err := NewSQLiteSessionStore(path)
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+
+	sa := a.GetOrCreate("shared")
+	sb, ok := b.Get("shared")
+	require.True(t, ok)
+
+	// Neither server has seen the other's key when writing its own
+	sa.SetData("from_a", "a")
+	sb.SetData("from_b", "b")
+	sb.DeleteData("missing")
+
+	sa, ok = a.Get("shared")
+	require.True(t, ok)
+	require.Equal(t, "a", sa.State["from_a"])
+	require.Equal(t, "b", sa.State["from_b"])
+}
+
+func TestSQLiteSessionStoreKeepsTypedValues(t *testing.T) {
+	type config struct {
+		Limit int
+	}
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	a, err := NewSQLiteSessionStore(path)
+	require.NoError(t, err)
+	defer func() { _ = a.Close() }()
+	b, err := NewSQLiteSessionStore(path)
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+
+	sa := a.GetOrCreate("shared")
+	sa.SetData("config", config{Limit: 10})
+	sa.SetData("channel", make(chan struct{}))
+
+	// Values that didn't change in the database keep their type, and values
+	// that cannot be stored stay in this process
+	sb, ok := b.Get("shared")
+	require.True(t, ok)
+	sb.SetData("step", "one")
+	sa, ok = a.Get("shared")
+	require.True(t, ok)
+	require.Equal(t, config{Limit: 10}, sa.State["config"])
+	require.IsType(t, make(chan struct{}), sa.State["channel"])
+	require.Equal(t, "one", sa.State["step"])
+
+	// Values changed by another server are decoded from JSON
+	sb.SetData("config", config{Limit: 20})
+	sa, ok = a.Get("shared")
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{"Limit": float64(20)}, sa.State["config"])
+}
+
+func TestSQLiteSessionStoreThrottlesAccessWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	store, err := NewSQLiteSessionStore(path, WithIdleTimeout(time.Hour))
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	start := time.Now()
+	store.mu.Lock()
+	store.createLocked("client-1", start)
+	_, ok := store.getLocked("client-1", start.Add(time.Second))
+	store.mu.Unlock()
+	require.True(t, ok)
+
+	var stored int64
+	require.NoError(t, store.db.QueryRow(`SELECT last_accessed_at FROM mcp_sessions WHERE id = 'client-1'`).Scan(&stored))
+	require.Equal(t, start.UnixMilli(), stored)
+
+	later := start.Add(2 * time.Minute)
+	store.mu.Lock()
+	_, ok = store.getLocked("client-1", later)
+	store.mu.Unlock()
+	require.True(t, ok)
+	require.NoError(t, store.db.QueryRow(`SELECT last_accessed_at FROM mcp_sessions WHERE id = 'client-1'`).Scan(&stored))
+	require.Equal(t, later.UnixMilli(), stored)
+}
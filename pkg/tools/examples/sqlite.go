@@ -95,27 +95,10 @@ func registerSQLiteOpenTool(registry *tool_registry.Registry) error {
 			// If not already open or different path requested, close existing connection if any
 			closeSessionDB(s) // Best effort closing
 
-			db, err := sql.Open("sqlite3", dbPath)
-			if err != nil {
-				return protocol.NewToolResult(
-					protocol.WithError(fmt.Sprintf("error opening database '%s': %v", dbPath, err)),
-				), nil
-			}
-
-			// Test connection
-			if err := db.PingContext(ctx); err != nil {
-				_ = db.Close()
-				return protocol.NewToolResult(
-					protocol.WithError(fmt.Sprintf("error pinging database '%s': %v", dbPath, err)),
-				), nil
+			if _, err := openSessionDB(ctx, s, dbPath); err != nil {
+				return protocol.NewToolResult(protocol.WithError(err.Error())), nil
 			}
 
-			// Store connection and path in session
-			s.SetData(sessionDBConnectionKey, db)
-			s.SetData(sessionDBPathKey, dbPath)
-
-			log.Info().Str("sessionID", string(s.ID)).Str("dbPath", dbPath).Msg("SQLite database opened and stored in session")
-
 			return protocol.NewToolResult(
 				protocol.WithText(fmt.Sprintf("Successfully opened database: %s", dbPath)),
 			), nil
@@ -124,6 +107,32 @@ func registerSQLiteOpenTool(registry *tool_registry.Registry) error {
 	return nil
 }
 
+// openSessionDB opens the database at dbPath and stores the connection in the
+// session. The connection is transient and closed with the session, while the
+// path is persisted, so that the connection can be reopened after a restart.
+func openSessionDB(ctx context.Context, s *session.Session, dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, errors.Errorf("error opening database '%s': %v", dbPath, err)
+	}
+
+	// Test connection
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, errors.Errorf("error pinging database '%s': %v", dbPath, err)
+	}
+
+	// Store connection and path in session
+	s.SetTransientData(sessionDBConnectionKey, db)
+	s.SetData(sessionDBPathKey, dbPath)
+	s.OnClose(sessionDBConnectionKey, func(s *session.Session) {
+		closeSessionDB(s)
+	})
+
+	log.Info().Str("sessionID", string(s.ID)).Str("dbPath", dbPath).Msg("SQLite database opened and stored in session")
+	return db, nil
+}
+
 // closeSessionDB closes the database connection stored in the session, if it exists.
 // It returns the path of the closed DB and true if a connection was found and closed, otherwise empty string and false.
 func closeSessionDB(s *session.Session) (string, bool) {
@@ -244,6 +253,17 @@ func registerSQLiteQueryTool(registry *tool_registry.Registry) error {
 						}
 						log.Debug().Str("sessionID", string(s.ID)).Str("dbPath", dbPath).Msg("Using SQLite connection from session")
 					}
+				} else if pathVal, pathOk := s.GetData(sessionDBPathKey); pathOk {
+					// The session was restored from a persistent store, reopen its database
+					if path, ok := pathVal.(string); ok {
+						var openErr error
+						db, openErr = openSessionDB(ctx, s, path)
+						if openErr != nil {
+							return protocol.NewToolResult(protocol.WithError(openErr.Error())), nil
+						}
+						sessionUsed = true
+						dbPath = path
+					}
 				}
 			}
 
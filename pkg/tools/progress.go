@@ -0,0 +1,46 @@
+package tools
+
+import "context"
+
+// ProgressReporter sends a progress update for the tool call it was created
+// for. total is 0 when unknown.
+type ProgressReporter func(progress, total float64, message string) error
+
+// LogReporter sends a log message for the tool call it was created for.
+// level is one of the MCP logging levels (debug, info, warning, error, ...).
+type LogReporter func(level string, data interface{}) error
+
+type progressContextKey struct{}
+type logContextKey struct{}
+
+// WithProgressReporter returns a context carrying the reporter used by
+// ReportProgress. Servers install it for tool calls whose request carries a
+// progress token.
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressContextKey{}, reporter)
+}
+
+// ReportProgress reports the progress of the tool call running in ctx. It is a
+// no-op if the client did not ask for progress notifications.
+func ReportProgress(ctx context.Context, progress, total float64, message string) error {
+	reporter, ok := ctx.Value(progressContextKey{}).(ProgressReporter)
+	if !ok || reporter == nil {
+		return nil
+	}
+	return reporter(progress, total, message)
+}
+
+// WithLogReporter returns a context carrying the reporter used by ReportLog
+func WithLogReporter(ctx context.Context, reporter LogReporter) context.Context {
+	return context.WithValue(ctx, logContextKey{}, reporter)
+}
+
+// ReportLog sends a log message to the client of the tool call running in
+// ctx. It is a no-op outside of a tool call.
+func ReportLog(ctx context.Context, level string, data interface{}) error {
+	reporter, ok := ctx.Value(logContextKey{}).(LogReporter)
+	if !ok || reporter == nil {
+		return nil
+	}
+	return reporter(level, data)
+}
@@ -0,0 +1,297 @@
+package config_provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	mcp_cmds "github.com/go-go-golems/go-go-mcp/pkg/cmds"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DescribeToolsFlag is passed to external commands to list their tools
+	DescribeToolsFlag = "--describe-tools"
+	// CallToolFlag is passed to external commands, followed by the tool name,
+	// to call one of their tools
+	CallToolFlag = "--call-tool"
+
+	describeToolsTimeout = 30 * time.Second
+)
+
+// ExternalCommand is a tool provided by an external executable.
+//
+// Running the executable with --describe-tools prints its tools as JSON, in
+// the format of an MCP tools/list result:
+//
+//	{"tools": [{"name": "...", "description": "...", "inputSchema": {...}}]}
+//
+// A tool is called by running the executable with --call-tool and the tool
+// name, with the arguments as a JSON object on stdin. Its standard output,
+// up to MaxOutputSize bytes, is the result; a non-zero exit status makes the
+// call fail. Tools are only described when they are loaded.
+//
+// The tool is advertised with the input schema it describes. The properties
+// of that schema that glazed can represent become flags of the command, so
+// that the defaults, overrides and filters of the profile apply to them like
+// to repository commands; the other ones are passed to the tool unchanged.
+type ExternalCommand struct {
+	*cmds.CommandDescription
+	// Command is the executable and the arguments given in the profile
+	Command      []string
+	InputSchema  json.RawMessage
+	OutputSchema json.RawMessage
+	Annotations  *protocol.ToolAnnotations
+	// MaxOutputSize caps the output in bytes, 0 means unlimited. Output
+	// beyond it is dropped and TruncationMarker appended, or the call fails
+	// if the tool has an output schema.
+	MaxOutputSize    int
+	TruncationMarker string
+
+	// passthrough lists the properties that have no flag
+	passthrough []string
+}
+
+var _ cmds.WriterCommand = &ExternalCommand{}
+
+// LoadExternalCommands runs the configured executable with --describe-tools
+// and returns a command for each tool it describes.
+func LoadExternalCommands(ctx context.Context, source *config.ExternalCommand) ([]*ExternalCommand, error) {
+	command := source.Command
+	if source.Path != "" && !filepath.IsAbs(command) {
+		command = filepath.Join(source.Path, command)
+	}
+	argv := append([]string{command}, source.Args...)
+	if source.MaxOutputSize < 0 {
+		return nil, errors.Errorf("max_output_size of %s must not be negative", command)
+	}
+	truncationMarker := source.TruncationMarker
+	if truncationMarker == "" {
+		truncationMarker = mcp_cmds.DefaultTruncationMarker
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, describeToolsTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, argv[0], append(append([]string{}, argv[1:]...), DescribeToolsFlag)...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		return nil, errors.Wrapf(err, "failed to describe tools of %s: %s", command, strings.TrimSpace(stderr.String()))
+	}
+
+	var description struct {
+		Tools []protocol.Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &description); err != nil {
+		return nil, errors.Wrapf(err, "invalid tool description from %s", command)
+	}
+
+	ret := make([]*ExternalCommand, 0, len(description.Tools))
+	for _, tool := range description.Tools {
+		if tool.Name == "" {
+			return nil, errors.Errorf("tool without a name described by %s", command)
+		}
+		flags, passthrough, err := fieldsFromInputSchema(tool.InputSchema)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid input schema for tool %s of %s", tool.Name, command)
+		}
+
+		ret = append(ret, &ExternalCommand{
+			CommandDescription: cmds.NewCommandDescription(
+				tool.Name,
+				cmds.WithShort(tool.Description),
+				cmds.WithFlags(flags...),
+			),
+			Command:          argv,
+			InputSchema:      tool.InputSchema,
+			OutputSchema:     tool.OutputSchema,
+			Annotations:      tool.Annotations,
+			MaxOutputSize:    source.MaxOutputSize,
+			TruncationMarker: truncationMarker,
+			passthrough:      passthrough,
+		})
+	}
+
+	log.Debug().Str("command", command).Int("tools", len(ret)).Msg("Loaded external command tools")
+	return ret, nil
+}
+
+// fieldsFromInputSchema maps the properties of a JSON object schema to
+// glazed fields. The names of the properties whose type has no glazed
+// equivalent are returned separately.
+func fieldsFromInputSchema(inputSchema json.RawMessage) ([]*fields.Definition, []string, error) {
+	if len(inputSchema) == 0 {
+		return nil, nil, nil
+	}
+
+	type propertySchema struct {
+		Type        string        `json:"type"`
+		Description string        `json:"description"`
+		Enum        []interface{} `json:"enum"`
+		Default     interface{}   `json:"default"`
+		Items       *struct {
+			Type string        `json:"type"`
+			Enum []interface{} `json:"enum"`
+		} `json:"items"`
+	}
+	var objectSchema struct {
+		Properties map[string]propertySchema `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal(inputSchema, &objectSchema); err != nil {
+		return nil, nil, err
+	}
+
+	required := map[string]bool{}
+	for _, name := range objectSchema.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(objectSchema.Properties))
+	for name := range objectSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := make([]*fields.Definition, 0, len(names))
+	var unsupported []string
+	for _, name := range names {
+		property := objectSchema.Properties[name]
+
+		var type_ fields.Type
+		var choices []string
+		switch property.Type {
+		case "string":
+			type_, choices = fields.TypeString, enumStrings(property.Enum)
+			if len(choices) > 0 {
+				type_ = fields.TypeChoice
+			}
+		case "integer":
+			type_ = fields.TypeInteger
+		case "number":
+			type_ = fields.TypeFloat
+		case "boolean":
+			type_ = fields.TypeBool
+		case "object":
+			type_ = fields.TypeObjectFromFile
+		case "array":
+			if property.Items == nil {
+				break
+			}
+			switch property.Items.Type {
+			case "string":
+				type_, choices = fields.TypeStringList, enumStrings(property.Items.Enum)
+				if len(choices) > 0 {
+					type_ = fields.TypeChoiceList
+				}
+			case "integer":
+				type_ = fields.TypeIntegerList
+			case "number":
+				type_ = fields.TypeFloatList
+			case "object":
+				type_ = fields.TypeObjectListFromFile
+			}
+		}
+		if type_ == "" {
+			log.Debug().Str("property", name).Str("type", property.Type).Msg("Passing property of unsupported type through")
+			unsupported = append(unsupported, name)
+			continue
+		}
+
+		options := []fields.Option{
+			fields.WithHelp(property.Description),
+			fields.WithRequired(required[name]),
+		}
+		if len(choices) > 0 {
+			options = append(options, fields.WithChoices(choices...))
+		}
+		if property.Default != nil {
+			options = append(options, fields.WithDefault(property.Default))
+		}
+		ret = append(ret, fields.New(name, type_, options...))
+	}
+
+	return ret, unsupported, nil
+}
+
+func enumStrings(enum []interface{}) []string {
+	ret := make([]string, 0, len(enum))
+	for _, v := range enum {
+		s, ok := v.(string)
+		if !ok {
+			return nil
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+// RunIntoWriter calls the tool with the parsed flags as arguments and writes
+// its output to w.
+func (c *ExternalCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	return c.runWithArguments(ctx, parsedValues, nil, w)
+}
+
+// runWithArguments calls the tool with the parsed flags, and the values in
+// raw of the properties that have no flag.
+func (c *ExternalCommand) runWithArguments(ctx context.Context, parsedValues *values.Values, raw map[string]interface{}, w io.Writer) error {
+	arguments := map[string]interface{}{}
+	for _, name := range c.passthrough {
+		if value, ok := raw[name]; ok {
+			arguments[name] = value
+		}
+	}
+	if section, ok := parsedValues.Get(schema.DefaultSlug); ok {
+		section.Fields.ForEach(func(name string, fv *fields.FieldValue) {
+			if fv.Value != nil {
+				arguments[name] = fv.Value
+			}
+		})
+	}
+	input, err := json.Marshal(arguments)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode arguments")
+	}
+
+	var stderr bytes.Buffer
+	args := append(append([]string{}, c.Command[1:]...), CallToolFlag, c.Name)
+	cmd := exec.CommandContext(ctx, c.Command[0], args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	var limited *mcp_cmds.LimitWriter
+	if c.MaxOutputSize > 0 {
+		limited = mcp_cmds.NewLimitWriter(w, c.MaxOutputSize)
+		cmd.Stdout = limited
+	}
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "%s failed: %s", c.Name, strings.TrimSpace(stderr.String()))
+	}
+
+	if limited != nil && limited.Truncated() {
+		// Truncated JSON can't be returned as structured content
+		if len(c.OutputSchema) > 0 {
+			return errors.Errorf("output exceeds max_output_size of %d bytes", c.MaxOutputSize)
+		}
+		log.Info().Str("tool", c.Name).Int("max_output_size", c.MaxOutputSize).Msg("truncated command output")
+		if _, err := io.WriteString(w, c.TruncationMarker); err != nil {
+			return errors.Wrap(err, "failed to write truncation marker")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package config_provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	mcp_cmds "github.com/go-go-golems/go-go-mcp/pkg/cmds"
+	"github.com/go-go-golems/go-go-mcp/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+const externalCommandScript = `#!/bin/sh
+case "$1" in
+--describe-tools)
+	cat <<'EOF'
+{"tools": [{
+	"name": "greet",
+	"description": "Greet someone",
+	"inputSchema": {
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"greeting": {"type": "string", "default": "Hello"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"target": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+		},
+		"required": ["name", "target"]
+	},
+	"annotations": {"readOnlyHint": true}
+}]}
+EOF
+	;;
+--call-tool)
+	echo "$2"
+	cat
+	;;
+*)
+	exit 1
+	;;
+esac
+`
+
+func TestExternalCommandTools(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter"), []byte(externalCommandScript), 0o755))
+
+	provider, err := NewConfigToolProvider(WithConfig(&config.Config{
+		Profiles: map[string]*config.Profile{
+			"default": {
+				Tools: &config.ToolSources{
+					ExternalCommands: []config.ExternalCommand{{
+						Command: "greeter",
+						SourceConfig: config.SourceConfig{
+							Path: dir,
+							Overrides: config.LayerParameters{
+								schema.DefaultSlug: {"greeting": "Howdy"},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}, "default"))
+	require.NoError(t, err)
+
+	tools, _, err := provider.ListTools(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	require.Equal(t, "greet", tools[0].Name)
+	// The schema is advertised as described, including the property that has
+	// no flag
+	var inputSchema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(tools[0].InputSchema, &inputSchema))
+	require.JSONEq(t, `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, string(inputSchema.Properties["target"]))
+	require.Equal(t, []string{"name", "target"}, inputSchema.Required)
+	require.NotNil(t, tools[0].Annotations)
+	require.True(t, *tools[0].Annotations.ReadOnlyHint)
+
+	result, err := provider.CallTool(context.Background(), "greet", map[string]interface{}{
+		"name":     "Ada",
+		"greeting": "Hi",
+		"target":   float64(42),
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	name, input, ok := strings.Cut(result.Content[0].Text, "\n")
+	require.True(t, ok)
+	require.Equal(t, "greet", name)
+
+	var arguments map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(input), &arguments))
+	require.Equal(t, "Ada", arguments["name"])
+	require.Equal(t, "Howdy", arguments["greeting"])
+	require.Equal(t, float64(42), arguments["target"])
+}
+
+func TestExternalCommandMaxOutputSize(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter"), []byte(externalCommandScript), 0o755))
+
+	commands, err := LoadExternalCommands(context.Background(), &config.ExternalCommand{
+		Command:       "greeter",
+		MaxOutputSize: 4,
+		SourceConfig:  config.SourceConfig{Path: dir},
+	})
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+
+	var out strings.Builder
+	require.NoError(t, commands[0].runWithArguments(context.Background(), values.New(), map[string]interface{}{"target": "x"}, &out))
+	require.Equal(t, "gree"+mcp_cmds.DefaultTruncationMarker, out.String())
+
+	// JSON output can't be truncated
+	commands[0].OutputSchema = json.RawMessage(`{"type": "object"}`)
+	out.Reset()
+	err = commands[0].runWithArguments(context.Background(), values.New(), nil, &out)
+	require.EqualError(t, err, "output exceeds max_output_size of 4 bytes")
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-go-golems/go-go-mcp/pkg/scholarly/mcp"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/go-go-golems/clay/pkg/repositories"
@@ -39,6 +40,12 @@ type ConfigToolProvider struct {
 	watching        bool
 	convertDashes   bool // controls whether to convert dashes to underscores in tool names
 	internalServers []string
+	// externalCommands describe their tools when the provider is created
+	externalCommands []*config.ExternalCommand
+
+	// ChangeNotifier signals subscribers whenever the watcher adds or removes
+	// commands
+	pkg.ChangeNotifier
 }
 
 type ConfigToolProviderOption func(*ConfigToolProvider) error
@@ -122,6 +129,10 @@ func WithConfig(config_ *config.Config, profile string) ConfigToolProviderOption
 
 		p.files = files
 
+		for i := range profileConfig.Tools.ExternalCommands {
+			p.externalCommands = append(p.externalCommands, &profileConfig.Tools.ExternalCommands[i])
+		}
+
 		return nil
 	}
 }
@@ -170,6 +181,16 @@ func NewConfigToolProvider(options ...ConfigToolProviderOption) (*ConfigToolProv
 		repositories.WithDirectories(provider.directories...),
 		repositories.WithFiles(provider.files...),
 		repositories.WithCommandLoader(&mcp_cmds.ShellCommandLoader{}),
+		repositories.WithUpdateCallback(func(cmd cmds.Command) error {
+			log.Debug().Str("command", cmd.Description().Name).Msg("Tool command updated")
+			provider.NotifyChanged()
+			return nil
+		}),
+		repositories.WithRemoveCallback(func(cmd cmds.Command) error {
+			log.Debug().Str("command", cmd.Description().Name).Msg("Tool command removed")
+			provider.NotifyChanged()
+			return nil
+		}),
 	)
 
 	// Load repository commands
@@ -177,9 +198,35 @@ func NewConfigToolProvider(options ...ConfigToolProviderOption) (*ConfigToolProv
 		return nil, errors.Wrap(err, "failed to load repository commands")
 	}
 
+	if err := provider.loadExternalCommands(context.Background()); err != nil {
+		return nil, err
+	}
+
 	return provider, nil
 }
 
+// loadExternalCommands asks the configured external commands for their tools
+// and registers them next to the shell commands, with the defaults, overrides
+// and filters of their source.
+func (p *ConfigToolProvider) loadExternalCommands(ctx context.Context) error {
+	for _, source := range p.externalCommands {
+		commands, err := LoadExternalCommands(ctx, source)
+		if err != nil {
+			return errors.Wrap(err, "failed to load external command tools")
+		}
+		for _, cmd := range commands {
+			name := cmd.Description().Name
+			if _, ok := p.shellCommands[name]; ok {
+				log.Warn().Str("tool", name).Str("command", source.Command).Msg("Skipping duplicate external command tool")
+				continue
+			}
+			p.shellCommands[name] = cmd
+			p.toolConfigs[name] = &source.SourceConfig
+		}
+	}
+	return nil
+}
+
 func ConvertCommandToTool(desc *cmds.CommandDescription) (protocol.Tool, error) {
 	schema_, err := desc.ToJsonSchema()
 	if err != nil {
@@ -247,12 +294,29 @@ func (p *ConfigToolProvider) ListTools(ctx context.Context, cursor string) ([]pr
 		tools = append(tools, tool)
 	}
 
-	// Add shell commands
-	for _, cmd := range p.shellCommands {
+	// Add shell and external commands
+	shellCommandNames := make([]string, 0, len(p.shellCommands))
+	for name := range p.shellCommands {
+		shellCommandNames = append(shellCommandNames, name)
+	}
+	sort.Strings(shellCommandNames)
+	for _, name := range shellCommandNames {
+		cmd := p.shellCommands[name]
 		tool, err := ConvertCommandToTool(cmd.Description())
 		if err != nil {
 			return nil, "", errors.Wrapf(err, "failed to convert command to tool")
 		}
+		switch c := cmd.(type) {
+		case *mcp_cmds.ShellCommand:
+			tool.OutputSchema = c.OutputSchema
+			tool.Annotations = c.Annotations
+		case *ExternalCommand:
+			if len(c.InputSchema) > 0 {
+				tool.InputSchema = c.InputSchema
+			}
+			tool.OutputSchema = c.OutputSchema
+			tool.Annotations = c.Annotations
+		}
 		if p.convertDashes {
 			tool.Name = p.convertToolName(tool.Name)
 		}
@@ -309,7 +373,7 @@ func (p *ConfigToolProvider) CallTool(ctx context.Context, name string, argument
 		return p.executeCommand(ctx, cmd, arguments)
 	}
 
-	// Try shell commands
+	// Try shell and external commands
 	if cmd, ok := p.shellCommands[originalName]; ok {
 		// Convert argument keys if needed
 		if p.convertDashes {
@@ -355,6 +419,10 @@ func (p *ConfigToolProvider) executeCommand(ctx context.Context, cmd cmds.Comman
 
 	// Run the command with parsed parameters
 	switch c := cmd.(type) {
+	case *ExternalCommand:
+		if err := c.runWithArguments(ctx, parsedValues, arguments, buf); err != nil {
+			return protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("%s\n\nOutput so far:\n%s", err.Error(), buf.String()))), nil
+		}
 	case cmds.WriterCommand:
 		if err := c.RunIntoWriter(ctx, parsedValues, buf); err != nil {
 			return protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("%s\n\nOutput so far:\n%s", err.Error(), buf.String()))), nil
@@ -368,12 +436,26 @@ func (p *ConfigToolProvider) executeCommand(ctx context.Context, cmd cmds.Comman
 	}
 
 	text := buf.String()
-	l := 62 * 1024
-	if len(text) > l {
-		text = text[:l]
+
+	// Commands with an output schema print JSON, which is returned as
+	// structured content. It is validated by the registry.
+	var outputSchema json.RawMessage
+	switch c := cmd.(type) {
+	case *mcp_cmds.ShellCommand:
+		outputSchema = c.OutputSchema
+	case *ExternalCommand:
+		outputSchema = c.OutputSchema
+	}
+	var structured interface{}
+	if len(outputSchema) > 0 {
+		if err := json.Unmarshal([]byte(text), &structured); err != nil {
+			return protocol.NewErrorToolResult(protocol.NewTextContent(fmt.Sprintf("command output is not valid JSON: %s\n\nOutput:\n%s", err.Error(), text))), nil
+		}
 	}
 
-	return protocol.NewToolResult(protocol.WithText(text)), nil
+	res := protocol.NewToolResult(protocol.WithText(text))
+	res.StructuredContent = structured
+	return res, nil
 }
 
 func (p *ConfigToolProvider) createConfigMiddlewares(sourceConfig *config.SourceConfig) []sources.Middleware {
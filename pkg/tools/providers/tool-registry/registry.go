@@ -1,13 +1,20 @@
 package tool_registry
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"reflect"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/go-go-golems/go-go-mcp/pkg"
 	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
 	"github.com/go-go-golems/go-go-mcp/pkg/tools"
+	"github.com/go-go-golems/go-go-mcp/pkg/tools/validation"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 // Registry provides a simple way to register individual tools
@@ -15,6 +22,11 @@ type Registry struct {
 	mu       sync.RWMutex
 	tools    map[string]tools.Tool
 	handlers map[string]Handler
+	// synced maps the tools registered by SyncFromProvider to their provider
+	synced map[string]pkg.ToolProvider
+	// ChangeNotifier signals subscribers whenever tools are registered or
+	// unregistered
+	pkg.ChangeNotifier
 }
 
 // Handler is a function that executes a tool with given arguments
@@ -27,6 +39,7 @@ func NewRegistry() *Registry {
 	return &Registry{
 		tools:    make(map[string]tools.Tool),
 		handlers: make(map[string]Handler),
+		synced:   make(map[string]pkg.ToolProvider),
 	}
 }
 
@@ -35,6 +48,8 @@ func (r *Registry) RegisterTool(tool tools.Tool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.tools[tool.GetName()] = tool
+	delete(r.synced, tool.GetName())
+	r.NotifyChanged()
 }
 
 // RegisterToolWithHandler adds a tool with a custom handler
@@ -43,6 +58,8 @@ func (r *Registry) RegisterToolWithHandler(tool tools.Tool, handler Handler) {
 	defer r.mu.Unlock()
 	r.tools[tool.GetName()] = tool
 	r.handlers[tool.GetName()] = handler
+	delete(r.synced, tool.GetName())
+	r.NotifyChanged()
 }
 
 // UnregisterTool removes a tool from the registry
@@ -51,6 +68,89 @@ func (r *Registry) UnregisterTool(name string) {
 	defer r.mu.Unlock()
 	delete(r.tools, name)
 	delete(r.handlers, name)
+	delete(r.synced, name)
+	r.NotifyChanged()
+}
+
+// GetTool returns the registered tool called name
+func (r *Registry) GetTool(name string) (tools.Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// SyncFromProvider makes the registry mirror the tools listed by provider.
+// Every listed tool is (re-)registered with a handler that proxies calls to
+// provider, and the tools registered by a previous sync from provider that
+// are no longer listed are removed. Other tools are left alone. Subscribers
+// are notified once if a tool was added, removed or its definition changed.
+func (r *Registry) SyncFromProvider(ctx context.Context, provider pkg.ToolProvider) error {
+	var listed []protocol.Tool
+	cursor := ""
+	for {
+		page, next, err := provider.ListTools(ctx, cursor)
+		if err != nil {
+			return errors.Wrap(err, "failed to list tools from provider")
+		}
+		listed = append(listed, page...)
+		if next == "" || next == cursor {
+			break
+		}
+		cursor = next
+	}
+
+	newTools := make(map[string]tools.Tool, len(listed))
+	for _, t := range listed {
+		toolImpl, err := tools.NewToolImpl(t.Name, t.Description, t.InputSchema)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create tool %s", t.Name)
+		}
+		if err := toolImpl.SetOutputSchema(t.OutputSchema); err != nil {
+			return errors.Wrapf(err, "failed to create tool %s", t.Name)
+		}
+		toolImpl.SetAnnotations(t.Annotations)
+		newTools[t.Name] = toolImpl
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := false
+	for name, owner := range r.synced {
+		if _, ok := newTools[name]; !ok && owner == provider {
+			delete(r.tools, name)
+			delete(r.handlers, name)
+			delete(r.synced, name)
+			changed = true
+		}
+	}
+	for name, tool := range newTools {
+		name := name
+		if existing, ok := r.tools[name]; !ok || !toolDefinitionEqual(existing.GetToolDefinition(), tool.GetToolDefinition()) {
+			changed = true
+		}
+		r.tools[name] = tool
+		r.handlers[name] = func(ctx context.Context, _ tools.Tool, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+			return provider.CallTool(ctx, name, arguments)
+		}
+		r.synced[name] = provider
+	}
+
+	if changed {
+		r.NotifyChanged()
+	}
+	return nil
+}
+
+// toolDefinitionEqual reports whether two tool definitions would be listed
+// the same way to clients.
+func toolDefinitionEqual(a, b protocol.Tool) bool {
+	return a.Name == b.Name &&
+		a.Description == b.Description &&
+		bytes.Equal(a.InputSchema, b.InputSchema) &&
+		bytes.Equal(a.OutputSchema, b.OutputSchema) &&
+		reflect.DeepEqual(a.Annotations, b.Annotations)
 }
 
 // ListTools implements ToolProvider interface
@@ -86,20 +186,68 @@ func (r *Registry) ListTools(_ context.Context, cursor string) ([]protocol.Tool,
 	return tools[pos+1:], "", nil
 }
 
-// CallTool implements ToolProvider interface
+// CallTool implements ToolProvider interface. The arguments are validated
+// against the tool's input schema before it is called, and the structured
+// content of tools with an output schema is validated against it.
 func (r *Registry) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+	// Look up under the lock but call outside of it, so that long-running
+	// tools do not block registration.
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	tool, ok := r.tools[name]
+	handler, hasHandler := r.handlers[name]
+	r.mu.RUnlock()
+
 	if !ok {
 		return nil, pkg.ErrToolNotFound
 	}
 
-	if handler, ok := r.handlers[name]; ok {
-		return handler(ctx, tool, arguments)
+	if !hasHandler {
+		// If no handler is registered, use the tool's Call method
+		handler = func(ctx context.Context, tool tools.Tool, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+			return tool.Call(ctx, arguments)
+		}
 	}
 
-	// If no handler is registered, use the tool's Call method
-	return tool.Call(ctx, arguments)
+	res, err := ValidateArguments(handler)(ctx, tool, arguments)
+	if err != nil {
+		return res, err
+	}
+
+	if err := tools.ValidateStructuredContent(tool, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ValidateArguments wraps next so that it is only called with arguments
+// matching the tool's input schema, after coercing string-encoded numbers
+// and booleans and filling in defaults. Invalid arguments are returned as an
+// error result listing every violation, so that the model can correct its
+// call. Tools with an invalid input schema are called without validation.
+func ValidateArguments(next Handler) Handler {
+	return func(ctx context.Context, tool tools.Tool, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+		prepared, err := tools.ValidateArguments(tool, arguments)
+		var validationErr *validation.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			return protocol.NewErrorToolResult(protocol.NewTextContent(formatViolations(tool.GetName(), validationErr))), nil
+		case err != nil:
+			log.Warn().Err(err).Str("tool", tool.GetName()).Msg("Calling tool without validating its arguments")
+			prepared = arguments
+		}
+		return next(ctx, tool, prepared)
+	}
+}
+
+func formatViolations(name string, err *validation.ValidationError) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid arguments for tool %s:", name)
+	for _, v := range err.Violations {
+		path := strings.TrimPrefix(v.Path, "/")
+		if path == "" {
+			path = "arguments"
+		}
+		fmt.Fprintf(&sb, "\n- %s: %s", path, v.Message)
+	}
+	return sb.String()
 }
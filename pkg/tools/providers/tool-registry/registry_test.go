@@ -0,0 +1,194 @@
+package tool_registry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+type staticToolProvider struct {
+	tools     []protocol.Tool
+	calls     []string
+	arguments map[string]interface{}
+	result    *protocol.ToolResult
+}
+
+func (p *staticToolProvider) ListTools(_ context.Context, _ string) ([]protocol.Tool, string, error) {
+	return p.tools, "", nil
+}
+
+func (p *staticToolProvider) CallTool(_ context.Context, name string, arguments map[string]interface{}) (*protocol.ToolResult, error) {
+	p.calls = append(p.calls, name)
+	p.arguments = arguments
+	if p.result != nil {
+		return p.result, nil
+	}
+	return protocol.NewToolResult(protocol.WithText(name)), nil
+}
+
+func newStaticTool(name string) protocol.Tool {
+	return protocol.Tool{
+		Name:        name,
+		Description: name,
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+	}
+}
+
+func TestSyncFromProviderAddsAndRemovesTools(t *testing.T) {
+	ctx := context.Background()
+	provider := &staticToolProvider{tools: []protocol.Tool{newStaticTool("a"), newStaticTool("b")}}
+	reg := NewRegistry()
+
+	changes, cleanup := reg.SubscribeToChanges()
+	defer cleanup()
+
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+	<-changes
+
+	tools, _, err := reg.ListTools(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tools, 2)
+
+	provider.tools = []protocol.Tool{newStaticTool("b"), newStaticTool("c")}
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+	<-changes
+
+	tools, _, err = reg.ListTools(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, []string{tools[0].Name, tools[1].Name})
+
+	_, err = reg.CallTool(ctx, "c", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, provider.calls)
+
+	_, err = reg.CallTool(ctx, "a", nil)
+	require.Error(t, err)
+}
+
+func TestSyncFromProviderKeepsOtherTools(t *testing.T) {
+	ctx := context.Background()
+	reg := NewRegistry()
+	other := &staticToolProvider{tools: []protocol.Tool{newStaticTool("other")}}
+	require.NoError(t, reg.SyncFromProvider(ctx, other))
+	provider := &staticToolProvider{tools: []protocol.Tool{newStaticTool("a")}}
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+
+	provider.tools = nil
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+
+	tools, _, err := reg.ListTools(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	require.Equal(t, "other", tools[0].Name)
+}
+
+func TestSyncFromProviderOnlyNotifiesChanges(t *testing.T) {
+	ctx := context.Background()
+	provider := &staticToolProvider{tools: []protocol.Tool{newStaticTool("a")}}
+	reg := NewRegistry()
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+
+	changes, cleanup := reg.SubscribeToChanges()
+	defer cleanup()
+
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+	select {
+	case <-changes:
+		t.Fatal("unexpected change notification for an unchanged tool list")
+	default:
+	}
+
+	changed := newStaticTool("a")
+	changed.Description = "changed"
+	provider.tools = []protocol.Tool{changed}
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+	select {
+	case <-changes:
+	default:
+		t.Fatal("expected a change notification for a changed description")
+	}
+}
+
+func TestCallToolValidatesStructuredContent(t *testing.T) {
+	ctx := context.Background()
+	tool := newStaticTool("weather")
+	tool.OutputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {"temperature": {"type": "number"}},
+		"required": ["temperature"]
+	}`)
+	provider := &staticToolProvider{tools: []protocol.Tool{tool}}
+	reg := NewRegistry()
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+
+	tools, _, err := reg.ListTools(ctx, "")
+	require.NoError(t, err)
+	require.JSONEq(t, string(tool.OutputSchema), string(tools[0].OutputSchema))
+
+	provider.result = protocol.NewToolResult(protocol.WithStructuredContent(map[string]interface{}{"temperature": 21.5}))
+	res, err := reg.CallTool(ctx, "weather", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"temperature": 21.5}, res.StructuredContent)
+
+	provider.result = protocol.NewToolResult(protocol.WithStructuredContent(map[string]interface{}{"temperature": "warm"}))
+	_, err = reg.CallTool(ctx, "weather", nil)
+	require.Error(t, err)
+
+	provider.result = protocol.NewToolResult(protocol.WithText("21.5"))
+	_, err = reg.CallTool(ctx, "weather", nil)
+	require.Error(t, err)
+
+	provider.result = protocol.NewErrorToolResult(protocol.NewTextContent("sensor offline"))
+	res, err = reg.CallTool(ctx, "weather", nil)
+	require.NoError(t, err)
+	require.True(t, res.IsError)
+}
+
+func TestCallToolValidatesArguments(t *testing.T) {
+	ctx := context.Background()
+	tool := newStaticTool("resize")
+	tool.InputSchema = json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"width": {"type": "integer", "minimum": 1},
+			"keepRatio": {"type": "boolean", "default": true},
+			"format": {"enum": ["png", "jpeg"]}
+		},
+		"required": ["width"],
+		"additionalProperties": false
+	}`)
+	provider := &staticToolProvider{tools: []protocol.Tool{tool}}
+	reg := NewRegistry()
+	require.NoError(t, reg.SyncFromProvider(ctx, provider))
+
+	res, err := reg.CallTool(ctx, "resize", map[string]interface{}{"width": "640"})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	require.Equal(t, map[string]interface{}{"width": 640.0, "keepRatio": true}, provider.arguments)
+
+	res, err = reg.CallTool(ctx, "resize", map[string]interface{}{"width": 0, "format": "gif", "height": 10})
+	require.NoError(t, err)
+	require.True(t, res.IsError)
+	require.Equal(t, `invalid arguments for tool resize:
+- format: must be one of ["png", "jpeg"]
+- arguments: property "height" is not allowed
+- width: must be >= 1`, res.Content[0].Text)
+	require.Len(t, provider.calls, 1)
+}
+
+func TestSyncFromProviderKeepsAnnotations(t *testing.T) {
+	ctx := context.Background()
+	readOnly := true
+	tool := newStaticTool("stat")
+	tool.Annotations = &protocol.ToolAnnotations{Title: "Stat", ReadOnlyHint: &readOnly}
+	reg := NewRegistry()
+	require.NoError(t, reg.SyncFromProvider(ctx, &staticToolProvider{tools: []protocol.Tool{tool}}))
+
+	registered, ok := reg.GetTool("stat")
+	require.True(t, ok)
+	require.Equal(t, tool.Annotations, registered.GetToolDefinition().Annotations)
+	require.Equal(t, `title="Stat", readOnlyHint=true`, tool.Annotations.String())
+}
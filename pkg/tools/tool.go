@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/go-go-golems/go-go-mcp/pkg/protocol"
+	"github.com/go-go-golems/go-go-mcp/pkg/tools/validation"
 )
 
 // Tool represents a tool that can be invoked
@@ -13,31 +14,27 @@ type Tool interface {
 	GetName() string
 	GetDescription() string
 	GetInputSchema() json.RawMessage
+	// GetOutputSchema returns the schema of the tool's structured content, or
+	// nil if the tool only returns unstructured content
+	GetOutputSchema() json.RawMessage
 	GetToolDefinition() protocol.Tool
 	Call(ctx context.Context, arguments map[string]interface{}) (*protocol.ToolResult, error)
 }
 
 // ToolImpl is a basic implementation of the Tool interface
 type ToolImpl struct {
-	name        string
-	description string
-	inputSchema json.RawMessage
+	name         string
+	description  string
+	inputSchema  json.RawMessage
+	outputSchema json.RawMessage
+	annotations  *protocol.ToolAnnotations
 }
 
 // NewToolImpl creates a new ToolImpl with the given parameters
 func NewToolImpl(name, description string, inputSchema interface{}) (*ToolImpl, error) {
-	var schema json.RawMessage
-	switch s := inputSchema.(type) {
-	case json.RawMessage:
-		schema = s
-	case string:
-		schema = json.RawMessage(s)
-	default:
-		var err error
-		schema, err = json.Marshal(s)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal input schema: %w", err)
-		}
+	schema, err := marshalSchema(inputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input schema: %w", err)
 	}
 
 	return &ToolImpl{
@@ -47,6 +44,35 @@ func NewToolImpl(name, description string, inputSchema interface{}) (*ToolImpl,
 	}, nil
 }
 
+// SetOutputSchema sets the schema of the tool's structured content. Like the
+// input schema, it can be given as JSON or as a value to marshal.
+func (t *ToolImpl) SetOutputSchema(outputSchema interface{}) error {
+	schema, err := marshalSchema(outputSchema)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output schema: %w", err)
+	}
+	t.outputSchema = schema
+	return nil
+}
+
+// SetAnnotations sets the hints about the tool's behavior
+func (t *ToolImpl) SetAnnotations(annotations *protocol.ToolAnnotations) {
+	t.annotations = annotations
+}
+
+func marshalSchema(schema interface{}) (json.RawMessage, error) {
+	switch s := schema.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		return s, nil
+	case string:
+		return json.RawMessage(s), nil
+	default:
+		return json.Marshal(s)
+	}
+}
+
 // GetName returns the tool's name
 func (t *ToolImpl) GetName() string {
 	return t.name
@@ -62,12 +88,19 @@ func (t *ToolImpl) GetInputSchema() json.RawMessage {
 	return t.inputSchema
 }
 
+// GetOutputSchema returns the tool's output schema
+func (t *ToolImpl) GetOutputSchema() json.RawMessage {
+	return t.outputSchema
+}
+
 // GetToolDefinition returns the tool's definition
 func (t *ToolImpl) GetToolDefinition() protocol.Tool {
 	return protocol.Tool{
-		Name:        t.name,
-		Description: t.description,
-		InputSchema: t.inputSchema,
+		Name:         t.name,
+		Description:  t.description,
+		InputSchema:  t.inputSchema,
+		OutputSchema: t.outputSchema,
+		Annotations:  t.annotations,
 	}
 }
 
@@ -78,13 +111,60 @@ func (t *ToolImpl) Call(ctx context.Context, arguments map[string]interface{}) (
 
 // MarshalJSON implements json.Marshaler
 func (t *ToolImpl) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Name        string          `json:"name"`
-		Description string          `json:"description"`
-		InputSchema json.RawMessage `json:"inputSchema"`
-	}{
-		Name:        t.name,
-		Description: t.description,
-		InputSchema: t.inputSchema,
-	})
+	return json.Marshal(t.GetToolDefinition())
+}
+
+// ValidateArguments checks arguments against the input schema of tool.
+// String-encoded numbers and booleans are first coerced to the types the
+// schema expects and missing properties are set to their defaults. It
+// returns the prepared arguments, or an error wrapping a
+// *validation.ValidationError that lists every violation.
+func ValidateArguments(tool Tool, arguments map[string]interface{}) (map[string]interface{}, error) {
+	schema, err := validation.Compile(tool.GetInputSchema())
+	if err != nil {
+		return nil, fmt.Errorf("tool %s has an invalid input schema: %w", tool.GetName(), err)
+	}
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+
+	v, err := schema.Coerce(arguments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments for tool %s: %w", tool.GetName(), err)
+	}
+	v, err = schema.ApplyDefaults(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments for tool %s: %w", tool.GetName(), err)
+	}
+	if err := schema.Validate(v); err != nil {
+		return nil, fmt.Errorf("invalid arguments for tool %s: %w", tool.GetName(), err)
+	}
+
+	prepared, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid arguments for tool %s: expected an object", tool.GetName())
+	}
+	return prepared, nil
+}
+
+// ValidateStructuredContent checks the structured content of res against the
+// output schema of tool. Tools with an output schema must return structured
+// content unless the result is an error.
+func ValidateStructuredContent(tool Tool, res *protocol.ToolResult) error {
+	outputSchema := tool.GetOutputSchema()
+	if len(outputSchema) == 0 || res == nil || res.IsError {
+		return nil
+	}
+	if res.StructuredContent == nil {
+		return fmt.Errorf("tool %s has an output schema but returned no structured content", tool.GetName())
+	}
+
+	schema, err := validation.Compile(outputSchema)
+	if err != nil {
+		return fmt.Errorf("tool %s has an invalid output schema: %w", tool.GetName(), err)
+	}
+	if err := schema.Validate(res.StructuredContent); err != nil {
+		return fmt.Errorf("structured content of tool %s does not match its output schema: %w", tool.GetName(), err)
+	}
+	return nil
 }
@@ -0,0 +1,146 @@
+package validation
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Coerce returns a copy of value in which strings are converted to numbers,
+// integers and booleans where the schema expects one of these types and
+// doesn't accept strings, so that "42" and "true" sent by clients that
+// stringify their arguments validate. Values that can't be converted are
+// left as they are for Validate to report.
+func (s *Schema) Coerce(value interface{}) (interface{}, error) {
+	v, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	return s.walk(s.root, v, 0, coerceScalar), nil
+}
+
+// ApplyDefaults returns a copy of value in which the missing properties of
+// objects are set to the default of their schema.
+func (s *Schema) ApplyDefaults(value interface{}) (interface{}, error) {
+	v, err := normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	return s.walk(s.root, v, 0, s.applyDefaults), nil
+}
+
+// walk applies f to v and its schema, then descends into the properties and
+// items of v. Subschemas are followed through $ref and allOf, which always
+// apply, but not through the conditional applicators.
+func (s *Schema) walk(schema interface{}, v interface{}, depth int, f func(sch map[string]interface{}, v interface{}) interface{}) interface{} {
+	sch, ok := schema.(map[string]interface{})
+	if !ok || depth > maxDepth {
+		return v
+	}
+
+	v = f(sch, v)
+	if ref, ok := sch["$ref"].(string); ok {
+		if target, ok := s.resolve(ref); ok {
+			v = s.walk(target, v, depth+1, f)
+		}
+	}
+	if all, ok := sch["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v = s.walk(sub, v, depth+1, f)
+		}
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		properties, _ := sch["properties"].(map[string]interface{})
+		additional, hasAdditional := sch["additionalProperties"]
+		for name, item := range value {
+			if propSchema, ok := properties[name]; ok {
+				value[name] = s.walk(propSchema, item, depth+1, f)
+			} else if hasAdditional {
+				value[name] = s.walk(additional, item, depth+1, f)
+			}
+		}
+	case []interface{}:
+		prefix, _ := sch["prefixItems"].([]interface{})
+		for i, item := range value {
+			if i < len(prefix) {
+				value[i] = s.walk(prefix[i], item, depth+1, f)
+			} else if items, ok := sch["items"].(map[string]interface{}); ok {
+				value[i] = s.walk(items, item, depth+1, f)
+			}
+		}
+	}
+	return v
+}
+
+func coerceScalar(sch map[string]interface{}, v interface{}) interface{} {
+	str, ok := v.(string)
+	t, hasType := sch["type"]
+	if !ok || !hasType || matchesType(t, v) {
+		return v
+	}
+
+	types, ok := t.([]interface{})
+	if !ok {
+		types = []interface{}{t}
+	}
+	str = strings.TrimSpace(str)
+	for _, name := range types {
+		switch name {
+		case "integer", "number":
+			f, err := strconv.ParseFloat(str, 64)
+			if err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) && isType(name.(string), f) {
+				return f
+			}
+		case "boolean":
+			switch strings.ToLower(str) {
+			case "true":
+				return true
+			case "false":
+				return false
+			}
+		}
+	}
+	return v
+}
+
+func (s *Schema) applyDefaults(sch map[string]interface{}, v interface{}) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	properties, _ := sch["properties"].(map[string]interface{})
+	for name, propSchema := range properties {
+		if _, ok := obj[name]; ok {
+			continue
+		}
+		if def, ok := s.defaultOf(propSchema); ok {
+			// Copy the default so that callers modifying the arguments
+			// don't modify the schema
+			obj[name], _ = normalize(def)
+		}
+	}
+	return v
+}
+
+// defaultOf returns the default of schema, following $ref
+func (s *Schema) defaultOf(schema interface{}) (interface{}, bool) {
+	for depth := 0; depth <= maxDepth; depth++ {
+		sch, ok := schema.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if def, ok := sch["default"]; ok {
+			return def, true
+		}
+		ref, ok := sch["$ref"].(string)
+		if !ok {
+			return nil, false
+		}
+		if schema, ok = s.resolve(ref); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
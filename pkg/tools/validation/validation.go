@@ -0,0 +1,743 @@
+// Package validation validates JSON values against JSON Schemas. It covers
+// the validation vocabulary of draft 2020-12 that tool schemas use: types,
+// enums and consts, object, array, string and number constraints, the
+// allOf/anyOf/oneOf/not/if and dependentSchemas applicators,
+// unevaluatedProperties and unevaluatedItems, and local $ref into $defs,
+// definitions or a $anchor. References to other documents are ignored and
+// formats are treated as annotations and not checked.
+//
+// Before validating tool arguments, Coerce converts string-encoded numbers
+// and booleans and ApplyDefaults fills in missing properties.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxDepth bounds the nesting of schemas applied to a value, to stop $ref
+// cycles that don't descend into the value.
+const maxDepth = 128
+
+// Violation is a single way in which a value doesn't match its schema
+type Violation struct {
+	// Path is the JSON pointer of the offending value, "" for the value itself
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError lists all the violations found in a value
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Schema is a parsed JSON Schema
+type Schema struct {
+	root     interface{}
+	patterns sync.Map // pattern string -> *regexp.Regexp, nil if not RE2
+}
+
+// Compile parses schema. An empty schema accepts every value.
+func Compile(schema json.RawMessage) (*Schema, error) {
+	s := &Schema{root: true}
+	if len(schema) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(schema, &s.root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	switch s.root.(type) {
+	case bool, map[string]interface{}:
+		return s, nil
+	default:
+		return nil, fmt.Errorf("invalid JSON schema: expected an object or a boolean")
+	}
+}
+
+// Validate checks value, which is converted to its JSON representation
+// first. It returns a *ValidationError listing every violation.
+func (s *Schema) Validate(value interface{}) error {
+	v, err := normalize(value)
+	if err != nil {
+		return err
+	}
+
+	var violations []Violation
+	s.validate(s.root, v, "", 0, &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// normalize converts value to the types produced by encoding/json
+func normalize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("value is not JSON serializable: %w", err)
+	}
+	var ret interface{}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Schema) validate(schema interface{}, v interface{}, path string, depth int, out *[]Violation) {
+	add := func(format string, args ...interface{}) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if depth > maxDepth {
+		add("schema nesting is too deep")
+		return
+	}
+
+	sch, ok := schema.(map[string]interface{})
+	if !ok {
+		if b, ok := schema.(bool); ok && !b {
+			add("no value is allowed")
+		}
+		return
+	}
+
+	// References to other documents can't be resolved and are ignored
+	if ref, ok := sch["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+		target, ok := s.resolve(ref)
+		if !ok {
+			add("unresolvable $ref %q", ref)
+		} else {
+			s.validate(target, v, path, depth+1, out)
+		}
+	}
+
+	if t, ok := sch["type"]; ok && !matchesType(t, v) {
+		add("expected %s, got %s", describeType(t), typeOf(v))
+		// The other keywords would only repeat the type mismatch
+		return
+	}
+	if enum, ok := sch["enum"].([]interface{}); ok && !containsValue(enum, v) {
+		add("must be one of %s", formatValues(enum))
+	}
+	if c, ok := sch["const"]; ok && !reflect.DeepEqual(c, v) {
+		add("must be %s", formatValue(c))
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		s.validateObject(sch, value, path, depth, out)
+	case []interface{}:
+		s.validateArray(sch, value, path, depth, out)
+	case string:
+		s.validateString(sch, value, add)
+	case float64:
+		validateNumber(sch, value, add)
+	}
+
+	s.validateCombinators(sch, v, path, depth, out)
+}
+
+func (s *Schema) validateObject(sch map[string]interface{}, obj map[string]interface{}, path string, depth int, out *[]Violation) {
+	add := func(format string, args ...interface{}) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if required, ok := sch["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				add("missing required property %q", name)
+			}
+		}
+	}
+	if deps, ok := sch["dependentRequired"].(map[string]interface{}); ok {
+		for name, required := range deps {
+			if _, ok := obj[name]; !ok {
+				continue
+			}
+			list, _ := required.([]interface{})
+			for _, r := range list {
+				other, _ := r.(string)
+				if _, ok := obj[other]; !ok {
+					add("property %q is required when %q is present", other, name)
+				}
+			}
+		}
+	}
+	if n, ok := number(sch["minProperties"]); ok && float64(len(obj)) < n {
+		add("must have at least %s properties", formatNumber(n))
+	}
+	if n, ok := number(sch["maxProperties"]); ok && float64(len(obj)) > n {
+		add("must have at most %s properties", formatNumber(n))
+	}
+
+	properties, _ := sch["properties"].(map[string]interface{})
+	patternProperties, _ := sch["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := sch["additionalProperties"]
+	propertyNames, hasPropertyNames := sch["propertyNames"]
+
+	for _, name := range sortedKeys(obj) {
+		value := obj[name]
+		childPath := path + "/" + escapePointer(name)
+
+		if hasPropertyNames {
+			var nameViolations []Violation
+			s.validate(propertyNames, name, childPath, depth+1, &nameViolations)
+			for _, v := range nameViolations {
+				add("invalid property name %q: %s", name, v.Message)
+			}
+		}
+
+		matched := false
+		if propSchema, ok := properties[name]; ok {
+			matched = true
+			s.validate(propSchema, value, childPath, depth+1, out)
+		}
+		for pattern, propSchema := range patternProperties {
+			if re := s.regexp(pattern); re != nil && re.MatchString(name) {
+				matched = true
+				s.validate(propSchema, value, childPath, depth+1, out)
+			}
+		}
+		if !matched && hasAdditional {
+			if b, ok := additional.(bool); ok && !b {
+				add("property %q is not allowed", name)
+			} else {
+				s.validate(additional, value, childPath, depth+1, out)
+			}
+		}
+	}
+
+	if deps, ok := sch["dependentSchemas"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(deps) {
+			if _, ok := obj[name]; ok {
+				s.validate(deps[name], obj, path, depth+1, out)
+			}
+		}
+	}
+
+	if unevaluated, ok := sch["unevaluatedProperties"]; ok {
+		evaluated := s.evaluatedProperties(sch, obj, depth)
+		for _, name := range sortedKeys(obj) {
+			if evaluated[name] {
+				continue
+			}
+			if b, ok := unevaluated.(bool); ok && !b {
+				add("property %q is not allowed", name)
+			} else {
+				s.validate(unevaluated, obj[name], path+"/"+escapePointer(name), depth+1, out)
+			}
+		}
+	}
+}
+
+// evaluatedProperties returns the properties of obj that sch and the
+// subschemas applying to obj evaluate, for unevaluatedProperties. The
+// unevaluatedProperties of sch itself is ignored.
+func (s *Schema) evaluatedProperties(sch map[string]interface{}, obj map[string]interface{}, depth int) map[string]bool {
+	ret := map[string]bool{}
+	if depth > maxDepth {
+		return ret
+	}
+
+	_, hasAdditional := sch["additionalProperties"]
+	properties, _ := sch["properties"].(map[string]interface{})
+	patternProperties, _ := sch["patternProperties"].(map[string]interface{})
+	for name := range obj {
+		if hasAdditional {
+			ret[name] = true
+			continue
+		}
+		if _, ok := properties[name]; ok {
+			ret[name] = true
+			continue
+		}
+		for pattern := range patternProperties {
+			if re := s.regexp(pattern); re != nil && re.MatchString(name) {
+				ret[name] = true
+				break
+			}
+		}
+	}
+
+	merge := func(schema interface{}) {
+		sub, ok := schema.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for name := range s.evaluatedProperties(sub, obj, depth+1) {
+			ret[name] = true
+		}
+		if _, ok := sub["unevaluatedProperties"]; ok {
+			for name := range obj {
+				ret[name] = true
+			}
+		}
+	}
+	s.forEachApplicator(sch, obj, depth, merge)
+	if deps, ok := sch["dependentSchemas"].(map[string]interface{}); ok {
+		for name, schema := range deps {
+			if _, ok := obj[name]; ok {
+				merge(schema)
+			}
+		}
+	}
+	return ret
+}
+
+// forEachApplicator calls f with the in-place subschemas of sch that apply
+// to v: the $ref target, allOf, the anyOf and oneOf schemas v matches, and
+// if with then or else.
+func (s *Schema) forEachApplicator(sch map[string]interface{}, v interface{}, depth int, f func(schema interface{})) {
+	if ref, ok := sch["$ref"].(string); ok {
+		if target, ok := s.resolve(ref); ok {
+			f(target)
+		}
+	}
+	if all, ok := sch["allOf"].([]interface{}); ok {
+		for _, schema := range all {
+			f(schema)
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if list, ok := sch[keyword].([]interface{}); ok {
+			for _, schema := range list {
+				if s.matches(schema, v, depth) {
+					f(schema)
+				}
+			}
+		}
+	}
+	if cond, ok := sch["if"]; ok {
+		if s.matches(cond, v, depth) {
+			f(cond)
+			if then, ok := sch["then"]; ok {
+				f(then)
+			}
+		} else if els, ok := sch["else"]; ok {
+			f(els)
+		}
+	}
+}
+
+// matches reports whether v is valid against schema
+func (s *Schema) matches(schema interface{}, v interface{}, depth int) bool {
+	var violations []Violation
+	s.validate(schema, v, "", depth+1, &violations)
+	return len(violations) == 0
+}
+
+func (s *Schema) validateArray(sch map[string]interface{}, arr []interface{}, path string, depth int, out *[]Violation) {
+	add := func(format string, args ...interface{}) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n, ok := number(sch["minItems"]); ok && float64(len(arr)) < n {
+		add("must have at least %s items", formatNumber(n))
+	}
+	if n, ok := number(sch["maxItems"]); ok && float64(len(arr)) > n {
+		add("must have at most %s items", formatNumber(n))
+	}
+	if unique, _ := sch["uniqueItems"].(bool); unique {
+	outer:
+		for i := range arr {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(arr[i], arr[j]) {
+					add("items %d and %d are equal, items must be unique", j, i)
+					break outer
+				}
+			}
+		}
+	}
+
+	prefix, _ := sch["prefixItems"].([]interface{})
+	for i, item := range arr {
+		itemPath := path + "/" + strconv.Itoa(i)
+		if i < len(prefix) {
+			s.validate(prefix[i], item, itemPath, depth+1, out)
+			continue
+		}
+		switch items := sch["items"].(type) {
+		case map[string]interface{}, bool:
+			s.validate(items, item, itemPath, depth+1, out)
+		case []interface{}:
+			// items as an array is the pre-2020-12 spelling of prefixItems
+			if i < len(items) {
+				s.validate(items[i], item, itemPath, depth+1, out)
+			}
+		}
+	}
+
+	if contains, ok := sch["contains"]; ok {
+		count := 0
+		for _, item := range arr {
+			var itemViolations []Violation
+			s.validate(contains, item, path, depth+1, &itemViolations)
+			if len(itemViolations) == 0 {
+				count++
+			}
+		}
+		minContains, ok := number(sch["minContains"])
+		if !ok {
+			minContains = 1
+		}
+		if float64(count) < minContains {
+			add("must contain at least %s matching items, found %d", formatNumber(minContains), count)
+		}
+		if maxContains, ok := number(sch["maxContains"]); ok && float64(count) > maxContains {
+			add("must contain at most %s matching items, found %d", formatNumber(maxContains), count)
+		}
+	}
+
+	if unevaluated, ok := sch["unevaluatedItems"]; ok {
+		evaluated := s.evaluatedItems(sch, arr, depth)
+		for i, item := range arr {
+			if evaluated[i] {
+				continue
+			}
+			if b, ok := unevaluated.(bool); ok && !b {
+				add("item %d is not allowed", i)
+			} else {
+				s.validate(unevaluated, item, path+"/"+strconv.Itoa(i), depth+1, out)
+			}
+		}
+	}
+}
+
+// evaluatedItems marks the items of arr that sch and the subschemas applying
+// to arr evaluate, for unevaluatedItems. The unevaluatedItems of sch itself
+// is ignored.
+func (s *Schema) evaluatedItems(sch map[string]interface{}, arr []interface{}, depth int) []bool {
+	ret := make([]bool, len(arr))
+	if depth > maxDepth {
+		return ret
+	}
+
+	mark := func(n int) {
+		for i := 0; i < n && i < len(arr); i++ {
+			ret[i] = true
+		}
+	}
+	if prefix, ok := sch["prefixItems"].([]interface{}); ok {
+		mark(len(prefix))
+	}
+	switch items := sch["items"].(type) {
+	case map[string]interface{}, bool:
+		mark(len(arr))
+	case []interface{}:
+		mark(len(items))
+	}
+	if contains, ok := sch["contains"]; ok {
+		for i, item := range arr {
+			if s.matches(contains, item, depth) {
+				ret[i] = true
+			}
+		}
+	}
+
+	s.forEachApplicator(sch, arr, depth, func(schema interface{}) {
+		sub, ok := schema.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if _, ok := sub["unevaluatedItems"]; ok {
+			mark(len(arr))
+			return
+		}
+		for i, evaluated := range s.evaluatedItems(sub, arr, depth+1) {
+			ret[i] = ret[i] || evaluated
+		}
+	})
+	return ret
+}
+
+func (s *Schema) validateString(sch map[string]interface{}, str string, add func(string, ...interface{})) {
+	length := float64(utf8.RuneCountInString(str))
+	if n, ok := number(sch["minLength"]); ok && length < n {
+		add("must be at least %s characters long", formatNumber(n))
+	}
+	if n, ok := number(sch["maxLength"]); ok && length > n {
+		add("must be at most %s characters long", formatNumber(n))
+	}
+	if pattern, ok := sch["pattern"].(string); ok {
+		if re := s.regexp(pattern); re != nil && !re.MatchString(str) {
+			add("must match pattern %q", pattern)
+		}
+	}
+}
+
+func validateNumber(sch map[string]interface{}, n float64, add func(string, ...interface{})) {
+	if minimum, ok := number(sch["minimum"]); ok && n < minimum {
+		add("must be >= %s", formatNumber(minimum))
+	}
+	if maximum, ok := number(sch["maximum"]); ok && n > maximum {
+		add("must be <= %s", formatNumber(maximum))
+	}
+	if minimum, ok := number(sch["exclusiveMinimum"]); ok && n <= minimum {
+		add("must be > %s", formatNumber(minimum))
+	}
+	if maximum, ok := number(sch["exclusiveMaximum"]); ok && n >= maximum {
+		add("must be < %s", formatNumber(maximum))
+	}
+	if m, ok := number(sch["multipleOf"]); ok && m > 0 {
+		q := n / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			add("must be a multiple of %s", formatNumber(m))
+		}
+	}
+}
+
+func (s *Schema) validateCombinators(sch map[string]interface{}, v interface{}, path string, depth int, out *[]Violation) {
+	add := func(format string, args ...interface{}) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	matches := func(schema interface{}) bool {
+		return s.matches(schema, v, depth)
+	}
+
+	if all, ok := sch["allOf"].([]interface{}); ok {
+		for _, schema := range all {
+			s.validate(schema, v, path, depth+1, out)
+		}
+	}
+	if anyOf, ok := sch["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, schema := range anyOf {
+			if matches(schema) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			add("must match at least one of the anyOf schemas")
+		}
+	}
+	if oneOf, ok := sch["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, schema := range oneOf {
+			if matches(schema) {
+				count++
+			}
+		}
+		if count != 1 {
+			add("must match exactly one of the oneOf schemas, matched %d", count)
+		}
+	}
+	if not, ok := sch["not"]; ok && matches(not) {
+		add("must not match the not schema")
+	}
+	if cond, ok := sch["if"]; ok {
+		if matches(cond) {
+			if then, ok := sch["then"]; ok {
+				s.validate(then, v, path, depth+1, out)
+			}
+		} else if els, ok := sch["else"]; ok {
+			s.validate(els, v, path, depth+1, out)
+		}
+	}
+}
+
+// resolve looks up a local reference: "#", a "#/..." JSON pointer or a
+// "#name" $anchor
+func (s *Schema) resolve(ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	ptr := strings.TrimPrefix(ref, "#")
+	cur := s.root
+	if ptr == "" {
+		return cur, true
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return findAnchor(s.root, ptr)
+	}
+	for _, token := range strings.Split(ptr[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			next, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			cur = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// findAnchor searches schema for the subschema declaring the $anchor name
+func findAnchor(schema interface{}, name string) (interface{}, bool) {
+	switch node := schema.(type) {
+	case map[string]interface{}:
+		if anchor, ok := node["$anchor"].(string); ok && anchor == name {
+			return node, true
+		}
+		for _, key := range sortedKeys(node) {
+			// Skip the keywords whose values are data, not schemas
+			if key == "enum" || key == "const" || key == "default" || key == "examples" {
+				continue
+			}
+			if found, ok := findAnchor(node[key], name); ok {
+				return found, true
+			}
+		}
+	case []interface{}:
+		for _, item := range node {
+			if found, ok := findAnchor(item, name); ok {
+				return found, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// regexp compiles pattern once. Patterns that aren't valid RE2 (e.g. using
+// lookaheads) are ignored.
+func (s *Schema) regexp(pattern string) *regexp.Regexp {
+	if re, ok := s.patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+	s.patterns.Store(pattern, re)
+	return re
+}
+
+func matchesType(t interface{}, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, v)
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, v) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func isType(name string, v interface{}) bool {
+	switch name {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	default:
+		return typeOf(v) == name
+	}
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func formatValues(list []interface{}) string {
+	values := make([]string, len(list))
+	for i, v := range list {
+		values[i] = formatValue(v)
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+    "count": {"type": "integer", "minimum": 0, "maximum": 10},
+    "mode": {"enum": ["fast", "slow"]},
+    "tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+    "node": {"$ref": "#/$defs/node"}
+  },
+  "required": ["name", "count"],
+  "additionalProperties": false,
+  "$defs": {
+    "node": {
+      "type": "object",
+      "properties": {
+        "value": {"type": "number"},
+        "children": {"type": "array", "items": {"$ref": "#/$defs/node"}}
+      },
+      "required": ["value"]
+    }
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile(json.RawMessage(testSchema))
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate(map[string]interface{}{
+		"name":  "abc",
+		"count": 3,
+		"mode":  "fast",
+		"tags":  []string{"a", "b"},
+		"node": map[string]interface{}{
+			"value":    1.5,
+			"children": []interface{}{map[string]interface{}{"value": 2}},
+		},
+	}))
+
+	err = schema.Validate(map[string]interface{}{
+		"name":  "ABC",
+		"count": 2.5,
+		"mode":  "medium",
+		"tags":  []string{"a", "a"},
+		"extra": true,
+		"node": map[string]interface{}{
+			"children": []interface{}{map[string]interface{}{"value": "x"}},
+		},
+	})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Equal(t, []Violation{
+		{Path: "/count", Message: "expected integer, got number"},
+		{Path: "", Message: `property "extra" is not allowed`},
+		{Path: "/mode", Message: `must be one of ["fast", "slow"]`},
+		{Path: "/name", Message: `must match pattern "^[a-z]+$"`},
+		{Path: "/node", Message: `missing required property "value"`},
+		{Path: "/node/children/0/value", Message: "expected number, got string"},
+		{Path: "/tags", Message: "items 0 and 1 are equal, items must be unique"},
+	}, validationErr.Violations)
+
+	err = schema.Validate(map[string]interface{}{})
+	require.EqualError(t, err, `missing required property "name"; missing required property "count"`)
+}
+
+func TestCombinators(t *testing.T) {
+	schema, err := Compile(json.RawMessage(`{
+		"oneOf": [{"type": "string"}, {"type": "integer", "exclusiveMinimum": 0}],
+		"not": {"const": "forbidden"}
+	}`))
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate("ok"))
+	require.NoError(t, schema.Validate(3))
+	require.Error(t, schema.Validate(0))
+	require.Error(t, schema.Validate("forbidden"))
+	require.Error(t, schema.Validate(true))
+}
+
+func TestEmptySchema(t *testing.T) {
+	schema, err := Compile(nil)
+	require.NoError(t, err)
+	require.NoError(t, schema.Validate(map[string]interface{}{"a": 1}))
+
+	_, err = Compile(json.RawMessage(`"string"`))
+	require.Error(t, err)
+}
+
+func TestUnevaluated(t *testing.T) {
+	schema, err := Compile(json.RawMessage(`{
+		"type": "object",
+		"properties": {"kind": {"enum": ["file", "url"]}},
+		"allOf": [{"$ref": "#location"}],
+		"if": {"properties": {"kind": {"const": "url"}}},
+		"then": {"properties": {"timeout": {"type": "integer"}}},
+		"dependentSchemas": {"path": {"properties": {"mode": {"type": "string"}}}},
+		"unevaluatedProperties": false,
+		"$defs": {
+			"location": {"$anchor": "location", "properties": {"path": {"type": "string"}, "url": {"type": "string"}}}
+		}
+	}`))
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate(map[string]interface{}{"kind": "url", "url": "https://example.com", "timeout": 3}))
+	require.NoError(t, schema.Validate(map[string]interface{}{"kind": "file", "path": "/tmp/x", "mode": "r"}))
+	require.EqualError(t, schema.Validate(map[string]interface{}{"kind": "file", "timeout": 3, "mode": "r"}),
+		`property "mode" is not allowed; property "timeout" is not allowed`)
+
+	schema, err = Compile(json.RawMessage(`{
+		"type": "array",
+		"prefixItems": [{"type": "string"}],
+		"contains": {"type": "boolean"},
+		"unevaluatedItems": {"type": "integer"}
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, schema.Validate([]interface{}{"a", true, 1, false, 2}))
+	require.EqualError(t, schema.Validate([]interface{}{"a", true, "b"}), "/2: expected integer, got string")
+}
+
+func TestCoerceAndApplyDefaults(t *testing.T) {
+	schema, err := Compile(json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"count": {"type": "integer", "default": 1},
+			"ratio": {"type": "number"},
+			"verbose": {"type": "boolean", "default": false},
+			"name": {"type": ["string", "integer"]},
+			"ids": {"type": "array", "items": {"type": "integer"}},
+			"options": {
+				"type": "object",
+				"properties": {"depth": {"$ref": "#/$defs/depth"}},
+				"default": {}
+			}
+		},
+		"$defs": {"depth": {"type": "integer", "default": 2}}
+	}`))
+	require.NoError(t, err)
+
+	v, err := schema.Coerce(map[string]interface{}{
+		"count":   "3",
+		"ratio":   " 0.5 ",
+		"verbose": "TRUE",
+		"name":    "42",
+		"ids":     []string{"1", "x", "2.5"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"count":   3.0,
+		"ratio":   0.5,
+		"verbose": true,
+		"name":    "42",
+		"ids":     []interface{}{1.0, "x", "2.5"},
+	}, v)
+
+	v, err = schema.ApplyDefaults(map[string]interface{}{"count": 5.0})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"count":   5.0,
+		"verbose": false,
+		"options": map[string]interface{}{"depth": 2.0},
+	}, v)
+}
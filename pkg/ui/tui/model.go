@@ -611,10 +611,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 					// Get tool and prompt paths
 					toolDirs, toolFiles, promptDirs, promptFiles := m.profileFormState.GetToolsAndPrompts()
+					resourceDirs := m.profileFormState.GetResourceDirectories()
 
 					isNewProfile := m.profileFormState.isAddMode
 					m.mode = modeList // Return to list view
-					return m, m.saveProfile(name, description, toolDirs, toolFiles, promptDirs, promptFiles, isNewProfile)
+					return m, m.saveProfile(name, description, toolDirs, toolFiles, promptDirs, promptFiles, resourceDirs, isNewProfile)
 				}
 
 				if m.profileFormState.cancelled {
@@ -1227,7 +1228,7 @@ func (m *Model) loadProfiles() tea.Cmd {
 }
 
 // saveProfile adds or updates a profile in the config
-func (m *Model) saveProfile(name, description string, toolDirs, toolFiles, promptDirs, promptFiles []string, isNewProfile bool) tea.Cmd {
+func (m *Model) saveProfile(name, description string, toolDirs, toolFiles, promptDirs, promptFiles, resourceDirs []string, isNewProfile bool) tea.Cmd {
 	return func() tea.Msg {
 		if m.profileEditor == nil {
 			return profileSavedMsg{err: fmt.Errorf("no profile editor initialized")}
@@ -1310,6 +1311,16 @@ func (m *Model) saveProfile(name, description string, toolDirs, toolFiles, promp
 			}
 		}
 
+		// Add resource directories if provided
+		for _, dir := range resourceDirs {
+			if dir != "" {
+				err = m.profileEditor.AddResourceDirectory(name, config.ResourceDirectory{Path: dir})
+				if err != nil {
+					return profileSavedMsg{err: fmt.Errorf("could not add resource directory %s: %w", dir, err)}
+				}
+			}
+		}
+
 		// Save changes to the config file
 		err = m.profileEditor.Save()
 		if err != nil {
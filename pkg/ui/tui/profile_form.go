@@ -45,6 +45,7 @@ const (
 	focusToolFiles
 	focusPromptDirs
 	focusPromptFiles
+	focusResourceDirs
 	focusProfileMax // Keep track of the total number of potential focus points
 )
 
@@ -52,17 +53,18 @@ type focusProfileField int
 
 // ProfileFormModel represents the form for adding/editing profiles
 type ProfileFormModel struct {
-	keyMap           ProfileFormKeyMap
-	nameInput        textinput.Model
-	descriptionInput textinput.Model
-	toolDirsInput    textinput.Model
-	toolFilesInput   textinput.Model
-	promptDirsInput  textinput.Model
-	promptFilesInput textinput.Model
-	activeInput      focusProfileField
-	isAddMode        bool // True if adding a new profile, false if editing
-	submitted        bool // Flag indicating form submission was triggered
-	cancelled        bool // Flag indicating form cancellation was triggered
+	keyMap            ProfileFormKeyMap
+	nameInput         textinput.Model
+	descriptionInput  textinput.Model
+	toolDirsInput     textinput.Model
+	toolFilesInput    textinput.Model
+	promptDirsInput   textinput.Model
+	promptFilesInput  textinput.Model
+	resourceDirsInput textinput.Model
+	activeInput       focusProfileField
+	isAddMode         bool // True if adding a new profile, false if editing
+	submitted         bool // Flag indicating form submission was triggered
+	cancelled         bool // Flag indicating form cancellation was triggered
 }
 
 // NewProfileFormModel creates a new profile form model with initialized inputs
@@ -98,16 +100,22 @@ func NewProfileFormModel() ProfileFormModel {
 	promptFilesInput.CharLimit = 500
 	promptFilesInput.Width = 80
 
+	resourceDirsInput := textinput.New()
+	resourceDirsInput.Placeholder = "Resource directories (comma separated paths)"
+	resourceDirsInput.CharLimit = 500
+	resourceDirsInput.Width = 80
+
 	return ProfileFormModel{
-		keyMap:           defaultProfileFormKeyMap,
-		nameInput:        nameInput,
-		descriptionInput: descriptionInput,
-		toolDirsInput:    toolDirsInput,
-		toolFilesInput:   toolFilesInput,
-		promptDirsInput:  promptDirsInput,
-		promptFilesInput: promptFilesInput,
-		activeInput:      focusProfileName,
-		isAddMode:        true, // Default to add mode
+		keyMap:            defaultProfileFormKeyMap,
+		nameInput:         nameInput,
+		descriptionInput:  descriptionInput,
+		toolDirsInput:     toolDirsInput,
+		toolFilesInput:    toolFilesInput,
+		promptDirsInput:   promptDirsInput,
+		promptFilesInput:  promptFilesInput,
+		resourceDirsInput: resourceDirsInput,
+		activeInput:       focusProfileName,
+		isAddMode:         true, // Default to add mode
 	}
 }
 
@@ -129,6 +137,7 @@ func (m ProfileFormModel) Update(msg tea.Msg) (ProfileFormModel, tea.Cmd) {
 			m.toolFilesInput.Blur()
 			m.promptDirsInput.Blur()
 			m.promptFilesInput.Blur()
+			m.resourceDirsInput.Blur()
 			return m, nil
 
 		case key.Matches(msg, m.keyMap.Submit):
@@ -176,6 +185,10 @@ func (m ProfileFormModel) Update(msg tea.Msg) (ProfileFormModel, tea.Cmd) {
 			m.promptFilesInput, cmd = m.promptFilesInput.Update(msg)
 			cmds = append(cmds, cmd)
 			return m, tea.Batch(cmds...)
+		case focusResourceDirs:
+			m.resourceDirsInput, cmd = m.resourceDirsInput.Update(msg)
+			cmds = append(cmds, cmd)
+			return m, tea.Batch(cmds...)
 		case focusProfileMax:
 			// This should never happen as focusProfileMax is just a sentinel value
 			// Do nothing in this case
@@ -194,6 +207,7 @@ func (m *ProfileFormModel) updateFocus() tea.Cmd {
 	m.toolFilesInput.Blur()
 	m.promptDirsInput.Blur()
 	m.promptFilesInput.Blur()
+	m.resourceDirsInput.Blur()
 
 	// Initialize command slice
 	var cmds []tea.Cmd
@@ -212,6 +226,8 @@ func (m *ProfileFormModel) updateFocus() tea.Cmd {
 		cmds = append(cmds, m.promptDirsInput.Focus())
 	case focusPromptFiles:
 		cmds = append(cmds, m.promptFilesInput.Focus())
+	case focusResourceDirs:
+		cmds = append(cmds, m.resourceDirsInput.Focus())
 	case focusProfileMax:
 		// This should never happen as focusProfileMax is just a sentinel value
 		// Do nothing in this case
@@ -253,7 +269,13 @@ func (m ProfileFormModel) View() string {
 	sb.WriteString(m.promptDirsInput.View() + "\n")
 
 	// Prompt files
-	sb.WriteString(m.promptFilesInput.View() + "\n")
+	sb.WriteString(m.promptFilesInput.View() + "\n\n")
+
+	// Resource configuration section
+	sb.WriteString(labelStyle.Render("Resources Configuration") + "\n")
+
+	// Resource directories
+	sb.WriteString(m.resourceDirsInput.View() + "\n")
 
 	sb.WriteString("\n")
 	sb.WriteString(m.helpView())
@@ -329,3 +351,8 @@ func (m *ProfileFormModel) GetToolsAndPrompts() ([]string, []string, []string, [
 	promptFiles := m.ParsePathList(m.promptFilesInput.Value())
 	return toolDirs, toolFiles, promptDirs, promptFiles
 }
+
+// GetResourceDirectories retrieves the resource directories from the form
+func (m *ProfileFormModel) GetResourceDirectories() []string {
+	return m.ParsePathList(m.resourceDirsInput.Value())
+}